@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/kaminocorp/lumber/internal/config"
+	"github.com/kaminocorp/lumber/internal/engine/classifier"
+	"github.com/kaminocorp/lumber/internal/eval"
+	"github.com/kaminocorp/lumber/internal/logging"
+)
+
+// runCalibrate implements "lumber calibrate": fit a softmax temperature and
+// per-label thresholds on a labeled corpus and write the calibration file.
+func runCalibrate(args []string) (int, error) {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	corpusPath := fs.String("corpus", "", "Labeled corpus, JSON array or NDJSON (default: built-in corpus)")
+	taxonomyPath := fs.String("taxonomy", cfg.Engine.TaxonomyPath, "Custom taxonomy file (.json, .yaml)")
+	precision := fs.Float64("precision", 0.95, "Target precision per label (0-1)")
+	minSupport := fs.Int("min-support", 3, "Min corpus predictions per label to fit its threshold")
+	softmax := fs.Bool("softmax", true, "Fit a softmax temperature; thresholds become calibrated probabilities")
+	out := fs.String("out", "calibration.json", "Output calibration file")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `Usage: lumber calibrate [flags]
+
+Fits per-label confidence thresholds (and a softmax temperature) on a labeled
+corpus and writes them to a file for -calibration / LUMBER_CALIBRATION_PATH.
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, nil
+		}
+		return 2, nil
+	}
+	if *precision <= 0 || *precision > 1 {
+		return 2, fmt.Errorf("-precision must be in (0, 1], got %g", *precision)
+	}
+
+	// Fit against the uncalibrated engine.
+	cfg.Engine.TaxonomyPath = *taxonomyPath
+	cfg.Engine.CalibrationPath = ""
+	logging.Init(true, logging.ParseLevel(cfg.LogLevel))
+
+	if err := cfg.Validate(); err != nil {
+		return 1, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	entries, err := loadEvalCorpus(*corpusPath, cfg.Engine.SeedExamples)
+	if err != nil {
+		return 1, err
+	}
+
+	eng, emb, err := newEngine(cfg)
+	if err != nil {
+		return 1, err
+	}
+	defer emb.Close()
+
+	cls := classifier.New(0)
+	cls.Scoring = classifier.Scoring(cfg.Engine.Scoring)
+	result, err := eval.Calibrate(emb, cls, eng.Labels(), entries, eval.CalibrateOptions{
+		TargetPrecision: *precision,
+		MinSupport:      *minSupport,
+		Softmax:         *softmax,
+	})
+	if err != nil {
+		return 1, err
+	}
+
+	skipped := make([]string, 0, len(result.Skipped))
+	for path := range result.Skipped {
+		skipped = append(skipped, path)
+	}
+	sort.Strings(skipped)
+	for _, path := range skipped {
+		slog.Debug("label threshold not fitted", "label", path, "reason", result.Skipped[path])
+	}
+
+	cal := result.Calibration
+	if err := cal.Save(*out); err != nil {
+		return 1, fmt.Errorf("writing calibration: %w", err)
+	}
+	slog.Info("calibration written", "path", *out, "entries", len(entries),
+		"temperature", cal.Temperature, "threshold", cal.Threshold,
+		"label_thresholds", len(cal.Thresholds), "skipped", len(skipped))
+	return 0, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/kaminocorp/lumber/internal/config"
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/eval"
+	"github.com/kaminocorp/lumber/internal/logging"
+)
+
+// runEval implements "lumber eval": classify a labeled corpus and report
+// accuracy, per-type and per-label precision/recall/F1, and confusions.
+func runEval(args []string) (int, error) {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	corpusPath := fs.String("corpus", "", "Labeled corpus, JSON array or NDJSON (default: built-in corpus)")
+	taxonomyPath := fs.String("taxonomy", cfg.Engine.TaxonomyPath, "Custom taxonomy file (.json, .yaml)")
+	rulesPath := fs.String("rules", cfg.Engine.RulesPath, "Pre-classification rules file (.json, .yaml)")
+	calibrationPath := fs.String("calibration", cfg.Engine.CalibrationPath, "Calibration file from 'lumber calibrate'")
+	threshold := fs.Float64("threshold", cfg.Engine.ConfidenceThreshold, "Confidence threshold (0-1)")
+	jsonOut := fs.Bool("json", false, "Write the full report as JSON")
+	misses := fs.Int("misses", 20, "Number of worst misclassifications to list")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `Usage: lumber eval [flags]
+
+Runs a labeled corpus through the engine and reports classification quality.
+Corpus entries use the fields raw, expected_type, expected_category.
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, nil
+		}
+		return 2, nil
+	}
+
+	cfg.Engine.TaxonomyPath = *taxonomyPath
+	cfg.Engine.ConfidenceThreshold = *threshold
+	cfg.Engine.CalibrationPath = *calibrationPath
+	cfg.Engine.RulesPath = *rulesPath
+	logging.Init(true, logging.ParseLevel(cfg.LogLevel))
+
+	if err := cfg.Validate(); err != nil {
+		return 1, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	entries, err := loadEvalCorpus(*corpusPath, cfg.Engine.SeedExamples)
+	if err != nil {
+		return 1, err
+	}
+
+	eng, emb, err := newEngine(cfg)
+	if err != nil {
+		return 1, err
+	}
+	defer emb.Close()
+
+	slog.Info("evaluating", "entries", len(entries), "threshold", cfg.Engine.ConfidenceThreshold)
+	report, err := eval.Run(eng, entries)
+	if err != nil {
+		return 1, err
+	}
+
+	if *jsonOut {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout, *misses)
+	}
+	if err != nil {
+		return 1, fmt.Errorf("writing report: %w", err)
+	}
+	return 0, nil
+}
+
+// loadEvalCorpus reads the corpus at path, or the built-in corpus when empty.
+// The built-in corpus is refused when it is also seeded as label examples:
+// every entry would match itself, inflating accuracy and thresholds.
+func loadEvalCorpus(path string, seeded bool) ([]corpus.CorpusEntry, error) {
+	if path == "" {
+		if seeded {
+			return nil, errors.New("the built-in corpus is seeded as label examples (LUMBER_SEED_EXAMPLES); unset it or pass -corpus with a held-out corpus")
+		}
+		return corpus.LoadCorpus()
+	}
+	return eval.LoadFile(path)
+}
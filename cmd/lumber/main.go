@@ -8,19 +8,27 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/kaminocorp/lumber/internal/cli"
 	"github.com/kaminocorp/lumber/internal/config"
 	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/corpus"
 	"github.com/kaminocorp/lumber/internal/engine"
+	"github.com/kaminocorp/lumber/internal/engine/attributes"
 	"github.com/kaminocorp/lumber/internal/engine/classifier"
 	"github.com/kaminocorp/lumber/internal/engine/compactor"
 	"github.com/kaminocorp/lumber/internal/engine/dedup"
+	"github.com/kaminocorp/lumber/internal/engine/drain"
 	"github.com/kaminocorp/lumber/internal/engine/embedder"
+	"github.com/kaminocorp/lumber/internal/engine/redact"
+	"github.com/kaminocorp/lumber/internal/engine/rules"
+	"github.com/kaminocorp/lumber/internal/engine/severity"
 	"github.com/kaminocorp/lumber/internal/engine/taxonomy"
 	"github.com/kaminocorp/lumber/internal/logging"
+	"github.com/kaminocorp/lumber/internal/model"
 	"github.com/kaminocorp/lumber/internal/output"
 	"github.com/kaminocorp/lumber/internal/output/async"
 	"github.com/kaminocorp/lumber/internal/output/file"
@@ -38,13 +46,33 @@ import (
 )
 
 func main() {
-	code, err := run()
+	var code int
+	var err error
+	switch subcommand() {
+	case "eval":
+		code, err = runEval(os.Args[2:])
+	case "calibrate":
+		code, err = runCalibrate(os.Args[2:])
+	case "templates":
+		code, err = runTemplates(os.Args[2:])
+	default:
+		code, err = run()
+	}
 	if err != nil {
 		slog.Error("fatal", "error", err)
 	}
 	os.Exit(code)
 }
 
+// subcommand returns the first argument when it names a subcommand rather
+// than a flag, or "" for the default pipeline.
+func subcommand() string {
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		return ""
+	}
+	return os.Args[1]
+}
+
 func run() (int, error) {
 	cfg := config.LoadWithFlags()
 
@@ -92,29 +120,29 @@ func run() (int, error) {
 		return 1, fmt.Errorf("invalid configuration: %w", err)
 	}
 
-	// Initialize embedder.
-	emb, err := embedder.New(cfg.Engine.ModelPath, cfg.Engine.VocabPath, cfg.Engine.ProjectionPath)
+	eng, emb, err := newEngine(cfg)
 	if err != nil {
-		return 1, fmt.Errorf("creating embedder: %w", err)
+		return 1, err
 	}
 	defer emb.Close()
-	slog.Info("embedder loaded", "model", cfg.Engine.ModelPath, "dim", emb.EmbedDim())
-
-	// Initialize taxonomy with default labels.
-	t0 := time.Now()
-	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
-	if err != nil {
-		return 1, fmt.Errorf("creating taxonomy: %w", err)
+	if cfg.Engine.CacheSize > 0 {
+		defer func() {
+			s := eng.CacheStats()
+			slog.Info("template cache", "hits", s.Hits, "misses", s.Misses,
+				"hit_rate", fmt.Sprintf("%.1f%%", s.HitRate()*100), "entries", s.Entries)
+		}()
+	}
+	defer func() {
+		s := eng.TokenStats()
+		slog.Info("tokens", "raw", s.Raw, "compacted", s.Compacted,
+			"reduction", fmt.Sprintf("%.1f%%", s.Reduction()*100))
+	}()
+	if eng.RedactionCounts() != nil {
+		defer func() {
+			slog.Info("redactions", "counts", eng.RedactionCounts())
+		}()
 	}
-	slog.Info("taxonomy pre-embedded", "labels", len(tax.Labels()), "duration", time.Since(t0).Round(time.Millisecond))
-
-	// Initialize classifier and compactor.
 	verbosity := parseVerbosity(cfg.Engine.Verbosity)
-	cls := classifier.New(cfg.Engine.ConfidenceThreshold)
-	cmp := compactor.New(verbosity)
-
-	// Initialize engine.
-	eng := engine.New(emb, tax, cls, cmp)
 
 	// Initialize output(s).
 	var outputs []output.Output
@@ -163,13 +191,36 @@ func run() (int, error) {
 	// Build pipeline with optional dedup.
 	var pipeOpts []pipeline.Option
 	if cfg.Engine.DedupWindow > 0 {
-		d := dedup.New(dedup.Config{Window: cfg.Engine.DedupWindow})
+		d := dedup.New(dedup.Config{
+			Window:       cfg.Engine.DedupWindow,
+			Keys:         cfg.Engine.DedupKeys,
+			Similarity:   cfg.Engine.DedupSimilarity,
+			Rollup:       cfg.Engine.DedupRollup,
+			ResolveAfter: cfg.Engine.DedupResolveAfter,
+		})
 		pipeOpts = append(pipeOpts, pipeline.WithDedup(d, cfg.Engine.DedupWindow))
-		slog.Info("dedup enabled", "window", cfg.Engine.DedupWindow)
+		if windows, _ := dedup.ParseWindows(cfg.Engine.DedupWindows); windows != nil {
+			pipeOpts = append(pipeOpts, pipeline.WithDedupWindows(windows))
+		}
+		if cfg.Engine.DedupRollup > 0 {
+			pipeOpts = append(pipeOpts, pipeline.WithStatefulDedup(dedup.NewTracker(d), time.Second))
+		}
+		slog.Info("dedup enabled", "window", cfg.Engine.DedupWindow, "keys", cfg.Engine.DedupKeys,
+			"similarity", cfg.Engine.DedupSimilarity, "rollup", cfg.Engine.DedupRollup)
 	}
 	if cfg.Engine.MaxBufferSize > 0 {
 		pipeOpts = append(pipeOpts, pipeline.WithMaxBufferSize(cfg.Engine.MaxBufferSize))
 	}
+	if cfg.Engine.BatchSize > 1 || cfg.Engine.Workers > 1 {
+		pipeOpts = append(pipeOpts,
+			pipeline.WithBatching(cfg.Engine.BatchSize, cfg.Engine.BatchLatency),
+			pipeline.WithWorkers(cfg.Engine.Workers))
+		slog.Debug("stream batching enabled", "batch_size", cfg.Engine.BatchSize,
+			"max_latency", cfg.Engine.BatchLatency, "workers", cfg.Engine.Workers)
+	}
+	if cfg.Engine.StatsInterval > 0 {
+		pipeOpts = append(pipeOpts, pipeline.WithStatsInterval(cfg.Engine.StatsInterval))
+	}
 	p := pipeline.New(conn, eng, out, pipeOpts...)
 	outputOwned = false // pipeline now owns the output via p.Close()
 	defer p.Close()
@@ -253,6 +304,166 @@ func run() (int, error) {
 	}
 }
 
+// newEngine loads the embedder and taxonomy and wires up the classification
+// engine described by cfg. The caller must close the returned embedder.
+func newEngine(cfg config.Config) (*engine.Engine, *embedder.ONNXEmbedder, error) {
+	// Resolve the taxonomy and calibration first so bad files fail before
+	// the model loads.
+	roots, err := loadRoots(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	var ruleSet *rules.Set
+	if cfg.Engine.RulesPath != "" {
+		ruleSet, err = rules.LoadFile(cfg.Engine.RulesPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading rules: %w", err)
+		}
+	}
+	var cal *classifier.Calibration
+	if cfg.Engine.CalibrationPath != "" {
+		c, err := classifier.LoadCalibration(cfg.Engine.CalibrationPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		cal = &c
+	}
+	var policy *compactor.Policy
+	if cfg.Engine.CompactionPolicy != "" {
+		if policy, err = compactor.LoadPolicy(cfg.Engine.CompactionPolicy); err != nil {
+			return nil, nil, err
+		}
+	}
+	counter, err := newTokenCounter(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Initialize embedder.
+	emb, err := embedder.New(cfg.Engine.ModelPath, cfg.Engine.VocabPath, cfg.Engine.ProjectionPath,
+		embedder.WithSessions(cfg.Engine.Sessions),
+		embedder.WithThreads(cfg.Engine.IntraOpThreads, cfg.Engine.InterOpThreads))
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating embedder: %w", err)
+	}
+	slog.Info("embedder loaded", "model", cfg.Engine.ModelPath, "dim", emb.EmbedDim(), "sessions", emb.Sessions())
+
+	t0 := time.Now()
+	tax, err := taxonomy.New(roots, emb)
+	if err != nil {
+		emb.Close()
+		return nil, nil, fmt.Errorf("creating taxonomy: %w", err)
+	}
+	slog.Info("taxonomy pre-embedded", "labels", len(tax.Labels()), "duration", time.Since(t0).Round(time.Millisecond))
+
+	// Initialize classifier and compactor.
+	cls := classifier.New(cfg.Engine.ConfidenceThreshold)
+	cls.Scoring = classifier.Scoring(cfg.Engine.Scoring)
+	cls.TopK = cfg.Engine.TopK
+	cls.AmbiguityMargin = cfg.Engine.AmbiguityMargin
+	if cal != nil {
+		cls.Calibrate(*cal)
+		slog.Info("calibration loaded", "path", cfg.Engine.CalibrationPath,
+			"temperature", cal.Temperature, "label_thresholds", len(cal.Thresholds))
+		if cal.Temperature > 0 {
+			for _, lbl := range tax.Labels() {
+				if lbl.Threshold > 0 {
+					slog.Warn("taxonomy label thresholds are ignored with a softmax calibration", "example", lbl.Path)
+					break
+				}
+			}
+		}
+	}
+	cmp := compactor.New(parseVerbosity(cfg.Engine.Verbosity), compactor.WithPolicy(policy),
+		compactor.WithTokenCounter(counter),
+		compactor.WithTokenBudget(cfg.Engine.TokenBudget, cfg.Engine.BatchTokenBudget))
+	if policy != nil {
+		slog.Info("compaction policy loaded", "path", cfg.Engine.CompactionPolicy, "rules", policy.Len())
+	}
+
+	// Initialize engine.
+	var engOpts []engine.Option
+	if ruleSet != nil {
+		if err := ruleSet.CheckPaths(tax.Labels()); err != nil {
+			emb.Close()
+			return nil, nil, fmt.Errorf("loading rules: %w", err)
+		}
+		engOpts = append(engOpts, engine.WithRules(ruleSet))
+		slog.Info("rules loaded", "path", cfg.Engine.RulesPath, "rules", ruleSet.Len())
+	}
+	if len(cfg.Engine.AttributesAllow) > 0 || len(cfg.Engine.AttributesDrop) > 0 {
+		engOpts = append(engOpts, engine.WithAttributes(attributes.New(
+			cfg.Engine.AttributesAllow, cfg.Engine.AttributesDrop, cmp.Verbosity == compactor.Full)))
+	}
+	engOpts = append(engOpts, engine.WithSeverityPolicy(severity.Policy(cfg.Engine.SeverityPolicy)))
+	if cfg.Engine.Templates {
+		engOpts = append(engOpts, engine.WithTemplates(drain.New(drain.Config{Similarity: cfg.Engine.TemplateSimilarity})))
+	}
+	if cfg.Engine.CacheSize > 0 {
+		engOpts = append(engOpts, engine.WithCache(cfg.Engine.CacheSize))
+		slog.Info("template cache enabled", "size", cfg.Engine.CacheSize)
+	}
+	if cfg.Engine.StackTraces {
+		engOpts = append(engOpts, engine.WithStackTraces())
+	}
+	if len(cfg.Engine.Redact) > 0 || len(cfg.Engine.RedactPatterns) > 0 {
+		red, err := redact.New(cfg.Engine.Redaction())
+		if err != nil {
+			emb.Close()
+			return nil, nil, fmt.Errorf("redaction: %w", err)
+		}
+		engOpts = append(engOpts, engine.WithRedaction(red))
+		slog.Info("redaction enabled", "detectors", cfg.Engine.Redact,
+			"patterns", len(cfg.Engine.RedactPatterns), "mode", cfg.Engine.RedactMode)
+	}
+	eng := engine.New(emb, tax, cls, cmp, engOpts...)
+	return eng, emb, nil
+}
+
+// newTokenCounter returns the configured token counter for compaction
+// budgets and token stats.
+func newTokenCounter(cfg config.Config) (compactor.TokenCounter, error) {
+	switch cfg.Engine.TokenCounter {
+	case "wordpiece":
+		wp, err := embedder.NewWordPiece(cfg.Engine.VocabPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading token counter: %w", err)
+		}
+		return wp, nil
+	case compactor.CL100K, compactor.O200K:
+		bpe, err := compactor.LoadBPE(cfg.Engine.TokenVocab, cfg.Engine.TokenCounter)
+		if err != nil {
+			return nil, fmt.Errorf("loading token counter: %w", err)
+		}
+		slog.Info("token vocabulary loaded", "encoding", cfg.Engine.TokenCounter, "path", cfg.Engine.TokenVocab)
+		return bpe, nil
+	default:
+		return compactor.Heuristic{}, nil
+	}
+}
+
+// loadRoots returns the taxonomy tree: built-in labels unless a custom file
+// is configured, optionally seeded with the labeled corpus as examples.
+func loadRoots(cfg config.Config) ([]*model.TaxonomyNode, error) {
+	roots := taxonomy.DefaultRoots()
+	if cfg.Engine.TaxonomyPath != "" {
+		var err error
+		roots, err = taxonomy.LoadFile(cfg.Engine.TaxonomyPath)
+		if err != nil {
+			return nil, fmt.Errorf("loading taxonomy: %w", err)
+		}
+		slog.Info("custom taxonomy loaded", "path", cfg.Engine.TaxonomyPath, "roots", len(roots))
+	}
+	if cfg.Engine.SeedExamples {
+		examples, err := corpus.Examples()
+		if err != nil {
+			return nil, fmt.Errorf("loading example corpus: %w", err)
+		}
+		roots = taxonomy.SeedExamples(roots, examples)
+	}
+	return roots, nil
+}
+
 func parseVerbosity(s string) compactor.Verbosity {
 	switch s {
 	case "minimal":
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/config"
+	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/engine/drain"
+	"github.com/kaminocorp/lumber/internal/logging"
+)
+
+// templateReport is the output of "lumber templates".
+type templateReport struct {
+	Lines     int             `json:"lines"`
+	Total     int             `json:"total_templates"`
+	Templates []drain.Cluster `json:"templates"`
+}
+
+// runTemplates implements "lumber templates": mine message templates from a
+// file, piped input or a connector query and report the most frequent ones.
+// No model is needed.
+func runTemplates(args []string) (int, error) {
+	cfg := config.Load()
+
+	fs := flag.NewFlagSet("templates", flag.ContinueOnError)
+	connFlag := fs.String("connector", cfg.Connector.Provider, "Connector: vercel, flyio, supabase, file, stdin")
+	fileInput := fs.String("file", cfg.Connector.Extra["file"], "Log file path (implies -connector file)")
+	from := fs.String("from", "", "Query start time (RFC3339)")
+	to := fs.String("to", "", "Query end time (RFC3339)")
+	limit := fs.Int("limit", 0, "Query result limit")
+	top := fs.Int("top", 20, "Number of templates to list (0 lists all)")
+	similarity := fs.Float64("similarity", cfg.Engine.TemplateSimilarity, "Token share a line needs to join a template (0-1]")
+	jsonOut := fs.Bool("json", false, "Write the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), `Usage: lumber templates [flags]
+
+Clusters log lines into message templates and lists the most frequent,
+e.g. "connection to <*> refused after <*>ms". Reads -file, piped input, or
+a connector; -from/-to/-limit run a query instead of a stream.
+
+Flags:
+`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0, nil
+		}
+		return 2, nil
+	}
+	if *similarity <= 0 || *similarity > 1 {
+		return 2, fmt.Errorf("-similarity must be in (0, 1], got %g", *similarity)
+	}
+	logging.Init(true, logging.ParseLevel(cfg.LogLevel))
+
+	provider := *connFlag
+	if *fileInput != "" && provider == "" {
+		provider = "file"
+	}
+	if provider == "" {
+		if isTerminal(os.Stdin) {
+			return 2, fmt.Errorf("no input: use -file, -connector, or pipe logs to stdin")
+		}
+		provider = "stdin"
+	}
+	ctor, err := connector.Get(provider)
+	if err != nil {
+		return 1, fmt.Errorf("getting connector: %w", err)
+	}
+	conn := ctor()
+
+	extra := make(map[string]string, len(cfg.Connector.Extra)+1)
+	for k, v := range cfg.Connector.Extra {
+		extra[k] = v
+	}
+	if *fileInput != "" {
+		extra["file"] = *fileInput
+	}
+	connCfg := connector.ConnectorConfig{
+		Provider: provider,
+		APIKey:   cfg.Connector.APIKey,
+		Endpoint: cfg.Connector.Endpoint,
+		Extra:    extra,
+	}
+
+	// Ctrl-C stops a stream and still prints the report.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	miner := drain.New(drain.Config{Similarity: *similarity})
+	lines := 0
+	if *from != "" || *to != "" || *limit > 0 {
+		params := connector.QueryParams{Limit: *limit}
+		if params.Start, err = parseTime("-from", *from); err != nil {
+			return 2, err
+		}
+		if params.End, err = parseTime("-to", *to); err != nil {
+			return 2, err
+		}
+		logs, err := conn.Query(ctx, connCfg, params)
+		if err != nil {
+			return 1, fmt.Errorf("query: %w", err)
+		}
+		for _, raw := range logs {
+			miner.Add(raw.Raw)
+		}
+		lines = len(logs)
+	} else {
+		ch, err := conn.Stream(ctx, connCfg)
+		if err != nil {
+			return 1, fmt.Errorf("stream: %w", err)
+		}
+		for raw := range ch {
+			miner.Add(raw.Raw)
+			lines++
+		}
+	}
+	slog.Debug("templates mined", "connector", provider, "lines", lines)
+
+	clusters := miner.Clusters()
+	report := templateReport{Lines: lines, Total: len(clusters), Templates: clusters}
+	if *top > 0 && len(clusters) > *top {
+		report.Templates = clusters[:*top]
+	}
+
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		err = enc.Encode(report)
+	} else {
+		err = writeTemplates(os.Stdout, report)
+	}
+	if err != nil {
+		return 1, fmt.Errorf("writing report: %w", err)
+	}
+	return 0, nil
+}
+
+// writeTemplates prints the report as an aligned table.
+func writeTemplates(w io.Writer, r templateReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d lines, %d templates\n\n", r.Lines, r.Total)
+	fmt.Fprintln(tw, "COUNT\tSHARE\tID\tTEMPLATE")
+	for _, c := range r.Templates {
+		share := 0.0
+		if r.Lines > 0 {
+			share = float64(c.Count) / float64(r.Lines) * 100
+		}
+		fmt.Fprintf(tw, "%d\t%.1f%%\t%s\t%s\n", c.Count, share, c.ID, c.Template)
+	}
+	return tw.Flush()
+}
+
+// parseTime parses an optional RFC3339 flag value.
+func parseTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: invalid RFC3339 time %q", name, value)
+	}
+	return t, nil
+}
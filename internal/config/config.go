@@ -9,9 +9,16 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/kaminocorp/lumber/internal/connector/format"
+	"github.com/kaminocorp/lumber/internal/connector/multiline"
+	"github.com/kaminocorp/lumber/internal/connector/timestamp"
+	"github.com/kaminocorp/lumber/internal/engine/dedup"
+	"github.com/kaminocorp/lumber/internal/engine/redact"
 )
 
 // Version is the current Lumber release version.
@@ -46,10 +53,46 @@ type EngineConfig struct {
 	ModelPath           string
 	VocabPath           string
 	ProjectionPath      string
+	Sessions            int    // ONNX sessions in the embedder pool (parallel inferences)
+	IntraOpThreads      int    // ONNX intra-op threads per session; 0 = auto
+	InterOpThreads      int    // ONNX inter-op threads per session; 0 = auto
+	TaxonomyPath        string // custom taxonomy JSON/YAML file; empty = built-in taxonomy
+	RulesPath           string // rule file evaluated before embedding; empty = no rules
+	CalibrationPath     string // fitted temperature/thresholds from "lumber calibrate"; empty = none
+	CompactionPolicy    string // per-type/category/severity compaction rules (.json, .yaml); empty = none
+	SeedExamples        bool   // add the embedded labeled corpus as leaf examples
+	Scoring             string // "knn" (nearest prototype) or "centroid"
+	SeverityPolicy      string // "taxonomy", "source" or "max": label vs. log-declared severity
 	ConfidenceThreshold float64
+	TopK                int           // ranked labels per event incl. the best; <=1 disables alternatives
+	AmbiguityMargin     float64       // flag events whose best-vs-runner-up margin is below this
+	CacheSize           int           // template classification cache entries; 0 disables
+	Templates           bool          // mine message templates (template_id/template on events)
+	TemplateSimilarity  float64       // token share a line needs to join a mined template
+	StackTraces         bool          // parse ERROR stack traces into a structured stack object
 	Verbosity           string        // "minimal", "standard", "full"
 	DedupWindow         time.Duration // event dedup window; 0 disables
+	DedupWindows        []string      // per-type/severity window overrides, e.g. "error=0,debug=30s"
+	DedupKeys           []string      // event fields that must match to merge; empty = type,category
+	DedupSimilarity     float64       // min embedding cosine to merge; 0 merges on keys alone
+	DedupRollup         time.Duration // stateful stream dedup update cadence; 0 = per-window batches
+	DedupResolveAfter   time.Duration // quiet time before a stateful dedup group is resolved
 	MaxBufferSize       int           // max events buffered before force flush; 0 = unlimited
+	BatchSize           int           // stream micro-batch size; <=1 processes logs one at a time
+	BatchLatency        time.Duration // max wait for a micro-batch to fill
+	Workers             int           // micro-batches classified concurrently
+	StatsInterval       time.Duration // periodic pipeline stats logging; 0 disables
+	AttributesAllow     []string      // keep only these event attributes; empty = curated defaults
+	AttributesDrop      []string      // event attributes always removed
+
+	Redact         []string          // redaction detectors ("all" or names); empty disables redaction
+	RedactMode     string            // "mask", "hash" or "drop"
+	RedactPatterns map[string]string // custom redaction detectors: name -> regex
+
+	TokenCounter     string // "heuristic", "wordpiece", "cl100k" or "o200k"
+	TokenVocab       string // tiktoken vocabulary file for cl100k/o200k
+	TokenBudget      int    // max raw-text tokens per event below full verbosity; 0 = rune limits
+	BatchTokenBudget int    // max raw-text tokens per processed batch; 0 = none
 }
 
 // OutputConfig holds output destination settings.
@@ -78,10 +121,44 @@ func Load() Config {
 			ModelPath:           getenv("LUMBER_MODEL_PATH", "models/model_quantized.onnx"),
 			VocabPath:           getenv("LUMBER_VOCAB_PATH", "models/vocab.txt"),
 			ProjectionPath:      getenv("LUMBER_PROJECTION_PATH", "models/2_Dense/model.safetensors"),
+			Sessions:            getenvInt("LUMBER_SESSIONS", 1),
+			IntraOpThreads:      getenvInt("LUMBER_INTRA_OP_THREADS", 0),
+			InterOpThreads:      getenvInt("LUMBER_INTER_OP_THREADS", 0),
+			TaxonomyPath:        os.Getenv("LUMBER_TAXONOMY_PATH"),
 			ConfidenceThreshold: getenvFloat("LUMBER_CONFIDENCE_THRESHOLD", 0.5),
+			RulesPath:           os.Getenv("LUMBER_RULES_PATH"),
+			CalibrationPath:     os.Getenv("LUMBER_CALIBRATION_PATH"),
+			CompactionPolicy:    os.Getenv("LUMBER_COMPACTION_POLICY"),
+			SeedExamples:        getenvBool("LUMBER_SEED_EXAMPLES", false),
+			Scoring:             getenv("LUMBER_SCORING", "knn"),
+			SeverityPolicy:      getenv("LUMBER_SEVERITY_POLICY", "taxonomy"),
+			TopK:                getenvInt("LUMBER_TOP_K", 0),
+			AmbiguityMargin:     getenvFloat("LUMBER_AMBIGUITY_MARGIN", 0.05),
+			CacheSize:           getenvInt("LUMBER_CACHE_SIZE", 0),
+			Templates:           getenvBool("LUMBER_TEMPLATES", true),
+			TemplateSimilarity:  getenvFloat("LUMBER_TEMPLATE_SIMILARITY", 0.4),
+			StackTraces:         getenvBool("LUMBER_STACK_TRACES", false),
 			Verbosity:           getenv("LUMBER_VERBOSITY", "standard"),
 			DedupWindow:         getenvDuration("LUMBER_DEDUP_WINDOW", 5*time.Second),
+			DedupWindows:        getenvList("LUMBER_DEDUP_WINDOWS"),
+			DedupKeys:           getenvList("LUMBER_DEDUP_KEYS"),
+			DedupSimilarity:     getenvFloat("LUMBER_DEDUP_SIMILARITY", 0),
+			DedupRollup:         getenvDuration("LUMBER_DEDUP_ROLLUP", 0),
+			DedupResolveAfter:   getenvDuration("LUMBER_DEDUP_RESOLVE_AFTER", 2*time.Minute),
 			MaxBufferSize:       getenvInt("LUMBER_MAX_BUFFER_SIZE", 1000),
+			BatchSize:           getenvInt("LUMBER_BATCH_SIZE", 32),
+			BatchLatency:        getenvDuration("LUMBER_BATCH_LATENCY", 50*time.Millisecond),
+			Workers:             getenvInt("LUMBER_WORKERS", 1),
+			StatsInterval:       getenvDuration("LUMBER_STATS_INTERVAL", 0),
+			AttributesAllow:     getenvList("LUMBER_ATTRIBUTES"),
+			AttributesDrop:      getenvList("LUMBER_DROP_ATTRIBUTES"),
+			Redact:              getenvList("LUMBER_REDACT"),
+			RedactMode:          getenv("LUMBER_REDACT_MODE", "mask"),
+			RedactPatterns:      loadRedactPatterns(),
+			TokenCounter:        getenv("LUMBER_TOKEN_COUNTER", "heuristic"),
+			TokenVocab:          os.Getenv("LUMBER_TOKEN_VOCAB"),
+			TokenBudget:         getenvInt("LUMBER_TOKEN_BUDGET", 0),
+			BatchTokenBudget:    getenvInt("LUMBER_BATCH_TOKEN_BUDGET", 0),
 		},
 		Output: OutputConfig{
 			Format:         getenv("LUMBER_OUTPUT", "stdout"),
@@ -102,7 +179,16 @@ func LoadWithFlags() Config {
 	showVersion := flag.Bool("version", false, "Print version and exit")
 	mode := flag.String("mode", "", "Pipeline mode: stream or query")
 	connFlag := flag.String("connector", "", "Connector: vercel, flyio, supabase, stdin, file")
-	fileInput := flag.String("file", "", "Log file path (for file connector)")
+	fileInput := flag.String("file", "", "Log file path or glob pattern (for file connector)")
+	followFlag := flag.Bool("follow", false, "Tail the file connector's files like tail -F")
+	checkpointPath := flag.String("checkpoint", "", "File recording follow-mode read offsets, to resume after a restart")
+	timestampLayout := flag.String("timestamp-layout", "", "Go time layout of log timestamps, tried before the built-in formats")
+	timezone := flag.String("timezone", "", "Timezone of log timestamps without an offset (default UTC)")
+	inputFormat := flag.String("format", "", "Input format: auto, raw, json, logfmt, clf, syslog, cri (default auto)")
+	messageField := flag.String("message-field", "", "JSON field holding the log message (dotted path, e.g. log.message)")
+	multilineFlag := flag.Bool("multiline", true, "Join stack traces and other continuation lines into one event (stdin, file)")
+	multilineStart := flag.String("multiline-start", "", "Regex for lines that begin a new event; other lines continue it")
+	multilineContinue := flag.String("multiline-continue", "", "Regex for lines that continue the event before them")
 	from := flag.String("from", "", "Query start time (RFC3339)")
 	to := flag.String("to", "", "Query end time (RFC3339)")
 	limit := flag.Int("limit", 0, "Query result limit")
@@ -111,6 +197,21 @@ func LoadWithFlags() Config {
 	logLevel := flag.String("log-level", "", "Log level: debug, info, warn, error")
 	outputFile := flag.String("output-file", "", "File path for NDJSON output")
 	webhookURL := flag.String("webhook-url", "", "Webhook POST endpoint")
+	taxonomyPath := flag.String("taxonomy", "", "Custom taxonomy file (.json, .yaml)")
+	rulesPath := flag.String("rules", "", "Pre-classification rules file (.json, .yaml)")
+	calibrationPath := flag.String("calibration", "", "Calibration file from 'lumber calibrate'")
+	compactionPolicy := flag.String("compaction-policy", "", "Compaction policy file (.json, .yaml)")
+	scoring := flag.String("scoring", "", "Label scoring: knn, centroid")
+	severityPolicy := flag.String("severity-policy", "", "Event severity: taxonomy, source, max")
+	topK := flag.Int("top-k", 0, "Report this many ranked labels per event (0 disables alternatives)")
+	sessions := flag.Int("sessions", 0, "ONNX sessions for parallel inference (default 1)")
+	batchSize := flag.Int("batch-size", 0, "Stream micro-batch size (default 32, 1 disables batching)")
+	workers := flag.Int("workers", 0, "Micro-batches classified concurrently (default 1)")
+	cacheSize := flag.Int("cache-size", 0, "Cache classifications for this many log templates (0 disables)")
+	redactFlag := flag.String("redact", "", "Redact PII/secrets before embedding: all or comma-separated detectors")
+	redactMode := flag.String("redact-mode", "", "Redaction mode: mask, hash, drop")
+	tokenCounter := flag.String("token-counter", "", "Token counting: heuristic, wordpiece, cl100k, o200k")
+	tokenBudget := flag.Int("token-budget", 0, "Max raw-text tokens per event below full verbosity (0 uses rune limits)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `lumber %s — log normalization pipeline
@@ -129,9 +230,47 @@ Flags:
 Environment variables:
   LUMBER_CONNECTOR      Log provider (vercel, flyio, supabase, stdin, file)
   LUMBER_API_KEY        Provider API key/token (cloud connectors only)
-  LUMBER_FILE_PATH      Log file path (file connector)
+  LUMBER_FILE_PATH      Log file path or glob pattern (file connector)
+  LUMBER_FOLLOW         Tail files like tail -F (file connector, default false)
+  LUMBER_CHECKPOINT_PATH  Follow-mode read offsets, to resume after a restart
+  LUMBER_TIMESTAMP_LAYOUT  Go time layout of log timestamps (stdin, file)
+  LUMBER_TIMEZONE       Timezone of timestamps without an offset (default UTC)
+  LUMBER_INPUT_FORMAT   Input format of stdin/file lines (default auto)
+  LUMBER_MESSAGE_FIELD  JSON field holding the log message (dotted path)
+  LUMBER_MULTILINE      Join continuation lines into one event (default true)
+  LUMBER_MULTILINE_START  Regex for lines that begin a new event
+  LUMBER_MULTILINE_CONTINUE  Regex for lines that continue an event
+  LUMBER_MULTILINE_MAX_LINES  Max lines per event (default 500)
+  LUMBER_MULTILINE_MAX_BYTES  Max bytes per event (default 524288)
+  LUMBER_MULTILINE_TIMEOUT  Emit a pending event after this idle time (default 1s)
   LUMBER_VERBOSITY      Output verbosity (minimal, standard, full)
   LUMBER_DEDUP_WINDOW   Dedup window duration (e.g. 5s, 0 to disable)
+  LUMBER_DEDUP_WINDOWS  Per-severity/type windows (e.g. error=0,debug=30s)
+  LUMBER_DEDUP_KEYS     Comma-separated dedup keys (default type,category)
+  LUMBER_DEDUP_SIMILARITY  Min embedding similarity to merge (0 to disable)
+  LUMBER_DEDUP_ROLLUP   Stateful dedup: first occurrence now, updates at this interval
+  LUMBER_TAXONOMY_PATH  Custom taxonomy file (.json, .yaml)
+  LUMBER_RULES_PATH     Pre-classification rules file (.json, .yaml)
+  LUMBER_CALIBRATION_PATH  Calibration file from 'lumber calibrate'
+  LUMBER_COMPACTION_POLICY  Per-type/severity verbosity and truncation rules
+  LUMBER_SCORING        Label scoring against examples (knn, centroid)
+  LUMBER_SEVERITY_POLICY  Event severity from taxonomy, source level, or max
+  LUMBER_TOP_K          Ranked labels per event; >1 adds alternatives/margin
+  LUMBER_CACHE_SIZE     Template classification cache entries (0 to disable)
+  LUMBER_TEMPLATES      Mine message templates onto events (default true)
+  LUMBER_STACK_TRACES   Add parsed stack traces to ERROR events (default false)
+  LUMBER_SESSIONS       ONNX sessions for parallel inference (default 1)
+  LUMBER_BATCH_SIZE     Stream micro-batch size (default 32, 1 to disable)
+  LUMBER_WORKERS        Micro-batches classified concurrently (default 1)
+  LUMBER_ATTRIBUTES     Comma-separated event attributes to keep (default: curated)
+  LUMBER_DROP_ATTRIBUTES  Comma-separated event attributes to remove
+  LUMBER_REDACT         Redact PII/secrets: all or detectors (email,ip,jwt,...)
+  LUMBER_REDACT_MODE    Redaction replacement (mask, hash, drop)
+  LUMBER_REDACT_PATTERN_<NAME>  Custom redaction regex counted as <name>
+  LUMBER_TOKEN_COUNTER  Token counting (heuristic, wordpiece, cl100k, o200k)
+  LUMBER_TOKEN_VOCAB    tiktoken vocabulary file for cl100k/o200k
+  LUMBER_TOKEN_BUDGET   Max raw-text tokens per event (0 uses rune limits)
+  LUMBER_BATCH_TOKEN_BUDGET  Max raw-text tokens per batch (0 to disable)
   LUMBER_LOG_LEVEL      Internal log level (debug, info, warn, error)
 
   See README for full configuration reference.
@@ -154,6 +293,51 @@ Environment variables:
 				cfg.Connector.Extra = make(map[string]string)
 			}
 			cfg.Connector.Extra["file"] = *fileInput
+		case "follow":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["follow"] = strconv.FormatBool(*followFlag)
+		case "checkpoint":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["checkpoint"] = *checkpointPath
+		case "timestamp-layout":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["timestamp_layout"] = *timestampLayout
+		case "timezone":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["timezone"] = *timezone
+		case "format":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["format"] = *inputFormat
+		case "message-field":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["message_field"] = *messageField
+		case "multiline":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["multiline"] = strconv.FormatBool(*multilineFlag)
+		case "multiline-start":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["multiline_start"] = *multilineStart
+		case "multiline-continue":
+			if cfg.Connector.Extra == nil {
+				cfg.Connector.Extra = make(map[string]string)
+			}
+			cfg.Connector.Extra["multiline_continue"] = *multilineContinue
 		case "verbosity":
 			cfg.Engine.Verbosity = *verbosity
 		case "pretty":
@@ -178,6 +362,36 @@ Environment variables:
 			cfg.Output.FilePath = *outputFile
 		case "webhook-url":
 			cfg.Output.WebhookURL = *webhookURL
+		case "taxonomy":
+			cfg.Engine.TaxonomyPath = *taxonomyPath
+		case "rules":
+			cfg.Engine.RulesPath = *rulesPath
+		case "calibration":
+			cfg.Engine.CalibrationPath = *calibrationPath
+		case "compaction-policy":
+			cfg.Engine.CompactionPolicy = *compactionPolicy
+		case "scoring":
+			cfg.Engine.Scoring = *scoring
+		case "severity-policy":
+			cfg.Engine.SeverityPolicy = *severityPolicy
+		case "top-k":
+			cfg.Engine.TopK = *topK
+		case "sessions":
+			cfg.Engine.Sessions = *sessions
+		case "batch-size":
+			cfg.Engine.BatchSize = *batchSize
+		case "workers":
+			cfg.Engine.Workers = *workers
+		case "cache-size":
+			cfg.Engine.CacheSize = *cacheSize
+		case "redact":
+			cfg.Engine.Redact = splitList(*redactFlag)
+		case "redact-mode":
+			cfg.Engine.RedactMode = *redactMode
+		case "token-counter":
+			cfg.Engine.TokenCounter = *tokenCounter
+		case "token-budget":
+			cfg.Engine.TokenBudget = *tokenBudget
 		}
 	})
 
@@ -194,15 +408,53 @@ func (c Config) Validate() error {
 		errs = append(errs, "LUMBER_API_KEY is required for cloud connectors")
 	}
 
-	// File connector requires a valid, accessible file path.
+	// File connector requires a valid, accessible file path, or a glob
+	// pattern matching at least one file. In follow mode files may appear
+	// later.
+	follow := false
+	if v := c.Connector.Extra["follow"]; v != "" {
+		var err error
+		if follow, err = strconv.ParseBool(v); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid follow %q (must be true or false)", v))
+		}
+	}
 	if c.Connector.Provider == "file" {
 		filePath := c.Connector.Extra["file"]
-		if filePath == "" {
+		switch {
+		case filePath == "":
 			errs = append(errs, "file path is required for file connector (-file flag or LUMBER_FILE_PATH)")
-		} else if _, err := os.Stat(filePath); err != nil {
-			errs = append(errs, fmt.Sprintf("log file not accessible: %s (%s)", filePath, err))
+		case strings.ContainsAny(filePath, "*?["):
+			if matches, err := filepath.Glob(filePath); err != nil {
+				errs = append(errs, fmt.Sprintf("invalid file pattern %q (%s)", filePath, err))
+			} else if len(matches) == 0 && !follow {
+				errs = append(errs, fmt.Sprintf("no log files match %s", filePath))
+			}
+		case !follow:
+			if _, err := os.Stat(filePath); err != nil {
+				errs = append(errs, fmt.Sprintf("log file not accessible: %s (%s)", filePath, err))
+			}
 		}
 	}
+	if cp := c.Connector.Extra["checkpoint"]; cp != "" {
+		if _, err := os.Stat(filepath.Dir(cp)); err != nil {
+			errs = append(errs, fmt.Sprintf("checkpoint directory not accessible: %s (%s)", filepath.Dir(cp), err))
+		}
+	}
+
+	// Timestamp layout and timezone of the stdin and file connectors.
+	if _, err := timestamp.New(c.Connector.Extra["timestamp_layout"], c.Connector.Extra["timezone"]); err != nil {
+		errs = append(errs, err.Error())
+	}
+	// Input format of the stdin and file connectors.
+	if _, err := format.New(c.Connector.Extra["format"], c.Connector.Extra["message_field"]); err != nil {
+		errs = append(errs, err.Error())
+	}
+	// Multi-line assembly of the stdin and file connectors.
+	if mc, err := multiline.FromExtra(c.Connector.Extra); err != nil {
+		errs = append(errs, err.Error())
+	} else if _, err := multiline.New(mc); err != nil {
+		errs = append(errs, err.Error())
+	}
 
 	// Model files must exist and be accessible on disk.
 	for _, f := range []struct{ name, path string }{
@@ -215,6 +467,34 @@ func (c Config) Validate() error {
 		}
 	}
 
+	// Custom taxonomy file must exist and be accessible.
+	if c.Engine.TaxonomyPath != "" {
+		if _, err := os.Stat(c.Engine.TaxonomyPath); err != nil {
+			errs = append(errs, fmt.Sprintf("taxonomy file not accessible: %s (%s)", c.Engine.TaxonomyPath, err))
+		}
+	}
+
+	// Rules file must exist and be accessible.
+	if c.Engine.RulesPath != "" {
+		if _, err := os.Stat(c.Engine.RulesPath); err != nil {
+			errs = append(errs, fmt.Sprintf("rules file not accessible: %s (%s)", c.Engine.RulesPath, err))
+		}
+	}
+
+	// Calibration file must exist and be accessible.
+	if c.Engine.CalibrationPath != "" {
+		if _, err := os.Stat(c.Engine.CalibrationPath); err != nil {
+			errs = append(errs, fmt.Sprintf("calibration file not accessible: %s (%s)", c.Engine.CalibrationPath, err))
+		}
+	}
+
+	// Compaction policy file must exist and be accessible.
+	if c.Engine.CompactionPolicy != "" {
+		if _, err := os.Stat(c.Engine.CompactionPolicy); err != nil {
+			errs = append(errs, fmt.Sprintf("compaction policy file not accessible: %s (%s)", c.Engine.CompactionPolicy, err))
+		}
+	}
+
 	// Confidence threshold must be a finite number in [0, 1].
 	// NaN comparisons are always false in IEEE 754, so check explicitly.
 	if math.IsNaN(c.Engine.ConfidenceThreshold) || math.IsInf(c.Engine.ConfidenceThreshold, 0) {
@@ -223,6 +503,27 @@ func (c Config) Validate() error {
 		errs = append(errs, fmt.Sprintf("confidence threshold must be 0-1, got %f", c.Engine.ConfidenceThreshold))
 	}
 
+	// Top-k non-negative; ambiguity margin in [0, 1].
+	if c.Engine.TopK < 0 {
+		errs = append(errs, fmt.Sprintf("top-k must be non-negative, got %d", c.Engine.TopK))
+	}
+	if c.Engine.Sessions < 1 {
+		errs = append(errs, fmt.Sprintf("sessions must be at least 1, got %d", c.Engine.Sessions))
+	}
+	if c.Engine.IntraOpThreads < 0 || c.Engine.InterOpThreads < 0 {
+		errs = append(errs, fmt.Sprintf("ONNX thread counts must be non-negative, got intra-op %d, inter-op %d",
+			c.Engine.IntraOpThreads, c.Engine.InterOpThreads))
+	}
+	if c.Engine.CacheSize < 0 {
+		errs = append(errs, fmt.Sprintf("cache size must be non-negative, got %d", c.Engine.CacheSize))
+	}
+	if math.IsNaN(c.Engine.TemplateSimilarity) || c.Engine.TemplateSimilarity <= 0 || c.Engine.TemplateSimilarity > 1 {
+		errs = append(errs, fmt.Sprintf("template similarity must be in (0, 1], got %f", c.Engine.TemplateSimilarity))
+	}
+	if math.IsNaN(c.Engine.AmbiguityMargin) || c.Engine.AmbiguityMargin < 0 || c.Engine.AmbiguityMargin > 1 {
+		errs = append(errs, fmt.Sprintf("ambiguity margin must be 0-1, got %f", c.Engine.AmbiguityMargin))
+	}
+
 	// Verbosity enum.
 	switch c.Engine.Verbosity {
 	case "minimal", "standard", "full":
@@ -230,6 +531,20 @@ func (c Config) Validate() error {
 		errs = append(errs, fmt.Sprintf("invalid verbosity %q (must be minimal|standard|full)", c.Engine.Verbosity))
 	}
 
+	// Scoring enum.
+	switch c.Engine.Scoring {
+	case "knn", "centroid":
+	default:
+		errs = append(errs, fmt.Sprintf("invalid scoring %q (must be knn|centroid)", c.Engine.Scoring))
+	}
+
+	// Severity policy enum.
+	switch c.Engine.SeverityPolicy {
+	case "taxonomy", "source", "max":
+	default:
+		errs = append(errs, fmt.Sprintf("invalid severity policy %q (must be taxonomy|source|max)", c.Engine.SeverityPolicy))
+	}
+
 	// Log level enum.
 	switch c.LogLevel {
 	case "debug", "info", "warn", "error":
@@ -256,6 +571,67 @@ func (c Config) Validate() error {
 	if c.Engine.DedupWindow < 0 {
 		errs = append(errs, fmt.Sprintf("dedup window must be non-negative, got %s", c.Engine.DedupWindow))
 	}
+	if c.Engine.DedupRollup < 0 {
+		errs = append(errs, fmt.Sprintf("dedup rollup must be non-negative, got %s", c.Engine.DedupRollup))
+	}
+	// Resolve-after only applies to stateful dedup.
+	if c.Engine.DedupRollup > 0 && c.Engine.DedupResolveAfter <= 0 {
+		errs = append(errs, fmt.Sprintf("dedup resolve-after must be positive with rollups, got %s", c.Engine.DedupResolveAfter))
+	}
+	if _, err := dedup.ParseWindows(c.Engine.DedupWindows); err != nil {
+		errs = append(errs, err.Error())
+	}
+	// Rollups keep groups open across windows, so per-lane windows have
+	// nothing to apply to.
+	if c.Engine.DedupRollup > 0 && len(c.Engine.DedupWindows) > 0 {
+		errs = append(errs, "dedup windows cannot be combined with dedup rollup")
+	}
+	for _, key := range c.Engine.DedupKeys {
+		if err := dedup.ValidateKey(key); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if math.IsNaN(c.Engine.DedupSimilarity) || c.Engine.DedupSimilarity < 0 || c.Engine.DedupSimilarity > 1 {
+		errs = append(errs, fmt.Sprintf("dedup similarity must be 0-1, got %f", c.Engine.DedupSimilarity))
+	}
+
+	// Redaction detectors, patterns and mode must compile.
+	if len(c.Engine.Redact) > 0 || len(c.Engine.RedactPatterns) > 0 {
+		if _, err := redact.New(c.Engine.Redaction()); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// Token counter enum; BPE encodings need a vocabulary file.
+	switch c.Engine.TokenCounter {
+	case "heuristic", "wordpiece":
+	case "cl100k", "o200k":
+		if c.Engine.TokenVocab == "" {
+			errs = append(errs, fmt.Sprintf("token counter %s requires LUMBER_TOKEN_VOCAB", c.Engine.TokenCounter))
+		} else if _, err := os.Stat(c.Engine.TokenVocab); err != nil {
+			errs = append(errs, fmt.Sprintf("token vocabulary not accessible: %s (%s)", c.Engine.TokenVocab, err))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("invalid token counter %q (must be heuristic|wordpiece|cl100k|o200k)", c.Engine.TokenCounter))
+	}
+	if c.Engine.TokenBudget < 0 || c.Engine.BatchTokenBudget < 0 {
+		errs = append(errs, fmt.Sprintf("token budgets must be non-negative, got %d per event, %d per batch",
+			c.Engine.TokenBudget, c.Engine.BatchTokenBudget))
+	}
+
+	// Micro-batching bounds.
+	if c.Engine.BatchSize < 0 {
+		errs = append(errs, fmt.Sprintf("batch size must be non-negative, got %d", c.Engine.BatchSize))
+	}
+	if c.Engine.BatchSize > 1 && c.Engine.BatchLatency <= 0 {
+		errs = append(errs, fmt.Sprintf("batch latency must be positive, got %s", c.Engine.BatchLatency))
+	}
+	if c.Engine.Workers < 1 {
+		errs = append(errs, fmt.Sprintf("workers must be at least 1, got %d", c.Engine.Workers))
+	}
+	if c.Engine.StatsInterval < 0 {
+		errs = append(errs, fmt.Sprintf("stats interval must be non-negative, got %s", c.Engine.StatsInterval))
+	}
 
 	// Buffer size non-negative.
 	if c.Engine.MaxBufferSize < 0 {
@@ -344,6 +720,18 @@ func loadConnectorExtra() map[string]string {
 		{"LUMBER_SUPABASE_TABLES", "tables"},
 		{"LUMBER_POLL_INTERVAL", "poll_interval"},
 		{"LUMBER_FILE_PATH", "file"},
+		{"LUMBER_FOLLOW", "follow"},
+		{"LUMBER_CHECKPOINT_PATH", "checkpoint"},
+		{"LUMBER_TIMESTAMP_LAYOUT", "timestamp_layout"},
+		{"LUMBER_TIMEZONE", "timezone"},
+		{"LUMBER_INPUT_FORMAT", "format"},
+		{"LUMBER_MESSAGE_FIELD", "message_field"},
+		{"LUMBER_MULTILINE", "multiline"},
+		{"LUMBER_MULTILINE_START", "multiline_start"},
+		{"LUMBER_MULTILINE_CONTINUE", "multiline_continue"},
+		{"LUMBER_MULTILINE_MAX_LINES", "multiline_max_lines"},
+		{"LUMBER_MULTILINE_MAX_BYTES", "multiline_max_bytes"},
+		{"LUMBER_MULTILINE_TIMEOUT", "multiline_timeout"},
 	}
 
 	var m map[string]string
@@ -384,6 +772,63 @@ func loadWebhookHeaders() map[string]string {
 	return m
 }
 
+// loadRedactPatterns reads env vars with the LUMBER_REDACT_PATTERN_ prefix
+// into custom redaction detectors. For example,
+// LUMBER_REDACT_PATTERN_CUSTOMER_ID=cus_[A-Za-z0-9]{14} redacts matches
+// and counts them as "customer_id".
+func loadRedactPatterns() map[string]string {
+	const prefix = "LUMBER_REDACT_PATTERN_"
+	var m map[string]string
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, prefix) {
+			continue
+		}
+		kv := strings.SplitN(env, "=", 2)
+		if len(kv) != 2 || kv[1] == "" || kv[0] == prefix {
+			continue
+		}
+		if m == nil {
+			m = make(map[string]string)
+		}
+		m[strings.ToLower(kv[0][len(prefix):])] = kv[1]
+	}
+	return m
+}
+
+// Redaction returns the redaction settings as a redact.Config, with custom
+// patterns sorted by name. Redaction is enabled when Redact or
+// RedactPatterns is non-empty.
+func (e EngineConfig) Redaction() redact.Config {
+	cfg := redact.Config{Detectors: e.Redact, Mode: redact.Mode(e.RedactMode)}
+	names := make([]string, 0, len(e.RedactPatterns))
+	for name := range e.RedactPatterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cfg.Patterns = append(cfg.Patterns, redact.Pattern{Name: name, Regex: e.RedactPatterns[name]})
+	}
+	return cfg
+}
+
+// getenvList splits a comma-separated env var, trimming spaces and dropping
+// empty entries. Returns nil when unset.
+func getenvList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+// splitList splits a comma-separated value, trimming spaces and dropping
+// empty entries.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func getenvBool(key string, fallback bool) bool {
 	v := os.Getenv(key)
 	if v == "" {
@@ -192,9 +192,16 @@ func validConfig(t *testing.T) Config {
 			ModelPath:           filepath.Join(dir, "model.onnx"),
 			VocabPath:           filepath.Join(dir, "vocab.txt"),
 			ProjectionPath:      filepath.Join(dir, "proj.safetensors"),
+			Sessions:            1,
+			Workers:             1,
 			ConfidenceThreshold: 0.5,
+			Scoring:             "knn",
+			SeverityPolicy:      "taxonomy",
+			TemplateSimilarity:  0.4,
+			TokenCounter:        "heuristic",
 			Verbosity:           "standard",
 			DedupWindow:         5 * time.Second,
+			DedupResolveAfter:   2 * time.Minute,
 		},
 		Output: OutputConfig{Format: "stdout"},
 	}
@@ -654,3 +661,592 @@ func TestLoad_FilePathEnv(t *testing.T) {
 		t.Fatalf("expected Extra[\"file\"]=/var/log/app.log, got %q", cfg.Connector.Extra["file"])
 	}
 }
+
+func TestLoad_TaxonomyPathEnv(t *testing.T) {
+	os.Setenv("LUMBER_TAXONOMY_PATH", "/etc/lumber/taxonomy.json")
+	defer os.Unsetenv("LUMBER_TAXONOMY_PATH")
+
+	cfg := Load()
+	if cfg.Engine.TaxonomyPath != "/etc/lumber/taxonomy.json" {
+		t.Fatalf("expected TaxonomyPath=/etc/lumber/taxonomy.json, got %q", cfg.Engine.TaxonomyPath)
+	}
+}
+
+func TestValidate_TaxonomyFileMissing(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.TaxonomyPath = "/nonexistent/taxonomy.json"
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for missing taxonomy file")
+	}
+	if !strings.Contains(err.Error(), "taxonomy file not accessible") {
+		t.Fatalf("expected error to mention 'taxonomy file not accessible', got: %v", err)
+	}
+}
+
+func TestLoad_TopKEnv(t *testing.T) {
+	os.Setenv("LUMBER_TOP_K", "3")
+	os.Setenv("LUMBER_AMBIGUITY_MARGIN", "0.1")
+	defer os.Unsetenv("LUMBER_TOP_K")
+	defer os.Unsetenv("LUMBER_AMBIGUITY_MARGIN")
+
+	cfg := Load()
+	if cfg.Engine.TopK != 3 {
+		t.Fatalf("expected TopK=3, got %d", cfg.Engine.TopK)
+	}
+	if cfg.Engine.AmbiguityMargin != 0.1 {
+		t.Fatalf("expected AmbiguityMargin=0.1, got %f", cfg.Engine.AmbiguityMargin)
+	}
+}
+
+func TestValidate_BadTopK(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.TopK = -1
+	cfg.Engine.AmbiguityMargin = 2
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for negative top-k and margin > 1")
+	}
+	if !strings.Contains(err.Error(), "top-k") || !strings.Contains(err.Error(), "ambiguity margin") {
+		t.Fatalf("expected error to mention top-k and ambiguity margin, got: %v", err)
+	}
+}
+
+func TestLoad_ScoringEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.Scoring != "knn" || cfg.Engine.SeedExamples {
+		t.Fatalf("expected default scoring knn without seeding, got %q seed=%v", cfg.Engine.Scoring, cfg.Engine.SeedExamples)
+	}
+
+	os.Setenv("LUMBER_SCORING", "centroid")
+	os.Setenv("LUMBER_SEED_EXAMPLES", "true")
+	defer os.Unsetenv("LUMBER_SCORING")
+	defer os.Unsetenv("LUMBER_SEED_EXAMPLES")
+
+	cfg := Load()
+	if cfg.Engine.Scoring != "centroid" || !cfg.Engine.SeedExamples {
+		t.Fatalf("expected centroid scoring with seeding, got %q seed=%v", cfg.Engine.Scoring, cfg.Engine.SeedExamples)
+	}
+}
+
+func TestValidate_BadScoring(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.Scoring = "mean"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "scoring") {
+		t.Fatalf("expected error to mention 'scoring', got: %v", err)
+	}
+}
+
+func TestLoad_SeverityPolicyEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.SeverityPolicy != "taxonomy" {
+		t.Fatalf("expected default severity policy taxonomy, got %q", cfg.Engine.SeverityPolicy)
+	}
+
+	os.Setenv("LUMBER_SEVERITY_POLICY", "max")
+	defer os.Unsetenv("LUMBER_SEVERITY_POLICY")
+
+	if cfg := Load(); cfg.Engine.SeverityPolicy != "max" {
+		t.Fatalf("expected severity policy max, got %q", cfg.Engine.SeverityPolicy)
+	}
+}
+
+func TestValidate_BadSeverityPolicy(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.SeverityPolicy = "highest"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "severity policy") {
+		t.Fatalf("expected error to mention 'severity policy', got: %v", err)
+	}
+}
+
+func TestLoad_TemplatesEnv(t *testing.T) {
+	if cfg := Load(); !cfg.Engine.Templates || cfg.Engine.TemplateSimilarity != 0.4 {
+		t.Fatalf("expected templates on with similarity 0.4, got %v/%f", cfg.Engine.Templates, cfg.Engine.TemplateSimilarity)
+	}
+
+	os.Setenv("LUMBER_TEMPLATES", "false")
+	os.Setenv("LUMBER_TEMPLATE_SIMILARITY", "0.7")
+	defer os.Unsetenv("LUMBER_TEMPLATES")
+	defer os.Unsetenv("LUMBER_TEMPLATE_SIMILARITY")
+
+	if cfg := Load(); cfg.Engine.Templates || cfg.Engine.TemplateSimilarity != 0.7 {
+		t.Fatalf("expected templates off with similarity 0.7, got %v/%f", cfg.Engine.Templates, cfg.Engine.TemplateSimilarity)
+	}
+}
+
+func TestValidate_BadTemplateSimilarity(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.TemplateSimilarity = 0
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "template similarity") {
+		t.Fatalf("expected error to mention 'template similarity', got: %v", err)
+	}
+}
+
+func TestValidate_CalibrationFileMissing(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.CalibrationPath = "/nonexistent/calibration.json"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "calibration file not accessible") {
+		t.Fatalf("expected error to mention 'calibration file not accessible', got: %v", err)
+	}
+}
+
+func TestLoad_RulesPathEnv(t *testing.T) {
+	os.Setenv("LUMBER_RULES_PATH", "/etc/lumber/rules.yaml")
+	defer os.Unsetenv("LUMBER_RULES_PATH")
+
+	cfg := Load()
+	if cfg.Engine.RulesPath != "/etc/lumber/rules.yaml" {
+		t.Fatalf("expected RulesPath=/etc/lumber/rules.yaml, got %q", cfg.Engine.RulesPath)
+	}
+}
+
+func TestValidate_RulesFileMissing(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.RulesPath = "/nonexistent/rules.json"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "rules file not accessible") {
+		t.Fatalf("expected error to mention 'rules file not accessible', got: %v", err)
+	}
+}
+
+func TestLoad_CacheSizeEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.CacheSize != 0 {
+		t.Fatalf("expected cache disabled by default, got %d", cfg.Engine.CacheSize)
+	}
+
+	os.Setenv("LUMBER_CACHE_SIZE", "5000")
+	defer os.Unsetenv("LUMBER_CACHE_SIZE")
+	if cfg := Load(); cfg.Engine.CacheSize != 5000 {
+		t.Fatalf("expected CacheSize=5000, got %d", cfg.Engine.CacheSize)
+	}
+}
+
+func TestValidate_NegativeCacheSize(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.CacheSize = -1
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cache size") {
+		t.Fatalf("expected cache size error, got: %v", err)
+	}
+}
+
+func TestLoad_SessionEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.Sessions != 1 || cfg.Engine.IntraOpThreads != 0 || cfg.Engine.InterOpThreads != 0 {
+		t.Fatalf("expected 1 session with auto threads by default, got %+v", cfg.Engine)
+	}
+
+	os.Setenv("LUMBER_SESSIONS", "4")
+	os.Setenv("LUMBER_INTRA_OP_THREADS", "2")
+	os.Setenv("LUMBER_INTER_OP_THREADS", "1")
+	defer os.Unsetenv("LUMBER_SESSIONS")
+	defer os.Unsetenv("LUMBER_INTRA_OP_THREADS")
+	defer os.Unsetenv("LUMBER_INTER_OP_THREADS")
+
+	cfg := Load()
+	if cfg.Engine.Sessions != 4 || cfg.Engine.IntraOpThreads != 2 || cfg.Engine.InterOpThreads != 1 {
+		t.Fatalf("expected 4 sessions with 2/1 threads, got %d %d/%d",
+			cfg.Engine.Sessions, cfg.Engine.IntraOpThreads, cfg.Engine.InterOpThreads)
+	}
+}
+
+func TestValidate_BadSessions(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.Sessions = 0
+	cfg.Engine.IntraOpThreads = -1
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for zero sessions and negative threads")
+	}
+	if !strings.Contains(err.Error(), "sessions must be at least 1") || !strings.Contains(err.Error(), "thread counts") {
+		t.Fatalf("expected sessions and thread errors, got: %v", err)
+	}
+}
+
+func TestLoad_BatchingEnv(t *testing.T) {
+	cfg := Load()
+	if cfg.Engine.BatchSize != 32 || cfg.Engine.BatchLatency != 50*time.Millisecond || cfg.Engine.Workers != 1 {
+		t.Fatalf("unexpected batching defaults: size=%d latency=%s workers=%d",
+			cfg.Engine.BatchSize, cfg.Engine.BatchLatency, cfg.Engine.Workers)
+	}
+
+	os.Setenv("LUMBER_BATCH_SIZE", "64")
+	os.Setenv("LUMBER_BATCH_LATENCY", "10ms")
+	os.Setenv("LUMBER_WORKERS", "4")
+	os.Setenv("LUMBER_STATS_INTERVAL", "30s")
+	defer os.Unsetenv("LUMBER_BATCH_SIZE")
+	defer os.Unsetenv("LUMBER_BATCH_LATENCY")
+	defer os.Unsetenv("LUMBER_WORKERS")
+	defer os.Unsetenv("LUMBER_STATS_INTERVAL")
+
+	cfg = Load()
+	if cfg.Engine.BatchSize != 64 || cfg.Engine.BatchLatency != 10*time.Millisecond ||
+		cfg.Engine.Workers != 4 || cfg.Engine.StatsInterval != 30*time.Second {
+		t.Fatalf("batching env not applied: %+v", cfg.Engine)
+	}
+}
+
+func TestValidate_BadBatching(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.BatchSize = 16
+	cfg.Engine.BatchLatency = 0
+	cfg.Engine.Workers = 0
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected error for zero latency and zero workers")
+	}
+	if !strings.Contains(err.Error(), "batch latency") || !strings.Contains(err.Error(), "workers") {
+		t.Fatalf("expected batch latency and workers errors, got: %v", err)
+	}
+}
+
+func TestLoad_AttributesEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.AttributesAllow != nil || cfg.Engine.AttributesDrop != nil {
+		t.Fatalf("expected no attribute filters by default, got %v / %v", cfg.Engine.AttributesAllow, cfg.Engine.AttributesDrop)
+	}
+
+	os.Setenv("LUMBER_ATTRIBUTES", "region, status,,path ")
+	os.Setenv("LUMBER_DROP_ATTRIBUTES", "host")
+	defer os.Unsetenv("LUMBER_ATTRIBUTES")
+	defer os.Unsetenv("LUMBER_DROP_ATTRIBUTES")
+
+	cfg := Load()
+	if strings.Join(cfg.Engine.AttributesAllow, "|") != "region|status|path" {
+		t.Fatalf("unexpected allow list: %q", cfg.Engine.AttributesAllow)
+	}
+	if len(cfg.Engine.AttributesDrop) != 1 || cfg.Engine.AttributesDrop[0] != "host" {
+		t.Fatalf("unexpected drop list: %q", cfg.Engine.AttributesDrop)
+	}
+}
+
+func TestLoad_DedupKeysEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.DedupKeys != nil || cfg.Engine.DedupSimilarity != 0 {
+		t.Fatalf("expected default dedup keys and no similarity, got %v / %f", cfg.Engine.DedupKeys, cfg.Engine.DedupSimilarity)
+	}
+
+	os.Setenv("LUMBER_DEDUP_KEYS", "type,category,source,field.route")
+	os.Setenv("LUMBER_DEDUP_SIMILARITY", "0.92")
+	defer os.Unsetenv("LUMBER_DEDUP_KEYS")
+	defer os.Unsetenv("LUMBER_DEDUP_SIMILARITY")
+
+	cfg := Load()
+	if strings.Join(cfg.Engine.DedupKeys, "|") != "type|category|source|field.route" {
+		t.Fatalf("unexpected dedup keys: %q", cfg.Engine.DedupKeys)
+	}
+	if cfg.Engine.DedupSimilarity != 0.92 {
+		t.Fatalf("expected DedupSimilarity=0.92, got %f", cfg.Engine.DedupSimilarity)
+	}
+}
+
+func TestValidate_BadDedupKeysAndSimilarity(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.DedupKeys = []string{"type", "summary"}
+	cfg.Engine.DedupSimilarity = 1.5
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `unknown dedup key "summary"`) || !strings.Contains(err.Error(), "dedup similarity") {
+		t.Fatalf("expected dedup key and similarity errors, got: %v", err)
+	}
+}
+
+func TestLoad_DedupRollupEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.DedupRollup != 0 || cfg.Engine.DedupResolveAfter != 2*time.Minute {
+		t.Fatalf("expected rollups off and resolve-after 2m, got %v / %v", cfg.Engine.DedupRollup, cfg.Engine.DedupResolveAfter)
+	}
+
+	os.Setenv("LUMBER_DEDUP_ROLLUP", "30s")
+	os.Setenv("LUMBER_DEDUP_RESOLVE_AFTER", "5m")
+	defer os.Unsetenv("LUMBER_DEDUP_ROLLUP")
+	defer os.Unsetenv("LUMBER_DEDUP_RESOLVE_AFTER")
+
+	if cfg := Load(); cfg.Engine.DedupRollup != 30*time.Second || cfg.Engine.DedupResolveAfter != 5*time.Minute {
+		t.Fatalf("expected 30s / 5m, got %v / %v", cfg.Engine.DedupRollup, cfg.Engine.DedupResolveAfter)
+	}
+}
+
+func TestValidate_BadDedupRollup(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.DedupRollup = -time.Second
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "dedup rollup") {
+		t.Fatalf("expected error to mention 'dedup rollup', got: %v", err)
+	}
+}
+
+func TestValidate_DedupResolveAfterOnlyWithRollup(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.DedupResolveAfter = 0
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("resolve-after should be ignored without rollups, got: %v", err)
+	}
+	cfg.Engine.DedupRollup = 30 * time.Second
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "resolve-after") {
+		t.Fatalf("expected error to mention 'resolve-after', got: %v", err)
+	}
+}
+
+func TestValidate_DedupWindowsWithRollup(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.DedupWindows = []string{"error=0"}
+	cfg.Engine.DedupRollup = time.Minute
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cannot be combined") {
+		t.Fatalf("expected windows and rollup to be rejected, got: %v", err)
+	}
+}
+
+func TestLoad_DedupWindowsEnv(t *testing.T) {
+	os.Setenv("LUMBER_DEDUP_WINDOWS", "error=0, debug=30s")
+	defer os.Unsetenv("LUMBER_DEDUP_WINDOWS")
+
+	cfg := Load()
+	if strings.Join(cfg.Engine.DedupWindows, "|") != "error=0|debug=30s" {
+		t.Fatalf("unexpected dedup windows: %q", cfg.Engine.DedupWindows)
+	}
+}
+
+func TestValidate_BadDedupWindows(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.DedupWindows = []string{"error=fast"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "invalid dedup window") {
+		t.Fatalf("expected error to mention 'invalid dedup window', got: %v", err)
+	}
+}
+
+func TestValidate_CompactionPolicyFileMissing(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.CompactionPolicy = "/nonexistent/policy.json"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "compaction policy file not accessible") {
+		t.Fatalf("expected error to mention 'compaction policy file not accessible', got: %v", err)
+	}
+}
+
+func TestLoad_RedactEnv(t *testing.T) {
+	if cfg := Load(); cfg.Engine.Redact != nil || cfg.Engine.RedactMode != "mask" || cfg.Engine.RedactPatterns != nil {
+		t.Fatalf("expected redaction off with mask mode, got %v / %q / %v", cfg.Engine.Redact, cfg.Engine.RedactMode, cfg.Engine.RedactPatterns)
+	}
+
+	os.Setenv("LUMBER_REDACT", "email, ip")
+	os.Setenv("LUMBER_REDACT_MODE", "hash")
+	os.Setenv("LUMBER_REDACT_PATTERN_CUSTOMER_ID", `cus_[A-Za-z0-9]{14}`)
+	defer os.Unsetenv("LUMBER_REDACT")
+	defer os.Unsetenv("LUMBER_REDACT_MODE")
+	defer os.Unsetenv("LUMBER_REDACT_PATTERN_CUSTOMER_ID")
+
+	cfg := Load()
+	if strings.Join(cfg.Engine.Redact, "|") != "email|ip" || cfg.Engine.RedactMode != "hash" {
+		t.Fatalf("unexpected redaction settings: %v / %q", cfg.Engine.Redact, cfg.Engine.RedactMode)
+	}
+	rc := cfg.Engine.Redaction()
+	if len(rc.Patterns) != 1 || rc.Patterns[0].Name != "customer_id" || rc.Patterns[0].Regex != `cus_[A-Za-z0-9]{14}` {
+		t.Fatalf("unexpected redaction patterns: %+v", rc.Patterns)
+	}
+}
+
+func TestValidate_BadRedaction(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.Redact = []string{"email", "ssn"}
+	cfg.Engine.RedactMode = "scramble"
+	cfg.Engine.RedactPatterns = map[string]string{"broken": "("}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected redaction errors")
+	}
+	for _, want := range []string{`unknown redaction detector "ssn"`, `invalid redaction mode "scramble"`, `redaction pattern "broken"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoad_StackTracesEnv(t *testing.T) {
+	if Load().Engine.StackTraces {
+		t.Fatal("expected stack traces off by default")
+	}
+	os.Setenv("LUMBER_STACK_TRACES", "true")
+	defer os.Unsetenv("LUMBER_STACK_TRACES")
+	if !Load().Engine.StackTraces {
+		t.Fatal("expected LUMBER_STACK_TRACES=true to enable stack traces")
+	}
+}
+
+func TestLoad_TokenEnv(t *testing.T) {
+	cfg := Load()
+	if cfg.Engine.TokenCounter != "heuristic" || cfg.Engine.TokenBudget != 0 || cfg.Engine.BatchTokenBudget != 0 {
+		t.Fatalf("expected heuristic counter without budgets, got %q / %d / %d",
+			cfg.Engine.TokenCounter, cfg.Engine.TokenBudget, cfg.Engine.BatchTokenBudget)
+	}
+
+	os.Setenv("LUMBER_TOKEN_COUNTER", "cl100k")
+	os.Setenv("LUMBER_TOKEN_VOCAB", "/models/cl100k_base.tiktoken")
+	os.Setenv("LUMBER_TOKEN_BUDGET", "120")
+	os.Setenv("LUMBER_BATCH_TOKEN_BUDGET", "4000")
+	defer os.Unsetenv("LUMBER_TOKEN_COUNTER")
+	defer os.Unsetenv("LUMBER_TOKEN_VOCAB")
+	defer os.Unsetenv("LUMBER_TOKEN_BUDGET")
+	defer os.Unsetenv("LUMBER_BATCH_TOKEN_BUDGET")
+
+	cfg = Load()
+	if cfg.Engine.TokenCounter != "cl100k" || cfg.Engine.TokenVocab != "/models/cl100k_base.tiktoken" ||
+		cfg.Engine.TokenBudget != 120 || cfg.Engine.BatchTokenBudget != 4000 {
+		t.Fatalf("unexpected token settings: %+v", cfg.Engine)
+	}
+}
+
+func TestValidate_BadTokenSettings(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Engine.TokenCounter = "p50k"
+	cfg.Engine.TokenBudget = -1
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected token errors")
+	}
+	for _, want := range []string{`invalid token counter "p50k"`, "token budgets must be non-negative"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	cfg = validConfig(t)
+	cfg.Engine.TokenCounter = "o200k"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "requires LUMBER_TOKEN_VOCAB") {
+		t.Errorf("expected missing vocabulary error, got %v", err)
+	}
+	cfg.Engine.TokenVocab = cfg.Engine.VocabPath
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected valid config, got %v", err)
+	}
+}
+
+func TestLoad_TimestampEnv(t *testing.T) {
+	os.Setenv("LUMBER_TIMESTAMP_LAYOUT", "02.01.2006 15:04:05")
+	os.Setenv("LUMBER_TIMEZONE", "Europe/Berlin")
+	defer os.Unsetenv("LUMBER_TIMESTAMP_LAYOUT")
+	defer os.Unsetenv("LUMBER_TIMEZONE")
+
+	cfg := Load()
+	if cfg.Connector.Extra["timestamp_layout"] != "02.01.2006 15:04:05" || cfg.Connector.Extra["timezone"] != "Europe/Berlin" {
+		t.Fatalf("unexpected timestamp settings: %v", cfg.Connector.Extra)
+	}
+}
+
+func TestValidate_BadTimestampSettings(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Connector.Extra = map[string]string{"timestamp_layout": "dd/mm/yyyy", "timezone": "Mars/Olympus"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected timestamp errors")
+	}
+	for _, want := range []string{`invalid timezone "Mars/Olympus"`, `invalid timestamp layout "dd/mm/yyyy"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoad_InputFormatEnv(t *testing.T) {
+	os.Setenv("LUMBER_INPUT_FORMAT", "json")
+	os.Setenv("LUMBER_MESSAGE_FIELD", "log.message")
+	defer os.Unsetenv("LUMBER_INPUT_FORMAT")
+	defer os.Unsetenv("LUMBER_MESSAGE_FIELD")
+
+	cfg := Load()
+	if cfg.Connector.Extra["format"] != "json" || cfg.Connector.Extra["message_field"] != "log.message" {
+		t.Fatalf("unexpected input format settings: %v", cfg.Connector.Extra)
+	}
+}
+
+func TestValidate_BadInputFormat(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Connector.Extra = map[string]string{"format": "xml"}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `invalid input format "xml"`) {
+		t.Fatalf("expected input format error, got %v", err)
+	}
+}
+
+func TestLoad_MultilineEnv(t *testing.T) {
+	os.Setenv("LUMBER_MULTILINE_START", `^\d{4}-`)
+	os.Setenv("LUMBER_MULTILINE_TIMEOUT", "250ms")
+	defer os.Unsetenv("LUMBER_MULTILINE_START")
+	defer os.Unsetenv("LUMBER_MULTILINE_TIMEOUT")
+
+	cfg := Load()
+	if cfg.Connector.Extra["multiline_start"] != `^\d{4}-` || cfg.Connector.Extra["multiline_timeout"] != "250ms" {
+		t.Fatalf("unexpected multiline settings: %v", cfg.Connector.Extra)
+	}
+}
+
+func TestValidate_BadMultilineSettings(t *testing.T) {
+	cfg := validConfig(t)
+	cfg.Connector.Extra = map[string]string{"multiline_max_lines": "0", "multiline_timeout": "soon"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected multiline errors")
+	}
+	for _, want := range []string{`invalid multiline_max_lines "0"`, `invalid multiline_timeout "soon"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	cfg.Connector.Extra = map[string]string{"multiline_continue": "["}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid multiline continue pattern") {
+		t.Errorf("expected pattern error, got %v", err)
+	}
+}
+
+func TestLoad_FollowEnv(t *testing.T) {
+	os.Setenv("LUMBER_FOLLOW", "true")
+	os.Setenv("LUMBER_CHECKPOINT_PATH", "/var/lib/lumber/offsets.json")
+	defer os.Unsetenv("LUMBER_FOLLOW")
+	defer os.Unsetenv("LUMBER_CHECKPOINT_PATH")
+
+	cfg := Load()
+	if cfg.Connector.Extra["follow"] != "true" || cfg.Connector.Extra["checkpoint"] != "/var/lib/lumber/offsets.json" {
+		t.Fatalf("unexpected follow settings: %v", cfg.Connector.Extra)
+	}
+}
+
+func TestValidate_FileGlob(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.log"), []byte("x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := validConfig(t)
+	cfg.Connector.Provider = "file"
+
+	cfg.Connector.Extra = map[string]string{"file": filepath.Join(dir, "*.log")}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected matching glob to be valid, got %v", err)
+	}
+
+	cfg.Connector.Extra = map[string]string{"file": filepath.Join(dir, "*.gz")}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "no log files match") {
+		t.Errorf("expected no-match error, got %v", err)
+	}
+
+	// Follow mode waits for files to appear.
+	cfg.Connector.Extra = map[string]string{"file": filepath.Join(dir, "*.gz"), "follow": "true"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected follow mode to accept no matches, got %v", err)
+	}
+	cfg.Connector.Extra = map[string]string{"file": filepath.Join(dir, "later.log"), "follow": "true"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected follow mode to accept a missing file, got %v", err)
+	}
+
+	cfg.Connector.Extra = map[string]string{"file": filepath.Join(dir, "[.log"), "follow": "yes please", "checkpoint": "/nonexistent/dir/offsets.json"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{"invalid file pattern", `invalid follow "yes please"`, "checkpoint directory not accessible"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package file
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// checkpoint persists how far each followed file has been read, so a
+// restart resumes where the last run stopped instead of reading lines
+// again. A nil *checkpoint records nothing.
+type checkpoint struct {
+	path string
+
+	mu    sync.Mutex
+	files map[string]checkpointEntry
+	dirty bool
+}
+
+// checkpointEntry is the read offset of one file. Fingerprint hashes the
+// file's first bytes so an offset is not applied to a different file
+// that was rotated in at the same path.
+type checkpointEntry struct {
+	Offset      int64  `json:"offset"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type checkpointFile struct {
+	Files map[string]checkpointEntry `json:"files"`
+}
+
+// loadCheckpoint reads the checkpoint at path; a missing file starts an
+// empty one. An empty path disables checkpointing.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+	cp := &checkpoint{path: path, files: make(map[string]checkpointEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint: %w", err)
+	}
+	var f checkpointFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %w", path, err)
+	}
+	for k, e := range f.Files {
+		cp.files[k] = e
+	}
+	return cp, nil
+}
+
+// lookup returns the entry for the file at key, of the given size, whose
+// first bytes hash to fingerprint(offset). The entry under key is tried
+// first; failing that, any entry matching the file, so a file rotated to a
+// new name while nothing followed it, such as app.log renamed app.log.1,
+// resumes where it was left under its old name.
+func (c *checkpoint) lookup(key string, size int64, fingerprint func(offset int64) string) (checkpointEntry, bool) {
+	if c == nil {
+		return checkpointEntry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	matches := func(e checkpointEntry) bool {
+		return e.Offset <= size && fingerprint(e.Offset) == e.Fingerprint
+	}
+	if e, ok := c.files[key]; ok && matches(e) {
+		return e, true
+	}
+	var best checkpointEntry
+	found := false
+	for k, e := range c.files {
+		// An empty prefix matches every file.
+		if k != key && e.Offset > 0 && e.Offset > best.Offset && matches(e) {
+			best, found = e, true
+		}
+	}
+	return best, found
+}
+
+func (c *checkpoint) set(key string, offset int64, fingerprint string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := checkpointEntry{Offset: offset, Fingerprint: fingerprint}
+	if c.files[key] != e {
+		c.files[key] = e
+		c.dirty = true
+	}
+}
+
+func (c *checkpoint) remove(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.files[key]; ok {
+		delete(c.files, key)
+		c.dirty = true
+	}
+}
+
+// save writes the checkpoint if it changed, replacing the file atomically
+// so a crash never leaves it half written.
+func (c *checkpoint) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	data, err := json.MarshalIndent(checkpointFile{Files: c.files}, "", "  ")
+	c.dirty = false
+	c.mu.Unlock()
+	if err == nil {
+		err = writeAtomic(c.path, data)
+	}
+	if err != nil {
+		// Retry on the next save.
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func fingerprintOf(head []byte) string {
+	sum := sha256.Sum256(head)
+	return hex.EncodeToString(sum[:8])
+}
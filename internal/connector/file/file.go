@@ -4,12 +4,18 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/connector/format"
+	"github.com/kaminocorp/lumber/internal/connector/multiline"
+	"github.com/kaminocorp/lumber/internal/connector/timestamp"
 	"github.com/kaminocorp/lumber/internal/model"
 )
 
@@ -27,82 +33,109 @@ func init() {
 	})
 }
 
-// Connector reads log lines from a file on disk.
+// Connector reads log lines from files on disk. cfg.Extra["file"] is a
+// path or a glob pattern such as /var/log/app/*.log. gzip, zstd and bzip2
+// files are decompressed and tar archives are read member by member (see
+// eachMember).
 type Connector struct{}
 
-// Stream reads all lines from the file specified in cfg.Extra["file"]
-// and sends each as a RawLog on the returned channel. The channel closes
-// on EOF or context cancellation.
+// Stream reads all lines from the files matching cfg.Extra["file"], one
+// file after another, and sends each as a RawLog on the returned channel.
+// The channel closes on EOF or context cancellation. Each log is stamped
+// with the timestamp found in its line, or the read time when there is
+// none, structured lines are split into message and fields, and
+// continuation lines such as stack frames are joined to the line before
+// them (see lineParser).
+//
+// With cfg.Extra["follow"] set to true, Stream tails the files instead,
+// like tail -F, and the channel only closes on context cancellation (see
+// follow).
 func (c *Connector) Stream(ctx context.Context, cfg connector.ConnectorConfig) (<-chan model.RawLog, error) {
-	filePath, err := resolveFilePath(cfg)
+	pattern, err := resolveFilePath(cfg)
 	if err != nil {
 		return nil, err
 	}
+	lp, err := newLineParser(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if follows, _ := strconv.ParseBool(cfg.Extra["follow"]); follows {
+		return follow(ctx, cfg, pattern, lp)
+	}
 
-	f, err := os.Open(filePath)
+	paths, err := matchFiles(pattern)
 	if err != nil {
-		return nil, fmt.Errorf("file connector: %w", err)
+		return nil, err
+	}
+	files := make([]*os.File, 0, len(paths))
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, fmt.Errorf("file connector: %w", err)
+		}
+		files = append(files, f)
 	}
 
 	ch := make(chan model.RawLog, 64)
 	go func() {
 		defer close(ch)
-		defer f.Close()
-
-		scanner := bufio.NewScanner(f)
-		scanner.Buffer(make([]byte, 0, maxLineSize), maxLineSize)
-
-		for scanner.Scan() {
-			// Fast-exit: check context before processing the scanned line.
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-			line := scanner.Text()
-			if line == "" {
-				continue
-			}
-			raw := model.RawLog{
-				Timestamp: time.Now(),
-				Source:    "file",
-				Raw:       line,
-				Metadata: map[string]any{
-					"file": filepath.Base(filePath),
-				},
+		defer func() {
+			for _, f := range files {
+				f.Close()
 			}
+		}()
+
+		emit := func(raw model.RawLog) bool {
 			select {
 			case ch <- raw:
+				return true
 			case <-ctx.Done():
-				return
+				return false
 			}
 		}
-		if err := scanner.Err(); err != nil {
-			slog.Warn("file connector: scanner error", "error", err, "file", filePath)
+		for _, f := range files {
+			err := eachMember(f, f.Name(), func(name string, r io.Reader) bool {
+				if err := lp.readEvents(ctx, r, name, emit); err != nil {
+					slog.Warn("file connector: scanner error", "error", err, "file", name)
+				}
+				return ctx.Err() == nil
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				slog.Warn("file connector: cannot read file", "error", err, "file", f.Name())
+			}
 		}
 	}()
 
 	return ch, nil
 }
 
-// Query reads lines from the file, returning up to params.Limit results.
-// When Limit is 0, a default cap of 100,000 lines is applied to prevent
-// unbounded memory allocation. Start/End time filters are not applicable
-// to file lines (they have no inherent timestamp) and are ignored.
+// Query reads events from the files matching cfg.Extra["file"], returning
+// up to params.Limit results. Events of several files, or of the members
+// of an archive, are merged in timestamp order and the limit applies to
+// them all: only the earliest Limit events are kept while reading, and a
+// file is read no further once its events sort after them. When Limit is
+// 0, a default cap of 100,000 events is applied to prevent unbounded
+// memory allocation. Start/End keep events timestamped in [Start, End);
+// an event without a timestamp follows the last timestamped event before
+// it.
 func (c *Connector) Query(ctx context.Context, cfg connector.ConnectorConfig, params connector.QueryParams) ([]model.RawLog, error) {
-	filePath, err := resolveFilePath(cfg)
+	pattern, err := resolveFilePath(cfg)
 	if err != nil {
 		return nil, err
 	}
-
-	f, err := os.Open(filePath)
+	lp, err := newLineParser(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("file connector: %w", err)
+		return nil, err
 	}
-	defer f.Close()
-
-	if !params.Start.IsZero() || !params.End.IsZero() {
-		slog.Debug("file connector: time range filters ignored (file lines have no inherent timestamp)")
+	paths, err := matchFiles(pattern)
+	if err != nil {
+		return nil, err
 	}
 
 	limit := params.Limit
@@ -110,39 +143,208 @@ func (c *Connector) Query(ctx context.Context, cfg connector.ConnectorConfig, pa
 		limit = defaultQueryLimit
 	}
 
-	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, maxLineSize), maxLineSize)
+	q := &query{lp: lp, params: params, limit: limit}
+	for _, path := range paths {
+		if err := q.file(ctx, path); err != nil {
+			return q.results, err
+		}
+	}
+	return q.results, nil
+}
+
+// query holds the earliest events read so far across files and members,
+// at most limit of them, in timestamp order.
+type query struct {
+	lp      *lineParser
+	params  connector.QueryParams
+	limit   int
+	results []model.RawLog
+}
+
+// file merges the events of each member of the file at path into the
+// results.
+func (q *query) file(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("file connector: %w", err)
+	}
+	defer f.Close()
+
+	var queryErr error
+	err = eachMember(f, path, func(name string, r io.Reader) bool {
+		events, err := q.member(ctx, r, name)
+		q.results = mergeByTime([][]model.RawLog{q.results, events}, q.limit)
+		queryErr = err
+		return err == nil
+	})
+	if queryErr != nil {
+		return queryErr
+	}
+	if err != nil {
+		return fmt.Errorf("file connector: %w", err)
+	}
+	return nil
+}
+
+// member reads the events of one file or archive member within the query
+// window, stopping at limit events or, once the results are full, at the
+// first event that sorts after all of them.
+func (q *query) member(ctx context.Context, r io.Reader, name string) ([]model.RawLog, error) {
+	var cutoff time.Time
+	full := len(q.results) >= q.limit
+	if full {
+		cutoff = sortKeys(q.results)[len(q.results)-1]
+	}
 
 	var results []model.RawLog
+	var key time.Time
+	inRange := true
+	err := q.lp.readEvents(ctx, r, name, func(raw model.RawLog) bool {
+		if raw.TimeSource == model.TimeParsed {
+			key = raw.Timestamp
+			inRange = inWindow(raw.Timestamp, q.params.Start, q.params.End)
+		}
+		if full && !key.Before(cutoff) {
+			return false
+		}
+		if inRange {
+			results = append(results, raw)
+		}
+		return len(results) < q.limit
+	})
+	if ctx.Err() != nil {
+		return results, ctx.Err()
+	}
+	if err != nil {
+		return results, fmt.Errorf("file connector: scanner error: %w", err)
+	}
+	return results, nil
+}
+
+// readEvents scans r, the contents of the file called name, and hands
+// each event to emit until emit returns false, r is exhausted or ctx is
+// cancelled.
+func (p *lineParser) readEvents(ctx context.Context, r io.Reader, name string, emit func(model.RawLog) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, maxLineSize), maxLineSize)
+	lines := p.lines.Clone()
+
 	for scanner.Scan() {
-		// Check for context cancellation periodically.
+		// Fast-exit: check context before processing the scanned line.
 		select {
 		case <-ctx.Done():
-			return results, ctx.Err()
+			return nil
 		default:
 		}
-
 		line := scanner.Text()
 		if line == "" {
 			continue
 		}
-		results = append(results, model.RawLog{
-			Timestamp: time.Now(),
-			Source:    "file",
-			Raw:       line,
-			Metadata: map[string]any{
-				"file": filepath.Base(filePath),
-			},
-		})
-		if len(results) >= limit {
+		if raw, ok := lines.Add(p.rawLog(line, name)); ok && !emit(raw) {
+			return nil
+		}
+	}
+	if raw, ok := lines.Flush(); ok {
+		emit(raw)
+	}
+	return scanner.Err()
+}
+
+// mergeByTime merges the events of several files into timestamp order,
+// keeping each file's own order, and returns at most limit events. An
+// event without a parsed timestamp sorts with the event before it.
+func mergeByTime(perFile [][]model.RawLog, limit int) []model.RawLog {
+	keys := make([][]time.Time, len(perFile))
+	total := 0
+	for i, events := range perFile {
+		keys[i] = sortKeys(events)
+		total += len(events)
+	}
+
+	merged := make([]model.RawLog, 0, min(total, limit))
+	next := make([]int, len(perFile))
+	for len(merged) < limit {
+		best := -1
+		for i := range perFile {
+			if next[i] < len(perFile[i]) && (best < 0 || keys[i][next[i]].Before(keys[best][next[best]])) {
+				best = i
+			}
+		}
+		if best < 0 {
 			break
 		}
+		merged = append(merged, perFile[best][next[best]])
+		next[best]++
 	}
-	if err := scanner.Err(); err != nil {
-		return results, fmt.Errorf("file connector: scanner error: %w", err)
+	return merged
+}
+
+// sortKeys returns the time each event sorts by: its parsed timestamp, or
+// that of the last event before it with one.
+func sortKeys(events []model.RawLog) []time.Time {
+	keys := make([]time.Time, len(events))
+	var last time.Time
+	for i, raw := range events {
+		if raw.TimeSource == model.TimeParsed {
+			last = raw.Timestamp
+		}
+		keys[i] = last
 	}
+	return keys
+}
 
-	return results, nil
+// lineParser turns file lines into RawLogs and joins them into events.
+type lineParser struct {
+	ts     *timestamp.Parser
+	format *format.Parser
+	// lines holds the multi-line settings; each file gets a Clone.
+	lines *multiline.Assembler
+}
+
+// newLineParser builds the parsers from the "timestamp_layout", "timezone",
+// "format", "message_field" and "multiline*" Extra keys.
+func newLineParser(cfg connector.ConnectorConfig) (*lineParser, error) {
+	ts, err := timestamp.New(cfg.Extra["timestamp_layout"], cfg.Extra["timezone"])
+	if err != nil {
+		return nil, fmt.Errorf("file connector: %w", err)
+	}
+	fp, err := format.New(cfg.Extra["format"], cfg.Extra["message_field"])
+	if err != nil {
+		return nil, fmt.Errorf("file connector: %w", err)
+	}
+	mc, err := multiline.FromExtra(cfg.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("file connector: %w", err)
+	}
+	lines, err := multiline.New(mc)
+	if err != nil {
+		return nil, fmt.Errorf("file connector: %w", err)
+	}
+	return &lineParser{ts: ts, format: fp, lines: lines}, nil
+}
+
+// rawLog stamps line with its timestamp and splits it into the message,
+// as Raw, and its fields, as Metadata alongside the file name.
+func (p *lineParser) rawLog(line, name string) model.RawLog {
+	t, source := p.ts.Stamp(line)
+	msg, fields := p.format.Parse(line)
+	md := make(map[string]any, len(fields)+1)
+	for k, v := range fields {
+		md[k] = v
+	}
+	md["file"] = name
+	return model.RawLog{
+		Timestamp:  t,
+		TimeSource: source,
+		Source:     "file",
+		Raw:        msg,
+		Metadata:   md,
+	}
+}
+
+// inWindow reports whether t is in [start, end); a zero bound is open.
+func inWindow(t, start, end time.Time) bool {
+	return (start.IsZero() || !t.Before(start)) && (end.IsZero() || t.Before(end))
 }
 
 // resolveFilePath extracts and validates the file path from connector config.
@@ -153,3 +355,25 @@ func resolveFilePath(cfg connector.ConnectorConfig) (string, error) {
 	}
 	return filePath, nil
 }
+
+// isGlob reports whether path is a glob pattern rather than a file path.
+func isGlob(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// matchFiles returns the files matching pattern in lexical order. A path
+// without glob characters is returned as is, so opening it reports a
+// missing file.
+func matchFiles(pattern string) ([]string, error) {
+	if !isGlob(pattern) {
+		return []string{pattern}, nil
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("file connector: invalid pattern %q: %w", pattern, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("file connector: no files match %q", pattern)
+	}
+	return paths, nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/model"
 )
 
 func TestStream_ReadsFile(t *testing.T) {
@@ -162,20 +163,140 @@ func TestQuery_ReadsAllWithoutLimit(t *testing.T) {
 	}
 }
 
-func TestQuery_TimeFiltersIgnored(t *testing.T) {
-	path := writeTempFile(t, "a\nb\n")
+func TestQuery_TimeFilters(t *testing.T) {
+	path := writeTempFile(t, strings.Join([]string{
+		"2026-02-19T11:59:00Z INFO warming up",
+		"2026-02-19T12:00:01Z ERROR db timeout",
+		"\tat com.acme.Pool.acquire(Pool.java:42)",
+		"2026-02-19T12:30:00Z INFO recovered",
+		"2026-02-19T13:00:00Z INFO shutdown",
+		"\tat com.acme.Main.stop(Main.java:9)",
+	}, "\n"))
 	c := &Connector{}
+	cfg := cfgWithFile(path)
+	cfg.Extra["multiline"] = "false"
 
-	// Time filters should be ignored (file lines have no timestamp).
-	results, err := c.Query(context.Background(), cfgWithFile(path), connector.QueryParams{
-		Start: time.Now().Add(-time.Hour),
-		End:   time.Now(),
+	results, err := c.Query(context.Background(), cfg, connector.QueryParams{
+		Start: time.Date(2026, 2, 19, 12, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 2, 19, 13, 0, 0, 0, time.UTC),
 	})
 	if err != nil {
 		t.Fatal(err)
 	}
+	// Without multi-line assembly the stack frame follows its ERROR line
+	// in; the last one follows the out-of-range shutdown line out.
+	if len(results) != 3 || !strings.Contains(results[1].Raw, "Pool.acquire") {
+		t.Fatalf("expected ERROR, its frame and recovered, got %+v", results)
+	}
+	if results[0].TimeSource != model.TimeParsed || results[1].TimeSource != model.TimeInferred {
+		t.Errorf("unexpected time sources %q, %q", results[0].TimeSource, results[1].TimeSource)
+	}
+}
+
+func TestQuery_NoTimestampsWithoutFilter(t *testing.T) {
+	path := writeTempFile(t, "a\nb\n")
+	c := &Connector{}
+
+	results, err := c.Query(context.Background(), cfgWithFile(path), connector.QueryParams{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].TimeSource != model.TimeInferred || results[0].Timestamp.IsZero() {
+		t.Fatalf("expected 2 results stamped with the read time, got %+v", results)
+	}
+}
+
+func TestStream_ParsesTimestamps(t *testing.T) {
+	path := writeTempFile(t, "19.02.2026 13:00:01 ERROR payment failed\n")
+	cfg := cfgWithFile(path)
+	cfg.Extra["timestamp_layout"] = "02.01.2006 15:04:05"
+	cfg.Extra["timezone"] = "Europe/Berlin"
+
+	ch, err := (&Connector{}).Stream(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := <-ch
+	if want := time.Date(2026, 2, 19, 12, 0, 1, 0, time.UTC); !raw.Timestamp.Equal(want) || raw.TimeSource != model.TimeParsed {
+		t.Errorf("Timestamp = %s (%s), want %s parsed", raw.Timestamp, raw.TimeSource, want)
+	}
+
+	cfg.Extra["timezone"] = "Mars/Olympus"
+	if _, err := (&Connector{}).Stream(context.Background(), cfg); err == nil {
+		t.Error("expected invalid timezone error")
+	}
+}
+
+func TestStream_ParsesInputFormat(t *testing.T) {
+	path := writeTempFile(t, `{"level":"error","msg":"db timeout","pool":"main"}`+"\nplain text line\n")
+	ch, err := (&Connector{}).Stream(context.Background(), cfgWithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, plain := <-ch, <-ch
+	if parsed.Raw != "db timeout" || parsed.Metadata["level"] != "error" || parsed.Metadata["pool"] != "main" || parsed.Metadata["file"] != filepath.Base(path) {
+		t.Errorf("JSON line: Raw=%q Metadata=%v", parsed.Raw, parsed.Metadata)
+	}
+	if plain.Raw != "plain text line" || len(plain.Metadata) != 1 {
+		t.Errorf("plain line: Raw=%q Metadata=%v", plain.Raw, plain.Metadata)
+	}
+
+	cfg := cfgWithFile(path)
+	cfg.Extra["format"] = "raw"
+	ch, err = (&Connector{}).Stream(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raw := <-ch; !strings.HasPrefix(raw.Raw, "{") {
+		t.Errorf("raw format should keep the line whole, got %q", raw.Raw)
+	}
+
+	cfg.Extra["format"] = "xml"
+	if _, err := (&Connector{}).Stream(context.Background(), cfg); err == nil {
+		t.Error("expected invalid format error")
+	}
+}
+
+func TestQuery_JoinsMultiLineEvents(t *testing.T) {
+	path := writeTempFile(t, strings.Join([]string{
+		"2026-02-19T12:00:01Z ERROR db timeout",
+		"java.lang.IllegalStateException: pool exhausted",
+		"\tat com.acme.Pool.acquire(Pool.java:42)",
+		"\tat com.acme.Api.handle(Api.java:7)",
+		"2026-02-19T12:00:02Z INFO retrying",
+		"2026-02-19T12:00:03Z INFO done",
+	}, "\n"))
+
+	results, err := (&Connector{}).Query(context.Background(), cfgWithFile(path), connector.QueryParams{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(results) != 2 {
-		t.Fatalf("expected 2 results (time filters ignored), got %d", len(results))
+		t.Fatalf("expected 2 events, got %d: %+v", len(results), results)
+	}
+	if !strings.HasSuffix(results[1].Raw, "pool exhausted\n\tat com.acme.Pool.acquire(Pool.java:42)\n\tat com.acme.Api.handle(Api.java:7)") {
+		t.Errorf("frames not joined: %q", results[1].Raw)
+	}
+}
+
+func TestStream_JoinsMultiLineEvents(t *testing.T) {
+	path := writeTempFile(t, "ERROR request failed\nTraceback (most recent call last):\n  File \"/app/api.py\", line 3, in handle\nKeyError: 'id'\nINFO next\n")
+	ch, err := (&Connector{}).Stream(context.Background(), cfgWithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for raw := range ch {
+		events = append(events, raw.Raw)
+	}
+	if len(events) != 2 || !strings.HasSuffix(events[0], "KeyError: 'id'") || events[1] != "INFO next" {
+		t.Errorf("unexpected events: %q", events)
+	}
+
+	cfg := cfgWithFile(path)
+	cfg.Extra["multiline_start"] = "("
+	if _, err := (&Connector{}).Stream(context.Background(), cfg); err == nil {
+		t.Error("expected invalid pattern error")
 	}
 }
 
@@ -0,0 +1,439 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/connector/multiline"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// defaultPollInterval is how often follow mode checks files for new lines
+// and rotation, and the glob for new files.
+const defaultPollInterval = time.Second
+
+// follower tails every file matching a pattern, like tail -F. Files are
+// polled rather than watched, so follow mode works the same on every
+// platform and on network filesystems.
+type follower struct {
+	pattern string
+	lp      *lineParser
+	poll    time.Duration
+	cp      *checkpoint
+	out     chan model.RawLog
+	wg      sync.WaitGroup
+
+	mu     sync.Mutex
+	active map[string]os.FileInfo // paths with a running tail, and the file each reads
+	read   []readFile             // files a tail finished, e.g. renamed by rotation
+}
+
+// readFile is how far a finished tail read a file, so a glob matching the
+// file under its rotated name continues from there.
+type readFile struct {
+	info   os.FileInfo
+	offset int64
+}
+
+// maxReadFiles bounds the finished files remembered.
+const maxReadFiles = 1024
+
+// follow starts tailing the files matching pattern. Files are read from
+// the start, or from their checkpointed offset (cfg.Extra["checkpoint"]),
+// and then followed for new lines. A file that is truncated is read again
+// from the start; one that is renamed or recreated, as by logrotate, is
+// read to its end and then replaced by the new file at the same path.
+// The glob is re-checked for new files every cfg.Extra["poll_interval"].
+func follow(ctx context.Context, cfg connector.ConnectorConfig, pattern string, lp *lineParser) (<-chan model.RawLog, error) {
+	if _, err := filepath.Glob(pattern); err != nil {
+		return nil, fmt.Errorf("file connector: invalid pattern %q: %w", pattern, err)
+	}
+	poll := defaultPollInterval
+	if raw := cfg.Extra["poll_interval"]; raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			poll = d
+		}
+	}
+	cp, err := loadCheckpoint(cfg.Extra["checkpoint"])
+	if err != nil {
+		return nil, fmt.Errorf("file connector: %w", err)
+	}
+
+	fw := &follower{
+		pattern: pattern,
+		lp:      lp,
+		poll:    poll,
+		cp:      cp,
+		out:     make(chan model.RawLog, 64),
+		active:  make(map[string]os.FileInfo),
+	}
+	go fw.run(ctx)
+	return fw.out, nil
+}
+
+func (fw *follower) run(ctx context.Context) {
+	defer close(fw.out)
+	ticker := time.NewTicker(fw.poll)
+	defer ticker.Stop()
+
+	for {
+		fw.discover(ctx)
+		if err := fw.cp.save(); err != nil {
+			slog.Warn("file connector: checkpoint not saved", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			fw.wg.Wait()
+			if err := fw.cp.save(); err != nil {
+				slog.Warn("file connector: checkpoint not saved", "error", err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// discover starts a tail for each matching file not tailed yet. A file
+// that a tail reads under another name, as after a rename, is left to it.
+func (fw *follower) discover(ctx context.Context) {
+	paths, _ := filepath.Glob(fw.pattern) // validated in follow
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		fw.mu.Lock()
+		_, running := fw.active[path]
+		for _, other := range fw.active {
+			if !running && other != nil && os.SameFile(info, other) {
+				running = true
+			}
+		}
+		if !running {
+			fw.active[path] = nil
+		}
+		fw.mu.Unlock()
+		if running {
+			continue
+		}
+		fw.wg.Add(1)
+		go func() {
+			defer fw.wg.Done()
+			if fw.tail(ctx, path) {
+				fw.mu.Lock()
+				delete(fw.active, path)
+				fw.mu.Unlock()
+			}
+		}()
+	}
+}
+
+// tail follows one file until it is removed or ctx is cancelled. Lines
+// are joined into events per file, so interleaved writes to different
+// files never mix. Returns false for a file that must not be followed
+// again: compressed files, such as logs rotated with compression, do not
+// grow and are skipped.
+func (fw *follower) tail(ctx context.Context, path string) bool {
+	t, err := openTail(path, fw.cp)
+	if err != nil {
+		slog.Warn("file connector: cannot follow file", "error", err, "file", path)
+		return true
+	}
+	defer func() { t.f.Close() }()
+
+	head := make([]byte, 4)
+	n, _ := t.f.ReadAt(head, 0)
+	if isCompressed(head[:n], path) {
+		slog.Debug("file connector: not following compressed file", "file", path)
+		return false
+	}
+	if !fw.resume(t) {
+		return true
+	}
+
+	ev := &tailEvents{ctx: ctx, fw: fw, lines: fw.lp.lines.Clone(), name: filepath.Base(path)}
+	ev.reset(t.offset)
+	ticker := time.NewTicker(fw.poll)
+	defer ticker.Stop()
+	for {
+		if !t.read(ev.add) {
+			return true
+		}
+		fw.cp.set(t.key, ev.done, t.fingerprint(ev.done))
+
+		// The pending event is sent once no line has joined it for the
+		// multiline timeout.
+		var flush <-chan time.Time
+		if ev.lines.Pending() {
+			flush = time.After(time.Until(ev.last.Add(ev.lines.Timeout())))
+		}
+		select {
+		case <-ctx.Done():
+			return true
+		case <-flush:
+			if !ev.flush() {
+				return true
+			}
+			continue
+		case <-ticker.C:
+		}
+
+		info, err := os.Stat(path)
+		switch {
+		case err != nil:
+			// Removed, or renamed and not recreated yet: finish the old
+			// file; a file created later at path gets a new tail.
+			fw.finish(t, ev)
+			fw.cp.remove(t.key)
+			return true
+		case !os.SameFile(info, t.info):
+			// Rotated: finish the old file and continue with the new one.
+			if !fw.finish(t, ev) {
+				return true
+			}
+			t.f.Close()
+			if t, err = openTail(path, nil); err != nil {
+				slog.Warn("file connector: cannot follow file", "error", err, "file", path)
+				fw.cp.remove(t.key)
+				return true
+			}
+			if !fw.resume(t) {
+				return true
+			}
+			ev.reset(t.offset)
+			slog.Debug("file connector: file rotated", "file", path)
+		case info.Size() < t.offset+int64(len(t.partial)):
+			// Truncated in place: read again from the start.
+			if !ev.flush() {
+				return true
+			}
+			if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+				slog.Warn("file connector: cannot follow file", "error", err, "file", path)
+				return true
+			}
+			t.offset, t.partial, t.head = 0, nil, ""
+			ev.reset(0)
+			slog.Debug("file connector: file truncated", "file", path)
+		}
+	}
+}
+
+// tailEvents joins the lines of a followed file into events and sends
+// them. done is the offset up to which every line has been sent as part
+// of an event: lines of the pending event are not, so the checkpoint
+// records done and a restart reads the pending event again in full.
+type tailEvents struct {
+	ctx   context.Context
+	fw    *follower
+	lines *multiline.Assembler
+	name  string
+
+	done int64     // end of the last line sent in an event
+	end  int64     // end of the last line added
+	last time.Time // when the pending event last grew
+}
+
+// reset starts over at offset, with nothing pending.
+func (e *tailEvents) reset(offset int64) {
+	e.done, e.end = offset, offset
+}
+
+// add joins the line read at [start, end) of the file. Returns false when
+// ctx is cancelled.
+func (e *tailEvents) add(line string, start, end int64) bool {
+	raw, ok := e.lines.Add(e.fw.lp.rawLog(line, e.name))
+	e.end, e.last = end, time.Now()
+	if ok && !e.send(raw) {
+		return false
+	}
+	switch {
+	case !e.lines.Pending():
+		e.done = end // multi-line joining is off
+	case ok:
+		e.done = start // the line began a new pending event
+	}
+	return true
+}
+
+// flush sends the pending event. Returns false when ctx is cancelled.
+func (e *tailEvents) flush() bool {
+	if raw, ok := e.lines.Flush(); ok && !e.send(raw) {
+		return false
+	}
+	e.done = e.end
+	return true
+}
+
+func (e *tailEvents) send(raw model.RawLog) bool {
+	select {
+	case e.fw.out <- raw:
+		return true
+	case <-e.ctx.Done():
+		return false
+	}
+}
+
+// resume registers the file t reads as active and moves t to where a
+// finished tail left the same file. Returns false on a seek error.
+func (fw *follower) resume(t *tailFile) bool {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	for i, r := range fw.read {
+		if os.SameFile(r.info, t.info) {
+			fw.read = append(fw.read[:i], fw.read[i+1:]...)
+			if _, err := t.f.Seek(r.offset, io.SeekStart); err != nil {
+				slog.Warn("file connector: cannot follow file", "error", err, "file", t.key)
+				return false
+			}
+			t.offset, t.partial, t.head = r.offset, nil, ""
+			break
+		}
+	}
+	fw.active[t.path] = t.info
+	return true
+}
+
+// finish reads t's file to its end, sends its last event and records how
+// far it was read, so a tail of the file under a rotated name continues
+// from there. When the glob matches that name, the offset is also
+// checkpointed under it for the next run. Returns false when ctx is
+// cancelled.
+func (fw *follower) finish(t *tailFile, ev *tailEvents) bool {
+	if !t.read(ev.add) || !t.flush(ev.add) || !ev.flush() {
+		return false
+	}
+	fw.mu.Lock()
+	if len(fw.read) >= maxReadFiles {
+		fw.read = fw.read[1:]
+	}
+	fw.read = append(fw.read, readFile{info: t.info, offset: ev.done})
+	fw.mu.Unlock()
+
+	paths, _ := filepath.Glob(fw.pattern)
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil && os.SameFile(info, t.info) {
+			key, err := filepath.Abs(path)
+			if err != nil {
+				key = path
+			}
+			fw.cp.set(key, ev.done, t.fingerprint(ev.done))
+			break
+		}
+	}
+	return true
+}
+
+// fingerprintSize is how many leading bytes identify a file in the
+// checkpoint, so a resumed offset is only used for the same file.
+const fingerprintSize = 1024
+
+// tailFile is an open file being followed.
+type tailFile struct {
+	path    string
+	key     string // absolute path, the checkpoint key
+	f       *os.File
+	info    os.FileInfo
+	offset  int64  // end of the last complete line read
+	partial []byte // bytes after offset with no newline yet
+	head    string // fingerprint once the file has fingerprintSize bytes
+	buf     []byte
+}
+
+// openTail opens path at its checkpointed offset when cp has one for the
+// same file, under path or under the name it had before a rotation, or at
+// the start.
+func openTail(path string, cp *checkpoint) (*tailFile, error) {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return &tailFile{key: key}, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return &tailFile{key: key}, err
+	}
+	t := &tailFile{path: path, key: key, f: f, info: info, buf: make([]byte, 64*1024)}
+	if e, ok := cp.lookup(key, info.Size(), t.fingerprint); ok {
+		t.offset = e.Offset
+	}
+	if _, err := f.Seek(t.offset, io.SeekStart); err != nil {
+		f.Close()
+		return t, err
+	}
+	return t, nil
+}
+
+// read emits the complete lines written since the last read, with their
+// start and end offsets. Returns false when emit does.
+func (t *tailFile) read(emit func(line string, start, end int64) bool) bool {
+	for {
+		n, err := t.f.Read(t.buf)
+		if n > 0 {
+			data := append(t.partial, t.buf[:n]...)
+			for {
+				i := bytes.IndexByte(data, '\n')
+				if i < 0 {
+					break
+				}
+				line := string(bytes.TrimSuffix(data[:i], []byte("\r")))
+				data = data[i+1:]
+				start := t.offset
+				t.offset += int64(i + 1)
+				if line != "" && !emit(line, start, t.offset) {
+					return false
+				}
+			}
+			t.partial = append([]byte(nil), data...)
+			if len(t.partial) > maxLineSize {
+				if !t.flush(emit) {
+					return false
+				}
+			}
+		}
+		if err != nil {
+			if err != io.EOF {
+				slog.Warn("file connector: read error", "error", err, "file", t.key)
+			}
+			return true
+		}
+	}
+}
+
+// flush emits the partial last line, for a file that will not grow.
+func (t *tailFile) flush(emit func(line string, start, end int64) bool) bool {
+	line := string(bytes.TrimSuffix(t.partial, []byte("\r")))
+	start := t.offset
+	t.offset += int64(len(t.partial))
+	t.partial = nil
+	return line == "" || emit(line, start, t.offset)
+}
+
+// fingerprint hashes the first bytes of the file, up to fingerprintSize
+// and no further than offset.
+func (t *tailFile) fingerprint(offset int64) string {
+	if t.head != "" && offset >= fingerprintSize {
+		return t.head
+	}
+	n := min(offset, fingerprintSize)
+	buf := make([]byte, n)
+	if _, err := t.f.ReadAt(buf, 0); err != nil && err != io.EOF {
+		return ""
+	}
+	fp := fingerprintOf(buf)
+	if n == fingerprintSize {
+		t.head = fp
+	}
+	return fp
+}
@@ -0,0 +1,342 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func followCfg(pattern string) connector.ConnectorConfig {
+	return connector.ConnectorConfig{Extra: map[string]string{
+		"file":          pattern,
+		"follow":        "true",
+		"poll_interval": "10ms",
+		"multiline":     "false",
+	}}
+}
+
+// next reads n logs from ch, failing the test after two seconds.
+func next(t *testing.T, ch <-chan model.RawLog, n int) []string {
+	t.Helper()
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for len(got) < n {
+		select {
+		case raw, ok := <-ch:
+			if !ok {
+				t.Fatalf("channel closed after %q", got)
+			}
+			got = append(got, raw.Raw)
+		case <-timeout:
+			t.Fatalf("timed out after %q, want %d lines", got, n)
+		}
+	}
+	return got
+}
+
+func appendFile(t *testing.T, path, content string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFollow_TailsAppendedLines(t *testing.T) {
+	path := writeTempFile(t, "one\ntwo\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := (&Connector{}).Stream(ctx, followCfg(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, ch, 2); strings.Join(got, ",") != "one,two" {
+		t.Fatalf("got %q", got)
+	}
+
+	// A line is only read once its newline is written.
+	appendFile(t, path, "thr")
+	appendFile(t, path, "ee\n")
+	if got := next(t, ch, 1); got[0] != "three" {
+		t.Errorf("got %q, want three", got)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestFollow_Truncation(t *testing.T) {
+	path := writeTempFile(t, "old line one\nold line two\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := (&Connector{}).Stream(ctx, followCfg(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, ch, 2)
+
+	if err := os.WriteFile(path, []byte("new\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, ch, 1); got[0] != "new" {
+		t.Errorf("got %q after truncation, want new", got)
+	}
+}
+
+func TestFollow_Rotation(t *testing.T) {
+	path := writeTempFile(t, "before\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := (&Connector{}).Stream(ctx, followCfg(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, ch, 1)
+
+	// logrotate: rename, keep writing to the old file briefly, recreate.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "late write\n")
+	appendFile(t, path, "after\n")
+
+	got := next(t, ch, 2)
+	if strings.Join(got, ",") != "late write,after" {
+		t.Errorf("got %q, want the old file's last line then the new file", got)
+	}
+}
+
+func TestFollow_GlobPicksUpNewFiles(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "a.log"), "from a\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch, err := (&Connector{}).Stream(ctx, followCfg(filepath.Join(dir, "*.log")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, ch, 1)
+
+	appendFile(t, filepath.Join(dir, "b.log"), "from b\n")
+	appendFile(t, filepath.Join(dir, "ignored.txt"), "not a log\n")
+	if got := next(t, ch, 1); got[0] != "from b" {
+		t.Errorf("got %q, want the new file's line", got)
+	}
+}
+
+func TestFollow_CheckpointResumes(t *testing.T) {
+	path := writeTempFile(t, "one\ntwo\n")
+	cfg := followCfg(path)
+	cfg.Extra["checkpoint"] = filepath.Join(t.TempDir(), "offsets.json")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := (&Connector{}).Stream(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, ch, 2)
+	time.Sleep(50 * time.Millisecond) // let a poll record the offset
+	cancel()
+	for range ch {
+	}
+
+	data, err := os.ReadFile(cfg.Extra["checkpoint"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved checkpointFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Files) != 1 {
+		t.Fatalf("expected one checkpointed file, got %s", data)
+	}
+
+	appendFile(t, path, "three\n")
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	ch, err = (&Connector{}).Stream(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, ch, 1); got[0] != "three" {
+		t.Errorf("got %q after restart, want only the new line", got)
+	}
+}
+
+func TestFollow_CheckpointIgnoredForOtherFile(t *testing.T) {
+	path := writeTempFile(t, "replacement file\n")
+	abs, _ := filepath.Abs(path)
+	cpPath := filepath.Join(t.TempDir(), "offsets.json")
+	data, _ := json.Marshal(checkpointFile{Files: map[string]checkpointEntry{abs: {Offset: 5, Fingerprint: "0123456789abcdef"}}})
+	if err := os.WriteFile(cpPath, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := followCfg(path)
+	cfg.Extra["checkpoint"] = cpPath
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := (&Connector{}).Stream(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, ch, 1); got[0] != "replacement file" {
+		t.Errorf("got %q, want the file read from the start", got)
+	}
+}
+
+func TestFollow_BadCheckpoint(t *testing.T) {
+	path := writeTempFile(t, "x\n")
+	cfg := followCfg(path)
+	cfg.Extra["checkpoint"] = writeTempFile(t, "not json")
+	if _, err := (&Connector{}).Stream(context.Background(), cfg); err == nil {
+		t.Error("expected checkpoint error")
+	}
+}
+
+func TestQuery_GlobMergesByTimestamp(t *testing.T) {
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "api.log"), strings.Join([]string{
+		"2026-02-19T12:00:01Z api started",
+		"2026-02-19T12:00:04Z api request failed",
+		"\tat com.acme.Api.handle(Api.java:7)",
+	}, "\n")+"\n")
+	appendFile(t, filepath.Join(dir, "worker.log"), strings.Join([]string{
+		"2026-02-19T12:00:02Z worker started",
+		"2026-02-19T12:00:03Z worker idle",
+		"2026-02-19T12:00:05Z worker stopped",
+	}, "\n")+"\n")
+
+	results, err := (&Connector{}).Query(context.Background(), cfgWithFile(filepath.Join(dir, "*.log")), connector.QueryParams{Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, raw := range results {
+		got = append(got, strings.SplitN(raw.Raw, " ", 2)[1])
+	}
+	want := "api started|worker started|worker idle|api request failed\n\tat com.acme.Api.handle(Api.java:7)"
+	if strings.Join(got, "|") != want {
+		t.Errorf("got %q", got)
+	}
+	if results[1].Metadata["file"] != "worker.log" {
+		t.Errorf("file metadata = %v", results[1].Metadata["file"])
+	}
+
+	if _, err := (&Connector{}).Query(context.Background(), cfgWithFile(filepath.Join(dir, "*.gz")), connector.QueryParams{}); err == nil {
+		t.Error("expected error when no files match")
+	}
+}
+
+func TestFollow_GlobMatchingRotatedName(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "before\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// app.log* also matches the rotated app.log.1, which must not be read
+	// again from the start.
+	ch, err := (&Connector{}).Stream(ctx, followCfg(filepath.Join(dir, "app.log*")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next(t, ch, 1)
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "late write\n")
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, path, "after\n")
+
+	got := next(t, ch, 2)
+	if strings.Join(got, ",") != "late write,after" {
+		t.Errorf("got %q", got)
+	}
+	select {
+	case raw := <-ch:
+		t.Errorf("unexpected line %q read again", raw.Raw)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// run follows cfg until want lines arrive, lets a poll save the
+// checkpoint and stops, returning the lines.
+func run(t *testing.T, cfg connector.ConnectorConfig, want int, during func()) []string {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := (&Connector{}).Stream(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if during != nil {
+		during()
+	}
+	got := next(t, ch, want)
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	for raw := range ch {
+		got = append(got, raw.Raw)
+	}
+	return got
+}
+
+func TestFollow_CheckpointRotatedWhileStopped(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "one\ntwo\n")
+	cfg := followCfg(filepath.Join(dir, "app.log*"))
+	cfg.Extra["checkpoint"] = filepath.Join(t.TempDir(), "offsets.json")
+	run(t, cfg, 2, nil)
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, path+".1", "three\n")
+	appendFile(t, path, "four\n")
+
+	got := run(t, cfg, 2, nil)
+	sort.Strings(got)
+	if strings.Join(got, ",") != "four,three" {
+		t.Errorf("got %q after restart, want only the unread lines", got)
+	}
+}
+
+func TestFollow_CheckpointExcludesPendingEvent(t *testing.T) {
+	path := writeTempFile(t, "first\nsecond\n\tat frame one\n")
+	cfg := followCfg(path)
+	cfg.Extra["multiline"] = "true"
+	cfg.Extra["multiline_timeout"] = "1h"
+	cfg.Extra["checkpoint"] = filepath.Join(t.TempDir(), "offsets.json")
+
+	// "second" and its frame wait for more lines when the run stops.
+	if got := run(t, cfg, 1, nil); strings.Join(got, ",") != "first" {
+		t.Fatalf("got %q, want only the complete event", got)
+	}
+
+	appendFile(t, path, "\tat frame two\nthird\n")
+	want := "second\n\tat frame one\n\tat frame two"
+	if got := run(t, cfg, 1, nil); len(got) != 1 || got[0] != want {
+		t.Errorf("got %q after restart, want the pending event in full", got)
+	}
+}
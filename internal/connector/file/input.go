@@ -0,0 +1,138 @@
+package file
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression formats recognized by their magic bytes, or by extension
+// when the magic bytes are missing so a corrupt file reports an error.
+var compressions = []struct {
+	name  string
+	ext   []string
+	magic []byte
+}{
+	{"gzip", []string{".gz", ".tgz"}, []byte{0x1f, 0x8b}},
+	{"zstd", []string{".zst", ".zstd", ".tzst"}, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{"bzip2", []string{".bz2", ".tbz2"}, []byte("BZh")},
+}
+
+// eachMember calls fn with every log stream of the input at path: the
+// file itself, decompressed if needed, or each regular file of a tar
+// archive, itself decompressed if needed. name is the stream's "file"
+// metadata: the file's base name, or "archive.tar.gz:dir/member.log" for
+// an archive member. Stops when fn returns false.
+func eachMember(r io.Reader, path string, fn func(name string, r io.Reader) bool) error {
+	base := filepath.Base(path)
+	dr, closer, err := decompress(r, path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", base, err)
+	}
+	defer closer()
+
+	br := bufio.NewReader(dr)
+	if !isTar(br) {
+		fn(base, br)
+		return nil
+	}
+	tr := tar.NewReader(br)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", base, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		mr, closeMember, err := decompress(tr, hdr.Name)
+		if err != nil {
+			return fmt.Errorf("%s:%s: %w", base, hdr.Name, err)
+		}
+		more := fn(base+":"+strings.TrimPrefix(hdr.Name, "./"), mr)
+		closeMember()
+		if !more {
+			return nil
+		}
+	}
+}
+
+// decompress wraps r in a decompressor when it starts with a known magic
+// number or path has a compressed extension. The returned func releases
+// the decompressor.
+func decompress(r io.Reader, path string) (io.Reader, func(), error) {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(4)
+	kind := ""
+	for _, c := range compressions {
+		if bytes.HasPrefix(head, c.magic) {
+			kind = c.name
+			break
+		}
+	}
+	if kind == "" {
+		ext := strings.ToLower(filepath.Ext(path))
+		for _, c := range compressions {
+			for _, e := range c.ext {
+				if ext == e {
+					kind = c.name
+				}
+			}
+		}
+	}
+
+	switch kind {
+	case "gzip":
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() { zr.Close() }, nil
+	case "zstd":
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	case "bzip2":
+		return bzip2.NewReader(br), func() {}, nil
+	default:
+		return br, func() {}, nil
+	}
+}
+
+// isCompressed reports whether the file starting with head, at path, is
+// compressed.
+func isCompressed(head []byte, path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, c := range compressions {
+		if bytes.HasPrefix(head, c.magic) {
+			return true
+		}
+		for _, e := range c.ext {
+			if ext == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isTar reports whether br starts with a tar header: the "ustar" magic at
+// offset 257 of the first block.
+func isTar(br *bufio.Reader) bool {
+	head, _ := br.Peek(512)
+	return len(head) == 512 && bytes.HasPrefix(head[257:], []byte("ustar"))
+}
@@ -0,0 +1,203 @@
+package file
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// bz2Line is "2026-02-19T12:00:01Z ERROR from bz2\n" compressed with bzip2,
+// which the standard library cannot write.
+const bz2Line = "\x42\x5a\x68\x39\x31\x41\x59\x26\x53\x59\x3d\x04\x33\x2f\x00\x00\x08\x5f\x80\x00\x10\x40\x02\x71\x30\x02\x00\x94\x10\x11\x02\x90\x10\x20\x00\x31\x4c\x26\x9a\x03\x4c\x42\x9e\x90\x03\x26\x87\xa9\x46\xe3\x62\x7a\x85\xd3\x1b\x92\x11\x90\xb5\x01\x17\x2e\x1d\xed\x48\x78\x93\xf1\x77\x24\x53\x85\x09\x03\xd0\x43\x32\xf0"
+
+func gzipBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(s))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, s string) []byte {
+	t.Helper()
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zw.Close()
+	return zw.EncodeAll([]byte(s), nil)
+}
+
+// tarBytes archives files, given as name/content pairs.
+func tarBytes(t *testing.T, files ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "logs/", Typeflag: tar.TypeDir, Mode: 0o755})
+	for i := 0; i < len(files); i += 2 {
+		tw.WriteHeader(&tar.Header{Name: files[i], Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(files[i+1]))})
+		tw.Write([]byte(files[i+1]))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestStream_Decompresses(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.log.gz", gzipBytes(t, "2026-02-19T12:00:01Z ERROR from gzip\n"))
+	writeFile(t, dir, "b.log.zst", zstdBytes(t, "2026-02-19T12:00:01Z ERROR from zstd\n"))
+	writeFile(t, dir, "c.log.bz2", []byte(bz2Line))
+	// Detected by magic bytes despite the extension.
+	writeFile(t, dir, "d.log", gzipBytes(t, "2026-02-19T12:00:01Z ERROR gzip without extension\n"))
+
+	ch, err := (&Connector{}).Stream(context.Background(), cfgWithFile(filepath.Join(dir, "*")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for raw := range ch {
+		got = append(got, raw.Metadata["file"].(string)+": "+raw.Raw)
+	}
+	want := []string{
+		"a.log.gz: 2026-02-19T12:00:01Z ERROR from gzip",
+		"b.log.zst: 2026-02-19T12:00:01Z ERROR from zstd",
+		"c.log.bz2: 2026-02-19T12:00:01Z ERROR from bz2",
+		"d.log: 2026-02-19T12:00:01Z ERROR gzip without extension",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestStream_TarMembers(t *testing.T) {
+	dir := t.TempDir()
+	inner := string(gzipBytes(t, "rotated line\n"))
+	path := writeFile(t, dir, "logs.tar.gz", gzipBytes(t, string(tarBytes(t,
+		"logs/app.log", "current line\n",
+		"logs/app.log.1.gz", inner,
+	))))
+
+	ch, err := (&Connector{}).Stream(context.Background(), cfgWithFile(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for raw := range ch {
+		got = append(got, raw.Metadata["file"].(string)+": "+raw.Raw)
+	}
+	want := "logs.tar.gz:logs/app.log: current line|logs.tar.gz:logs/app.log.1.gz: rotated line"
+	if strings.Join(got, "|") != want {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestQuery_ArchiveMergedWithLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "logs.tar", tarBytes(t,
+		"api.log", "2026-02-19T12:00:01Z api one\n2026-02-19T12:00:03Z api two\n2026-02-19T12:00:05Z api three\n",
+		"worker.log", "2026-02-19T12:00:02Z worker one\n2026-02-19T12:00:04Z worker two\n",
+	))
+
+	results, err := (&Connector{}).Query(context.Background(), cfgWithFile(path), connector.QueryParams{Limit: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, raw := range results {
+		got = append(got, strings.SplitN(raw.Raw, " ", 2)[1])
+	}
+	if strings.Join(got, "|") != "api one|worker one|api two|worker two" {
+		t.Errorf("got %q", got)
+	}
+	if results[1].Metadata["file"] != "logs.tar:worker.log" || results[1].Source != "file" {
+		t.Errorf("member metadata = %v, source %q", results[1].Metadata, results[1].Source)
+	}
+}
+
+func TestQuery_LimitAcrossMembers(t *testing.T) {
+	lp, err := newLineParser(cfgWithFile("unused"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := &query{lp: lp, limit: 2}
+	for _, member := range []string{
+		"2026-02-19T12:00:01Z a one\n2026-02-19T12:00:04Z a two\n2026-02-19T12:00:06Z a three\n",
+		"2026-02-19T12:00:02Z b one\n2026-02-19T12:00:03Z b two\n2026-02-19T12:00:07Z b three\n",
+	} {
+		events, err := q.member(context.Background(), strings.NewReader(member), "m")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) > q.limit {
+			t.Fatalf("member kept %d events, want at most %d", len(events), q.limit)
+		}
+		q.results = mergeByTime([][]model.RawLog{q.results, events}, q.limit)
+	}
+
+	var got []string
+	for _, raw := range q.results {
+		got = append(got, strings.SplitN(raw.Raw, " ", 2)[1])
+	}
+	if strings.Join(got, "|") != "a one|b one" {
+		t.Errorf("got %q", got)
+	}
+	// With the results full, a member is read only until its events sort
+	// after them: c late is never read.
+	events, err := q.member(context.Background(), strings.NewReader("2026-02-19T12:00:01Z c one\n2026-02-19T12:00:09Z c two\n2026-02-19T12:00:00Z c late\n"), "c")
+	if err != nil || len(events) != 1 {
+		t.Errorf("member after the results filled = %d events, %v; want only the event sorting before them", len(events), err)
+	}
+}
+
+func TestQuery_CorruptArchive(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "broken.log.gz", []byte("not gzip at all"))
+	if _, err := (&Connector{}).Query(context.Background(), cfgWithFile(path), connector.QueryParams{}); err == nil {
+		t.Error("expected error for a corrupt .gz file")
+	}
+}
+
+func TestFollow_SkipsCompressedFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "app.log.1.gz", gzipBytes(t, "old\n"))
+	appendFile(t, filepath.Join(dir, "app.log"), "live\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch, err := (&Connector{}).Stream(ctx, followCfg(filepath.Join(dir, "app.log*")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := next(t, ch, 1); got[0] != "live" {
+		t.Errorf("got %q, want only the plain file's line", got)
+	}
+	select {
+	case raw := <-ch:
+		t.Errorf("unexpected line %q", raw.Raw)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,300 @@
+// Package format parses structured log lines read by the stdin and file
+// connectors (JSON lines, logfmt, nginx/Apache access logs, syslog and
+// Kubernetes CRI) into a message and its fields, so embeddings see the
+// message rather than the envelope around it.
+package format
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Input formats.
+const (
+	Auto   = "auto"   // detect per line, falling back to Raw
+	Raw    = "raw"    // the whole line is the message
+	JSON   = "json"   // JSON lines
+	Logfmt = "logfmt" // key=value pairs
+	CLF    = "clf"    // Common and Combined Log Format (nginx, Apache)
+	Syslog = "syslog" // RFC 3164 and RFC 5424
+	CRI    = "cri"    // Kubernetes container runtime logs
+)
+
+// Formats returns the names accepted by New.
+func Formats() []string {
+	return []string{Auto, Raw, JSON, Logfmt, CLF, Syslog, CRI}
+}
+
+// messageKeys are the fields holding the message of JSON and logfmt lines
+// when no message field is configured, in priority order.
+var messageKeys = []string{"message", "msg", "@message", "log"}
+
+// syslogSeverities are the RFC 5424 severity names, by code.
+var syslogSeverities = []string{"emerg", "alert", "crit", "err", "warning", "notice", "info", "debug"}
+
+var (
+	// 2026-02-19T12:00:01.123456789Z stdout F message
+	criRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+) (stdout|stderr) ([FP]) ?(.*)$`)
+	// 10.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /a HTTP/1.1" 200 2326 "referer" "agent"
+	clfRe = regexp.MustCompile(`^(\S+) (\S+) (\S+) \[([^\]]+)\] "((?:[^"\\]|\\.)*)" (\d{3}) (\d+|-)(?: "((?:[^"\\]|\\.)*)" "((?:[^"\\]|\\.)*)")?`)
+	// <34>1 2003-10-11T22:14:15.003Z host app 1234 ID47 [sd] message
+	rfc5424Re = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|(?:\[(?:[^\]\\]|\\.)*\])+) ?(.*)$`)
+	// <34>Oct 11 22:14:15 host app[1234]: message
+	rfc3164Re = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^\s:\[]+)(?:\[(\d+)\])?: ?(.*)$`)
+)
+
+// Parser splits log lines into a message and fields. Safe for concurrent
+// use.
+type Parser struct {
+	format       string
+	messageField string
+}
+
+// New creates a Parser for format (see Formats; empty means Auto).
+// messageField is the dotted path of the message in JSON lines, e.g.
+// "log.message"; empty tries message, msg, @message and log.
+func New(format, messageField string) (*Parser, error) {
+	if format == "" {
+		format = Auto
+	}
+	for _, f := range Formats() {
+		if f == format {
+			return &Parser{format: format, messageField: messageField}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid input format %q (must be %s)", format, strings.Join(Formats(), "|"))
+}
+
+// Parse returns the message of line and its remaining fields. A line that
+// does not match the format (in Auto mode, any format) is its own message
+// with no fields.
+func (p *Parser) Parse(line string) (message string, fields map[string]any) {
+	var ok bool
+	switch p.format {
+	case Raw:
+	case JSON:
+		message, fields, ok = p.parseJSON(line)
+	case Logfmt:
+		message, fields, ok = parseLogfmt(line)
+	case CLF:
+		message, fields, ok = parseCLF(line)
+	case Syslog:
+		message, fields, ok = parseSyslog(line)
+	case CRI:
+		message, fields, ok = parseCRI(line)
+	default:
+		message, fields, ok = p.detect(line)
+	}
+	if !ok {
+		return line, nil
+	}
+	return message, fields
+}
+
+// detect tries each format in turn. The message of a CRI line is parsed
+// again, since containers usually log JSON or logfmt themselves.
+func (p *Parser) detect(line string) (string, map[string]any, bool) {
+	if msg, fields, ok := parseCRI(line); ok {
+		if inner, innerFields, ok := p.detect(msg); ok {
+			for k, v := range innerFields {
+				if _, taken := fields[k]; !taken {
+					fields[k] = v
+				}
+			}
+			msg = inner
+		}
+		return msg, fields, true
+	}
+	if strings.HasPrefix(line, "{") {
+		return p.parseJSON(line)
+	}
+	if msg, fields, ok := parseSyslog(line); ok {
+		return msg, fields, true
+	}
+	if msg, fields, ok := parseCLF(line); ok {
+		return msg, fields, true
+	}
+	return parseLogfmt(line)
+}
+
+func (p *Parser) parseJSON(line string) (string, map[string]any, bool) {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return "", nil, false
+	}
+	keys := messageKeys
+	if p.messageField != "" {
+		keys = []string{p.messageField}
+	}
+	for _, key := range keys {
+		if msg, ok := take(obj, key); ok {
+			return msg, obj, true
+		}
+	}
+	return "", nil, false
+}
+
+// take removes and returns the string at a dotted path in obj, trying the
+// path as a literal key first.
+func take(obj map[string]any, path string) (string, bool) {
+	if s, ok := obj[path].(string); ok {
+		delete(obj, path)
+		return s, true
+	}
+	head, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		return "", false
+	}
+	child, ok := obj[head].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	s, ok := take(child, rest)
+	if ok && len(child) == 0 {
+		delete(obj, head)
+	}
+	return s, ok
+}
+
+// parseLogfmt accepts lines made only of key=value pairs, at least two.
+// Values may be double-quoted.
+func parseLogfmt(line string) (string, map[string]any, bool) {
+	fields := make(map[string]any)
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(line) && isKeyChar(line[i]) {
+			i++
+		}
+		if i == start || i >= len(line) || line[i] != '=' {
+			return "", nil, false
+		}
+		key := line[start:i]
+		i++
+		var value string
+		if i < len(line) && line[i] == '"' {
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				return "", nil, false
+			}
+			v, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				return "", nil, false
+			}
+			value, i = v, end+1
+		} else {
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			value = line[start:i]
+		}
+		fields[key] = value
+	}
+	if len(fields) < 2 {
+		return "", nil, false
+	}
+	for _, key := range messageKeys {
+		if msg, ok := fields[key].(string); ok {
+			delete(fields, key)
+			return msg, fields, true
+		}
+	}
+	// Without a message key the pairs are the message, as in router logs
+	// (at=info method=GET path=/ status=200).
+	return line, fields, true
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || c == '@' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// parseCLF parses Common and Combined Log Format lines. The message is the
+// request line and status, e.g. "GET /api/users HTTP/1.1 502".
+func parseCLF(line string) (string, map[string]any, bool) {
+	m := clfRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", nil, false
+	}
+	fields := map[string]any{"client_ip": m[1], "time": m[4], "request": m[5]}
+	if m[3] != "-" {
+		fields["user"] = m[3]
+	}
+	status, _ := strconv.Atoi(m[6])
+	fields["status"] = status
+	if bytes, err := strconv.Atoi(m[7]); err == nil {
+		fields["bytes"] = bytes
+	}
+	if m[8] != "" && m[8] != "-" {
+		fields["referer"] = m[8]
+	}
+	if m[9] != "" && m[9] != "-" {
+		fields["user_agent"] = m[9]
+	}
+	if parts := strings.Fields(m[5]); len(parts) >= 2 {
+		fields["method"], fields["path"] = parts[0], parts[1]
+	}
+	return m[5] + " " + m[6], fields, true
+}
+
+// parseSyslog parses RFC 5424 and RFC 3164 lines.
+func parseSyslog(line string) (string, map[string]any, bool) {
+	if m := rfc5424Re.FindStringSubmatch(line); m != nil {
+		fields := priority(m[1])
+		fields["time"] = m[2]
+		for i, key := range []string{"host", "app", "pid", "msgid", "structured_data"} {
+			if v := m[3+i]; v != "-" {
+				fields[key] = v
+			}
+		}
+		return strings.TrimPrefix(m[8], "\ufeff"), fields, true
+	}
+	if m := rfc3164Re.FindStringSubmatch(line); m != nil {
+		fields := map[string]any{}
+		if m[1] != "" {
+			fields = priority(m[1])
+		}
+		fields["time"], fields["host"], fields["app"] = m[2], m[3], m[4]
+		if m[5] != "" {
+			fields["pid"] = m[5]
+		}
+		return m[6], fields, true
+	}
+	return "", nil, false
+}
+
+// priority decodes a syslog PRI into facility and severity name.
+func priority(pri string) map[string]any {
+	n, err := strconv.Atoi(pri)
+	if err != nil || n > 191 {
+		return map[string]any{}
+	}
+	return map[string]any{"facility": n / 8, "severity": syslogSeverities[n%8]}
+}
+
+// parseCRI parses a container runtime (CRI) log line. Partial lines, split
+// by the runtime at 16KB, are flagged "partial".
+func parseCRI(line string) (string, map[string]any, bool) {
+	m := criRe.FindStringSubmatch(line)
+	if m == nil {
+		return "", nil, false
+	}
+	fields := map[string]any{"time": m[1], "stream": m[2]}
+	if m[3] == "P" {
+		fields["partial"] = true
+	}
+	return m[4], fields, true
+}
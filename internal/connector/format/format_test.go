@@ -0,0 +1,152 @@
+package format
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseAuto(t *testing.T) {
+	p, err := New("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		line   string
+		msg    string
+		fields map[string]any
+	}{
+		{
+			name:   "raw",
+			line:   "ERROR: connection refused to host=db-primary",
+			msg:    "ERROR: connection refused to host=db-primary",
+			fields: nil,
+		},
+		{
+			name:   "json",
+			line:   `{"level":"error","msg":"db timeout","ctx":{"pool":"main"}}`,
+			msg:    "db timeout",
+			fields: map[string]any{"level": "error", "ctx": map[string]any{"pool": "main"}},
+		},
+		{
+			name:   "json without message",
+			line:   `{"level":"error","code":42}`,
+			msg:    `{"level":"error","code":42}`,
+			fields: nil,
+		},
+		{
+			name:   "logfmt",
+			line:   `ts=2026-02-19T12:00:01Z level=warn msg="pool low, 2 left" pool=main`,
+			msg:    "pool low, 2 left",
+			fields: map[string]any{"ts": "2026-02-19T12:00:01Z", "level": "warn", "pool": "main"},
+		},
+		{
+			name:   "logfmt without message",
+			line:   "at=info method=GET path=/health status=200",
+			msg:    "at=info method=GET path=/health status=200",
+			fields: map[string]any{"at": "info", "method": "GET", "path": "/health", "status": "200"},
+		},
+		{
+			name: "combined",
+			line: `10.0.0.1 - frank [19/Feb/2026:12:00:01 +0000] "GET /api/users?id=7 HTTP/1.1" 502 157 "-" "curl/8.5.0"`,
+			msg:  "GET /api/users?id=7 HTTP/1.1 502",
+			fields: map[string]any{"client_ip": "10.0.0.1", "user": "frank", "time": "19/Feb/2026:12:00:01 +0000",
+				"request": "GET /api/users?id=7 HTTP/1.1", "status": 502, "bytes": 157, "user_agent": "curl/8.5.0",
+				"method": "GET", "path": "/api/users?id=7"},
+		},
+		{
+			name:   "common",
+			line:   `127.0.0.1 - - [19/Feb/2026:12:00:01 +0000] "POST /login HTTP/1.0" 401 -`,
+			msg:    "POST /login HTTP/1.0 401",
+			fields: map[string]any{"client_ip": "127.0.0.1", "time": "19/Feb/2026:12:00:01 +0000", "request": "POST /login HTTP/1.0", "status": 401, "method": "POST", "path": "/login"},
+		},
+		{
+			name:   "rfc5424",
+			line:   `<11>1 2026-02-19T12:00:01.003Z web1 api 4242 ID47 [origin ip="10.0.0.1"] upstream timed out`,
+			msg:    "upstream timed out",
+			fields: map[string]any{"facility": 1, "severity": "err", "time": "2026-02-19T12:00:01.003Z", "host": "web1", "app": "api", "pid": "4242", "msgid": "ID47", "structured_data": `[origin ip="10.0.0.1"]`},
+		},
+		{
+			name:   "rfc3164",
+			line:   "<38>Feb 19 12:00:01 web1 sshd[42]: Accepted publickey for deploy",
+			msg:    "Accepted publickey for deploy",
+			fields: map[string]any{"facility": 4, "severity": "info", "time": "Feb 19 12:00:01", "host": "web1", "app": "sshd", "pid": "42"},
+		},
+		{
+			name:   "rfc3164 without priority",
+			line:   "Feb  9 12:00:01 web1 kernel: Out of memory: Killed process 912",
+			msg:    "Out of memory: Killed process 912",
+			fields: map[string]any{"time": "Feb  9 12:00:01", "host": "web1", "app": "kernel"},
+		},
+		{
+			name:   "cri",
+			line:   "2026-02-19T12:00:01.123456789Z stderr F panic: boom",
+			msg:    "panic: boom",
+			fields: map[string]any{"time": "2026-02-19T12:00:01.123456789Z", "stream": "stderr"},
+		},
+		{
+			name:   "cri wrapping json",
+			line:   `2026-02-19T12:00:01Z stdout P {"level":"info","msg":"listening","time":"inner"}`,
+			msg:    "listening",
+			fields: map[string]any{"time": "2026-02-19T12:00:01Z", "stream": "stdout", "partial": true, "level": "info"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, fields := p.Parse(tt.line)
+			if msg != tt.msg {
+				t.Errorf("message = %q, want %q", msg, tt.msg)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Errorf("fields = %#v, want %#v", fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestParseForced(t *testing.T) {
+	line := `{"msg":"db timeout"}`
+	raw, _ := New(Raw, "")
+	if msg, fields := raw.Parse(line); msg != line || fields != nil {
+		t.Errorf("raw format changed the line: %q %v", msg, fields)
+	}
+	// A forced format leaves lines of other formats whole.
+	logfmt, _ := New(Logfmt, "")
+	if msg, fields := logfmt.Parse(line); msg != line || fields != nil {
+		t.Errorf("logfmt parsed a JSON line: %q %v", msg, fields)
+	}
+	if msg, _ := logfmt.Parse("level=error msg=boom"); msg != "boom" {
+		t.Errorf("logfmt message = %q", msg)
+	}
+}
+
+func TestParseMessageField(t *testing.T) {
+	p, _ := New(JSON, "log.message")
+	msg, fields := p.Parse(`{"log":{"message":"disk full","logger":"fs"},"level":"error"}`)
+	if msg != "disk full" {
+		t.Errorf("message = %q", msg)
+	}
+	want := map[string]any{"log": map[string]any{"logger": "fs"}, "level": "error"}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("fields = %#v, want %#v", fields, want)
+	}
+
+	// A literal dotted key wins over the nested path.
+	msg, fields = p.Parse(`{"log.message":"flat","log":{"message":"nested"}}`)
+	if msg != "flat" || fields["log"] == nil {
+		t.Errorf("literal key: %q %v", msg, fields)
+	}
+
+	// The default message keys no longer apply.
+	if msg, _ := p.Parse(`{"msg":"db timeout"}`); msg != `{"msg":"db timeout"}` {
+		t.Errorf("expected the line unchanged without the configured field, got %q", msg)
+	}
+}
+
+func TestNewInvalidFormat(t *testing.T) {
+	_, err := New("xml", "")
+	if err == nil || !strings.Contains(err.Error(), `invalid input format "xml"`) {
+		t.Fatalf("expected invalid format error, got %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+// Package multiline joins the lines of one log event, such as a stack
+// trace, a Python traceback or a goroutine dump, into a single RawLog for
+// the line-based connectors.
+package multiline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Defaults for the caps and the flush timeout.
+const (
+	DefaultMaxLines = 500
+	DefaultMaxBytes = 512 * 1024
+	DefaultTimeout  = time.Second
+)
+
+// Config selects how lines are joined.
+type Config struct {
+	// Disabled passes every line through as its own event.
+	Disabled bool
+	// Start is a regex for lines that always begin a new event. When set,
+	// every other line continues the event before it.
+	Start string
+	// Continue is a regex for lines that continue the event before them,
+	// in addition to the built-in rules.
+	Continue string
+	// MaxLines and MaxBytes cap an event; the line that would exceed a
+	// cap begins a new one. Default: DefaultMaxLines, DefaultMaxBytes.
+	MaxLines int
+	MaxBytes int
+	// Timeout is how long Stream waits for another line before emitting
+	// the pending event. Default: DefaultTimeout.
+	Timeout time.Duration
+}
+
+// FromExtra reads a Config from the connector Extra keys "multiline"
+// (false disables), "multiline_start", "multiline_continue",
+// "multiline_max_lines", "multiline_max_bytes" and "multiline_timeout".
+func FromExtra(extra map[string]string) (Config, error) {
+	cfg := Config{Start: extra["multiline_start"], Continue: extra["multiline_continue"]}
+	var errs []string
+	if v := extra["multiline"]; v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("invalid multiline %q (must be true or false)", v))
+		}
+		cfg.Disabled = err == nil && !enabled
+	}
+	for _, n := range []struct {
+		key string
+		dst *int
+	}{
+		{"multiline_max_lines", &cfg.MaxLines},
+		{"multiline_max_bytes", &cfg.MaxBytes},
+	} {
+		if v := extra[n.key]; v != "" {
+			i, err := strconv.Atoi(v)
+			if err != nil || i <= 0 {
+				errs = append(errs, fmt.Sprintf("invalid %s %q (must be a positive integer)", n.key, v))
+			}
+			*n.dst = i
+		}
+	}
+	if v := extra["multiline_timeout"]; v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Sprintf("invalid multiline_timeout %q (must be a positive duration)", v))
+		}
+		cfg.Timeout = d
+	}
+	if len(errs) > 0 {
+		return Config{}, errors.New(strings.Join(errs, "; "))
+	}
+	return cfg, nil
+}
+
+// Built-in continuation rules: indented lines, Java frames and chained
+// causes, and the headers of Python tracebacks, Rust backtraces and Go
+// goroutine dumps.
+var (
+	continuePrefixes = []string{
+		"at ",
+		"Caused by:",
+		"Suppressed:",
+		"Traceback (most recent call last):",
+		"During handling of the above exception",
+		"The above exception was the direct cause",
+		"stack backtrace:",
+		"created by ",
+	}
+	goroutineRe = regexp.MustCompile(`^goroutine \d+ \[`)
+	// pyExceptionRe is the line closing a Python traceback,
+	// "json.decoder.JSONDecodeError: Expecting value".
+	pyExceptionRe = regexp.MustCompile(`^[A-Za-z_][\w.]*(?:Error|Exception|Warning|Exit|Interrupt|Iteration)(?::|$)`)
+	// goFuncRe is a function line of a goroutine dump,
+	// "main.(*Server).handle(0x0, {0x0, 0x0})".
+	goFuncRe = regexp.MustCompile(`^[\w./*()\-]+\(.*\)$`)
+)
+
+// Assembler joins continuation lines into the event before them. Not safe
+// for concurrent use; create one per input.
+type Assembler struct {
+	disabled    bool
+	start, cont *regexp.Regexp
+	maxLines    int
+	maxBytes    int
+	timeout     time.Duration
+
+	pending   model.RawLog
+	lines     int
+	traceback bool // pending holds a Python traceback
+	goroutine bool // pending holds a goroutine dump
+	indented  bool // the last line joined was indented
+}
+
+// New compiles cfg's patterns, reporting both when invalid.
+func New(cfg Config) (*Assembler, error) {
+	a := &Assembler{
+		disabled: cfg.Disabled,
+		maxLines: cfg.MaxLines,
+		maxBytes: cfg.MaxBytes,
+		timeout:  cfg.Timeout,
+	}
+	if a.maxLines <= 0 {
+		a.maxLines = DefaultMaxLines
+	}
+	if a.maxBytes <= 0 {
+		a.maxBytes = DefaultMaxBytes
+	}
+	if a.timeout <= 0 {
+		a.timeout = DefaultTimeout
+	}
+	var errs []string
+	var err error
+	if cfg.Start != "" {
+		if a.start, err = regexp.Compile(cfg.Start); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid multiline start pattern %q: %v", cfg.Start, err))
+		}
+	}
+	if cfg.Continue != "" {
+		if a.cont, err = regexp.Compile(cfg.Continue); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid multiline continue pattern %q: %v", cfg.Continue, err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return a, nil
+}
+
+// Clone returns an Assembler with a's settings and no pending event, for
+// another input.
+func (a *Assembler) Clone() *Assembler {
+	return &Assembler{
+		disabled: a.disabled,
+		start:    a.start,
+		cont:     a.cont,
+		maxLines: a.maxLines,
+		maxBytes: a.maxBytes,
+		timeout:  a.timeout,
+	}
+}
+
+// Add takes the next line and returns the previous event when raw begins
+// a new one. A continuation line is appended to the pending event's Raw;
+// its own timestamp and metadata are dropped.
+func (a *Assembler) Add(raw model.RawLog) (model.RawLog, bool) {
+	if a.disabled {
+		return raw, true
+	}
+	if a.lines == 0 {
+		a.begin(raw)
+		return model.RawLog{}, false
+	}
+	if a.continues(raw.Raw) && a.lines < a.maxLines && len(a.pending.Raw)+1+len(raw.Raw) <= a.maxBytes {
+		a.pending.Raw += "\n" + raw.Raw
+		a.lines++
+		a.observe(raw.Raw)
+		return model.RawLog{}, false
+	}
+	event := a.pending
+	a.begin(raw)
+	return event, true
+}
+
+// Flush returns the pending event, if any.
+func (a *Assembler) Flush() (model.RawLog, bool) {
+	if a.lines == 0 {
+		return model.RawLog{}, false
+	}
+	event := a.pending
+	a.pending, a.lines = model.RawLog{}, 0
+	return event, true
+}
+
+// Pending reports whether Add holds lines not yet returned as an event.
+func (a *Assembler) Pending() bool {
+	return a.lines > 0
+}
+
+// Timeout is how long a pending event may wait for another line before it
+// should be flushed.
+func (a *Assembler) Timeout() time.Duration {
+	return a.timeout
+}
+
+// Stream assembles the lines read from in and sends the events on the
+// returned channel, which closes when in closes or ctx is cancelled. The
+// pending event is emitted once no line arrives within the timeout.
+func (a *Assembler) Stream(ctx context.Context, in <-chan model.RawLog) <-chan model.RawLog {
+	if a.disabled {
+		return in
+	}
+	out := make(chan model.RawLog, cap(in))
+	go func() {
+		defer close(out)
+		timer := time.NewTimer(a.timeout)
+		timer.Stop()
+		defer timer.Stop()
+
+		send := func(raw model.RawLog) bool {
+			select {
+			case out <- raw:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		for {
+			select {
+			case raw, ok := <-in:
+				if !ok {
+					if event, ok := a.Flush(); ok {
+						send(event)
+					}
+					return
+				}
+				if event, ok := a.Add(raw); ok && !send(event) {
+					return
+				}
+				timer.Reset(a.timeout)
+			case <-timer.C:
+				if event, ok := a.Flush(); ok && !send(event) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (a *Assembler) begin(raw model.RawLog) {
+	a.pending, a.lines = raw, 1
+	a.traceback, a.goroutine = false, false
+	a.observe(raw.Raw)
+}
+
+// observe tracks the trace kinds seen in the pending event, which extend
+// the built-in rules to lines that are not indented.
+func (a *Assembler) observe(line string) {
+	a.indented = isIndented(line)
+	switch {
+	case strings.HasPrefix(line, "Traceback (most recent call last):"):
+		a.traceback = true
+	case goroutineRe.MatchString(line):
+		a.goroutine = true
+	}
+}
+
+// continues reports whether line belongs to the pending event.
+func (a *Assembler) continues(line string) bool {
+	if a.start != nil && a.start.MatchString(line) {
+		return false
+	}
+	if a.cont != nil && a.cont.MatchString(line) {
+		return true
+	}
+	if builtin(line) {
+		return true
+	}
+	// The exception line after a traceback's last frame, and the function
+	// lines of a goroutine dump.
+	if a.traceback && a.indented && pyExceptionRe.MatchString(line) {
+		return true
+	}
+	if a.goroutine && goFuncRe.MatchString(line) {
+		return true
+	}
+	return a.start != nil
+}
+
+func builtin(line string) bool {
+	if isIndented(line) || goroutineRe.MatchString(line) {
+		return true
+	}
+	for _, p := range continuePrefixes {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
@@ -0,0 +1,201 @@
+package multiline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// assemble runs lines through a and returns the events' Raw text.
+func assemble(t *testing.T, a *Assembler, lines ...string) []string {
+	t.Helper()
+	var out []string
+	for _, line := range lines {
+		if event, ok := a.Add(model.RawLog{Raw: line}); ok {
+			out = append(out, event.Raw)
+		}
+	}
+	if event, ok := a.Flush(); ok {
+		out = append(out, event.Raw)
+	}
+	return out
+}
+
+func TestBuiltinRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{
+			name: "java",
+			lines: []string{
+				"2026-02-19 12:00:01 ERROR request failed",
+				"java.lang.IllegalStateException: pool exhausted",
+				"\tat com.acme.db.Pool.acquire(Pool.java:42)",
+				"Caused by: java.net.SocketTimeoutException: connect timed out",
+				"\t... 3 more",
+				"2026-02-19 12:00:02 INFO retrying",
+			},
+			want: []string{
+				"2026-02-19 12:00:01 ERROR request failed",
+				"java.lang.IllegalStateException: pool exhausted\n\tat com.acme.db.Pool.acquire(Pool.java:42)\nCaused by: java.net.SocketTimeoutException: connect timed out\n\t... 3 more",
+				"2026-02-19 12:00:02 INFO retrying",
+			},
+		},
+		{
+			name: "python",
+			lines: []string{
+				"ERROR worker crashed",
+				"Traceback (most recent call last):",
+				`  File "/app/worker.py", line 12, in <module>`,
+				"    main()",
+				"json.decoder.JSONDecodeError: Expecting value: line 1 column 1 (char 0)",
+				"INFO restarting worker",
+			},
+			want: []string{
+				"ERROR worker crashed\nTraceback (most recent call last):\n  File \"/app/worker.py\", line 12, in <module>\n    main()\njson.decoder.JSONDecodeError: Expecting value: line 1 column 1 (char 0)",
+				"INFO restarting worker",
+			},
+		},
+		{
+			name: "go",
+			lines: []string{
+				"panic: runtime error: invalid memory address or nil pointer dereference",
+				"goroutine 1 [running]:",
+				"main.(*Server).handle(0x0, {0x0, 0x0})",
+				"\t/app/server.go:27 +0x1d",
+				"main.main()",
+				"\t/app/main.go:9 +0x25",
+				"exit status 2",
+			},
+			want: []string{
+				"panic: runtime error: invalid memory address or nil pointer dereference\ngoroutine 1 [running]:\nmain.(*Server).handle(0x0, {0x0, 0x0})\n\t/app/server.go:27 +0x1d\nmain.main()\n\t/app/main.go:9 +0x25",
+				"exit status 2",
+			},
+		},
+		{
+			name:  "single lines",
+			lines: []string{"user logged in", "ValueError: not after a traceback", "main.main()"},
+			want:  []string{"user logged in", "ValueError: not after a traceback", "main.main()"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := New(Config{})
+			got := assemble(t, a, tt.lines...)
+			if strings.Join(got, "\n---\n") != strings.Join(tt.want, "\n---\n") {
+				t.Errorf("got %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCustomPatterns(t *testing.T) {
+	// Start: every line not starting with a date continues the event.
+	a, err := New(Config{Start: `^\d{4}-\d{2}-\d{2}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := assemble(t, a, "2026-02-19 query failed:", "SELECT *", "FROM orders", "2026-02-19 done")
+	if len(got) != 2 || got[0] != "2026-02-19 query failed:\nSELECT *\nFROM orders" {
+		t.Errorf("start pattern: %q", got)
+	}
+
+	// Continue: adds to the built-in rules.
+	a, _ = New(Config{Continue: `^\| `})
+	got = assemble(t, a, "report:", "| row 1", "| row 2", "next")
+	if len(got) != 2 || got[0] != "report:\n| row 1\n| row 2" {
+		t.Errorf("continue pattern: %q", got)
+	}
+}
+
+func TestCaps(t *testing.T) {
+	a, _ := New(Config{MaxLines: 3})
+	got := assemble(t, a, "boom", "\tat a", "\tat b", "\tat c", "\tat d")
+	if len(got) != 2 || got[0] != "boom\n\tat a\n\tat b" || got[1] != "\tat c\n\tat d" {
+		t.Errorf("max lines: %q", got)
+	}
+
+	a, _ = New(Config{MaxBytes: 12})
+	got = assemble(t, a, "boom", "\tat a", "\tat b")
+	if len(got) != 2 || got[0] != "boom\n\tat a" {
+		t.Errorf("max bytes: %q", got)
+	}
+}
+
+func TestKeepsFirstLine(t *testing.T) {
+	a, _ := New(Config{})
+	first := model.RawLog{Raw: "boom", TimeSource: model.TimeParsed, Metadata: map[string]any{"file": "app.log"}}
+	a.Add(first)
+	a.Add(model.RawLog{Raw: "\tat a", TimeSource: model.TimeInferred})
+	event, _ := a.Flush()
+	if event.TimeSource != model.TimeParsed || event.Metadata["file"] != "app.log" || event.Raw != "boom\n\tat a" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	a, _ := New(Config{Disabled: true})
+	got := assemble(t, a, "boom", "\tat a")
+	if len(got) != 2 {
+		t.Errorf("disabled: %q", got)
+	}
+}
+
+func TestStreamFlushesAfterTimeout(t *testing.T) {
+	a, _ := New(Config{Timeout: 20 * time.Millisecond})
+	in := make(chan model.RawLog)
+	out := a.Stream(context.Background(), in)
+
+	in <- model.RawLog{Raw: "boom"}
+	in <- model.RawLog{Raw: "\tat a"}
+	select {
+	case event := <-out:
+		if event.Raw != "boom\n\tat a" {
+			t.Errorf("event = %q", event.Raw)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("pending event not flushed")
+	}
+
+	in <- model.RawLog{Raw: "last"}
+	close(in)
+	if event := <-out; event.Raw != "last" {
+		t.Errorf("event = %q", event.Raw)
+	}
+	if _, ok := <-out; ok {
+		t.Error("expected closed channel")
+	}
+}
+
+func TestFromExtra(t *testing.T) {
+	cfg, err := FromExtra(map[string]string{
+		"multiline": "false", "multiline_start": "^x", "multiline_max_lines": "10",
+		"multiline_max_bytes": "2048", "multiline_timeout": "250ms",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{Disabled: true, Start: "^x", MaxLines: 10, MaxBytes: 2048, Timeout: 250 * time.Millisecond}
+	if cfg != want {
+		t.Errorf("FromExtra = %+v, want %+v", cfg, want)
+	}
+
+	_, err = FromExtra(map[string]string{"multiline": "maybe", "multiline_max_lines": "-1", "multiline_timeout": "soon"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{`invalid multiline "maybe"`, `invalid multiline_max_lines "-1"`, `invalid multiline_timeout "soon"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	if _, err := New(Config{Start: "(", Continue: "["}); err == nil || !strings.Contains(err.Error(), "start pattern") || !strings.Contains(err.Error(), "continue pattern") {
+		t.Errorf("expected both pattern errors, got %v", err)
+	}
+}
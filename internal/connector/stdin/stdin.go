@@ -7,9 +7,11 @@ import (
 	"io"
 	"log/slog"
 	"os"
-	"time"
 
 	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/connector/format"
+	"github.com/kaminocorp/lumber/internal/connector/multiline"
+	"github.com/kaminocorp/lumber/internal/connector/timestamp"
 	"github.com/kaminocorp/lumber/internal/model"
 )
 
@@ -47,7 +49,29 @@ func New(opts ...Option) *Connector {
 
 // Stream reads lines from the reader and sends each as a RawLog on the
 // returned channel. The channel closes on EOF or context cancellation.
-func (c *Connector) Stream(ctx context.Context, _ connector.ConnectorConfig) (<-chan model.RawLog, error) {
+// Each log is stamped with the timestamp found in its line (see the
+// "timestamp_layout" and "timezone" Extra keys), or the read time, and
+// structured lines are split into the message, as Raw, and fields, as
+// Metadata (see the "format" and "message_field" Extra keys). Continuation
+// lines such as stack frames are joined to the line before them (see the
+// "multiline*" Extra keys and multiline.FromExtra).
+func (c *Connector) Stream(ctx context.Context, cfg connector.ConnectorConfig) (<-chan model.RawLog, error) {
+	ts, err := timestamp.New(cfg.Extra["timestamp_layout"], cfg.Extra["timezone"])
+	if err != nil {
+		return nil, fmt.Errorf("stdin connector: %w", err)
+	}
+	fp, err := format.New(cfg.Extra["format"], cfg.Extra["message_field"])
+	if err != nil {
+		return nil, fmt.Errorf("stdin connector: %w", err)
+	}
+	mc, err := multiline.FromExtra(cfg.Extra)
+	if err != nil {
+		return nil, fmt.Errorf("stdin connector: %w", err)
+	}
+	lines, err := multiline.New(mc)
+	if err != nil {
+		return nil, fmt.Errorf("stdin connector: %w", err)
+	}
 	ch := make(chan model.RawLog, 64)
 
 	scanner := bufio.NewScanner(c.reader)
@@ -66,10 +90,14 @@ func (c *Connector) Stream(ctx context.Context, _ connector.ConnectorConfig) (<-
 			if line == "" {
 				continue
 			}
+			t, source := ts.Stamp(line)
+			msg, fields := fp.Parse(line)
 			raw := model.RawLog{
-				Timestamp: time.Now(),
-				Source:    "stdin",
-				Raw:       line,
+				Timestamp:  t,
+				TimeSource: source,
+				Source:     "stdin",
+				Raw:        msg,
+				Metadata:   fields,
 			}
 			select {
 			case ch <- raw:
@@ -82,7 +110,7 @@ func (c *Connector) Stream(ctx context.Context, _ connector.ConnectorConfig) (<-
 		}
 	}()
 
-	return ch, nil
+	return lines.Stream(ctx, ch), nil
 }
 
 // Query is not supported for stdin — it is inherently a streaming source.
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/model"
 )
 
 func TestStream_ReadsLines(t *testing.T) {
@@ -132,3 +133,67 @@ func TestStream_LongLines(t *testing.T) {
 		t.Errorf("expected 100000 chars, got %d", len(got))
 	}
 }
+
+func TestStream_ParsesTimestamps(t *testing.T) {
+	input := "2026-02-19T12:00:01Z ERROR db timeout\nno timestamp here\n"
+	c := New(WithReader(strings.NewReader(input)))
+
+	ch, err := c.Stream(context.Background(), connector.ConnectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, inferred := <-ch, <-ch
+	if want := time.Date(2026, 2, 19, 12, 0, 1, 0, time.UTC); !parsed.Timestamp.Equal(want) || parsed.TimeSource != model.TimeParsed {
+		t.Errorf("parsed line: %s (%s), want %s", parsed.Timestamp, parsed.TimeSource, want)
+	}
+	if inferred.TimeSource != model.TimeInferred || time.Since(inferred.Timestamp) > time.Minute {
+		t.Errorf("line without timestamp: %s (%s), want ingest time", inferred.Timestamp, inferred.TimeSource)
+	}
+}
+
+func TestStream_ParsesInputFormat(t *testing.T) {
+	input := `{"severity":"warn","log":{"message":"disk 91% full"}}` + "\n"
+	c := New(WithReader(strings.NewReader(input)))
+
+	cfg := connector.ConnectorConfig{Extra: map[string]string{"message_field": "log.message"}}
+	ch, err := c.Stream(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := <-ch
+	if raw.Raw != "disk 91% full" || raw.Metadata["severity"] != "warn" {
+		t.Errorf("Raw=%q Metadata=%v", raw.Raw, raw.Metadata)
+	}
+}
+
+func TestStream_JoinsMultiLineEvents(t *testing.T) {
+	input := "java.lang.NullPointerException\n\tat com.acme.A.run(A.java:1)\nCaused by: java.io.IOException\n\tat com.acme.B.read(B.java:2)\nnext line\n"
+	c := New(WithReader(strings.NewReader(input)))
+
+	ch, err := c.Stream(context.Background(), connector.ConnectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []string
+	for raw := range ch {
+		events = append(events, raw.Raw)
+	}
+	if len(events) != 2 || strings.Count(events[0], "\n") != 3 || events[1] != "next line" {
+		t.Errorf("unexpected events: %q", events)
+	}
+}
+
+func TestStream_JoinsContainerLines(t *testing.T) {
+	// Frames are joined after the CRI prefix is parsed off each line.
+	input := "2026-02-19T12:00:01Z stderr F java.lang.NullPointerException\n2026-02-19T12:00:01Z stderr F \tat com.acme.A.run(A.java:1)\n"
+	c := New(WithReader(strings.NewReader(input)))
+
+	ch, err := c.Stream(context.Background(), connector.ConnectorConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := <-ch
+	if raw.Raw != "java.lang.NullPointerException\n\tat com.acme.A.run(A.java:1)" || raw.Metadata["stream"] != "stderr" {
+		t.Errorf("Raw=%q Metadata=%v", raw.Raw, raw.Metadata)
+	}
+}
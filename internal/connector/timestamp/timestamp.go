@@ -0,0 +1,233 @@
+// Package timestamp extracts event times from log text, so that a replayed
+// log file keeps its original timing instead of the time it was read.
+package timestamp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// jsonKeys are the JSON fields holding the event time, in priority order.
+var jsonKeys = []string{"@timestamp", "timestamp", "time", "ts"}
+
+var (
+	// RFC3339 and ISO-8601 variants: "T" or space separator, "," or "."
+	// fractions, "/" date separators, optional Z, ±hh, ±hhmm or ±hh:mm zone.
+	isoRe = regexp.MustCompile(`\b(\d{4})[-/](\d{2})[-/](\d{2})[T ](\d{2}:\d{2}:\d{2})(?:[.,](\d{1,9}))?(Z|[+-]\d{2}(?::?\d{2})?)?`)
+	// Common Log Format: [10/Oct/2000:13:55:36 -0700]
+	clfRe = regexp.MustCompile(`\[(\d{2}/[A-Z][a-z]{2}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4})\]`)
+	// BSD syslog (RFC 3164): "<34>Oct 11 22:14:15", no year.
+	syslogRe = regexp.MustCompile(`^(?:<\d{1,3}>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2})\b`)
+	// Epoch seconds, millis or micros leading the line or as a logfmt
+	// ts/time/timestamp value.
+	epochRe = regexp.MustCompile(`^\[?(\d{10}(?:\d{3}){0,2}(?:\.\d{1,9})?)\b|\b(?:ts|time|timestamp)="?(\d{10}(?:\d{3}){0,2}(?:\.\d{1,9})?)\b`)
+)
+
+// Epoch values outside this range are ids or counters, not times.
+var (
+	minTime = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	maxTime = time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// Parser finds the timestamp of a log line. Safe for concurrent use.
+type Parser struct {
+	layout string
+	loc    *time.Location
+	now    func() time.Time
+}
+
+// New creates a Parser. layout is an optional Go time layout tried before
+// the built-in formats, e.g. "02.01.2006 15:04:05". timezone is the IANA
+// zone of timestamps that carry no offset, or "Local"; empty means UTC.
+func New(layout, timezone string) (*Parser, error) {
+	var errs []string
+	loc := time.UTC
+	if timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(timezone); err != nil {
+			errs = append(errs, fmt.Sprintf("invalid timezone %q: %v", timezone, err))
+		}
+	}
+	// A layout without any time element formats to itself.
+	if layout != "" && time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(layout) == layout {
+		errs = append(errs, fmt.Sprintf("invalid timestamp layout %q: no time elements (use Go's reference time, e.g. 2006-01-02 15:04:05)", layout))
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return &Parser{layout: layout, loc: loc, now: time.Now}, nil
+}
+
+// Stamp returns the time of line and how it was obtained: model.TimeParsed
+// when the line carries a timestamp, otherwise the current time and
+// model.TimeInferred.
+func (p *Parser) Stamp(line string) (time.Time, string) {
+	if t, ok := p.Parse(line); ok {
+		return t, model.TimeParsed
+	}
+	return p.now(), model.TimeInferred
+}
+
+// Parse returns the timestamp found in line. JSON lines are searched for
+// @timestamp, timestamp, time and ts fields; other lines for the custom
+// layout at the start of the line, then RFC3339/ISO-8601, Common Log
+// Format, syslog and epoch seconds/millis/micros.
+func (p *Parser) Parse(line string) (time.Time, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		if t, ok := p.parseJSON(line); ok {
+			return t, true
+		}
+	}
+	if p.layout != "" {
+		if t, ok := p.parseLayout(line); ok {
+			return t, true
+		}
+	}
+	return p.parseText(line)
+}
+
+func (p *Parser) parseText(s string) (time.Time, bool) {
+	if m := isoRe.FindStringSubmatch(s); m != nil {
+		if t, ok := p.parseISO(m); ok {
+			return t, true
+		}
+	}
+	if m := clfRe.FindStringSubmatch(s); m != nil {
+		if t, err := time.Parse("02/Jan/2006:15:04:05 -0700", m[1]); err == nil {
+			return t, true
+		}
+	}
+	if m := syslogRe.FindStringSubmatch(s); m != nil {
+		if t, ok := p.parseSyslog(m[1]); ok {
+			return t, true
+		}
+	}
+	if m := epochRe.FindStringSubmatch(s); m != nil {
+		return parseEpoch(m[1] + m[2])
+	}
+	return time.Time{}, false
+}
+
+func (p *Parser) parseJSON(line string) (time.Time, bool) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return time.Time{}, false
+	}
+	for _, key := range jsonKeys {
+		switch v := obj[key].(type) {
+		case string:
+			if p.layout != "" {
+				if t, err := time.ParseInLocation(p.layout, v, p.loc); err == nil {
+					return t, true
+				}
+			}
+			if t, ok := p.parseText(v); ok {
+				return t, true
+			}
+		case json.Number:
+			if t, ok := parseEpoch(v.String()); ok {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseLayout parses the custom layout against as many leading fields of
+// s as the layout has, ignoring a surrounding "[...]".
+func (p *Parser) parseLayout(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(s, "[")
+	n := len(strings.Fields(p.layout))
+	end, fields := 0, 0
+	for end < len(s) && fields < n {
+		for end < len(s) && s[end] == ' ' {
+			end++
+		}
+		for end < len(s) && s[end] != ' ' {
+			end++
+		}
+		fields++
+	}
+	t, err := time.ParseInLocation(p.layout, strings.TrimRight(s[:end], "],"), p.loc)
+	return t, err == nil
+}
+
+// parseISO parses an isoRe match: year, month, day, clock, fraction, zone.
+func (p *Parser) parseISO(m []string) (time.Time, bool) {
+	value := m[1] + "-" + m[2] + "-" + m[3] + "T" + m[4]
+	layout := "2006-01-02T15:04:05"
+	if m[5] != "" {
+		value += "." + m[5]
+		layout += ".999999999"
+	}
+	switch zone := m[6]; {
+	case zone == "":
+		t, err := time.ParseInLocation(layout, value, p.loc)
+		return t, err == nil
+	case zone == "Z":
+		layout += "Z07:00"
+	case len(zone) == 3:
+		layout += "-07"
+	case strings.Contains(zone, ":"):
+		layout += "-07:00"
+	default:
+		layout += "-0700"
+	}
+	t, err := time.Parse(layout, value+m[6])
+	return t, err == nil
+}
+
+// parseSyslog parses a yearless syslog time as the most recent such time
+// not more than a day in the future.
+func (p *Parser) parseSyslog(s string) (time.Time, bool) {
+	t, err := time.ParseInLocation("Jan _2 15:04:05", s, p.loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	now := p.now().In(p.loc)
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// parseEpoch parses Unix seconds (10 digits), millis (13) or micros (16),
+// with an optional fraction, rejecting values outside 2000-2100.
+func parseEpoch(s string) (time.Time, bool) {
+	whole, frac, _ := strings.Cut(s, ".")
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var t time.Time
+	switch len(whole) {
+	case 10:
+		nanos := 0
+		if frac != "" {
+			f := (frac + "000000000")[:9]
+			nanos, _ = strconv.Atoi(f)
+		}
+		t = time.Unix(n, int64(nanos))
+	case 13:
+		t = time.UnixMilli(n)
+	case 16:
+		t = time.UnixMicro(n)
+	default:
+		return time.Time{}, false
+	}
+	if t.Before(minTime) || !t.Before(maxTime) {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
@@ -0,0 +1,131 @@
+package timestamp
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func TestParseFormats(t *testing.T) {
+	p, err := New("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.now = func() time.Time { return time.Date(2026, 2, 20, 8, 0, 0, 0, time.UTC) }
+
+	utc := func(y int, mo time.Month, d, h, mi, s, ns int) time.Time {
+		return time.Date(y, mo, d, h, mi, s, ns, time.UTC)
+	}
+	tests := []struct {
+		name string
+		line string
+		want time.Time
+	}{
+		{"rfc3339", "2026-02-19T12:00:01Z ERROR db timeout", utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"rfc3339 nanos offset", "level=info 2026-02-19T14:00:01.123456789+02:00 started", utc(2026, 2, 19, 12, 0, 1, 123456789)},
+		{"iso space comma", "2026-02-19 12:00:01,250 WARN pool low", utc(2026, 2, 19, 12, 0, 1, 250000000)},
+		{"iso compact offset", "2026-02-19T07:00:01-0500 done", utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"slash date", "2026/02/19 12:00:01 [error] 17#17: connect() failed", utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"clf", `10.0.0.1 - - [19/Feb/2026:13:00:01 +0100] "GET / HTTP/1.1" 200 12`, utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"syslog", "<34>Feb 19 12:00:01 web1 sshd[42]: Accepted publickey", utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"syslog last year", "Dec 31 23:59:59 web1 cron[1]: done", utc(2025, 12, 31, 23, 59, 59, 0)},
+		{"epoch seconds", "1771502401 GET /health", utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"epoch millis fraction", "1771502401.5 tick", utc(2026, 2, 19, 12, 0, 1, 500000000)},
+		{"epoch millis", "[1771502401250] tick", utc(2026, 2, 19, 12, 0, 1, 250000000)},
+		{"logfmt epoch micros", "level=info ts=1771502401250000 msg=ok", utc(2026, 2, 19, 12, 0, 1, 250000000)},
+		{"json ts", `{"level":"info","ts":1771502401.25,"msg":"ok"}`, utc(2026, 2, 19, 12, 0, 1, 250000000)},
+		{"json @timestamp", `{"@timestamp":"2026-02-19T12:00:01Z","message":"2020-01-01T00:00:00Z"}`, utc(2026, 2, 19, 12, 0, 1, 0)},
+		{"json time", `{"time":"2026-02-19T12:00:01.5Z","msg":"ok"}`, utc(2026, 2, 19, 12, 0, 1, 500000000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := p.Parse(tt.line)
+			if !ok {
+				t.Fatalf("no timestamp found in %q", tt.line)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse = %s, want %s", got.UTC(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNone(t *testing.T) {
+	p, _ := New("", "")
+	for _, line := range []string{
+		"connection refused",
+		"user 1234567 logged in",
+		"order 9999999999999 shipped", // 13 digits past 2100
+		"\tat com.acme.Pool.acquire(Pool.java:42)",
+		`{"msg":"no time here","id":42}`,
+	} {
+		if got, ok := p.Parse(line); ok {
+			t.Errorf("Parse(%q) = %s, want none", line, got)
+		}
+	}
+}
+
+func TestParseTimezone(t *testing.T) {
+	p, err := New("", "America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := p.Parse("2026-02-19 07:00:01 INFO ready")
+	if want := time.Date(2026, 2, 19, 12, 0, 1, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("zoneless time = %s, want %s", got.UTC(), want)
+	}
+	got, _ = p.Parse("2026-02-19T12:00:01Z INFO ready")
+	if want := time.Date(2026, 2, 19, 12, 0, 1, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("explicit offset should win over the default zone, got %s", got.UTC())
+	}
+}
+
+func TestParseCustomLayout(t *testing.T) {
+	p, err := New("02.01.2006 15:04:05.000", "Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Date(2026, 2, 19, 12, 0, 1, 250000000, time.UTC)
+	for _, line := range []string{
+		"19.02.2026 13:00:01.250 ERROR payment failed",
+		"[19.02.2026 13:00:01.250] ERROR payment failed",
+		`{"time":"19.02.2026 13:00:01.250","msg":"payment failed"}`,
+	} {
+		if got, ok := p.Parse(line); !ok || !got.Equal(want) {
+			t.Errorf("Parse(%q) = %s, %v; want %s", line, got.UTC(), ok, want)
+		}
+	}
+	// Built-in formats still apply when the layout does not match.
+	if got, ok := p.Parse("2026-02-19T12:00:01.25Z ok"); !ok || !got.Equal(want) {
+		t.Errorf("fallback to RFC3339 failed: %s, %v", got, ok)
+	}
+}
+
+func TestStamp(t *testing.T) {
+	p, _ := New("", "")
+	now := time.Date(2026, 2, 20, 8, 0, 0, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	if got, src := p.Stamp("2026-02-19T12:00:01Z boom"); src != model.TimeParsed || got.Year() != 2026 || got.Day() != 19 {
+		t.Errorf("Stamp = %s, %s", got, src)
+	}
+	if got, src := p.Stamp("boom"); src != model.TimeInferred || !got.Equal(now) {
+		t.Errorf("Stamp without timestamp = %s, %s; want ingest time", got, src)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New("no time here", "Mars/Olympus")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{`invalid timezone "Mars/Olympus"`, `invalid timestamp layout "no time here"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if _, err := New("", "Local"); err != nil {
+		t.Errorf("Local timezone rejected: %v", err)
+	}
+}
@@ -1,4 +1,6 @@
-package testdata
+// Package corpus embeds Lumber's labeled log corpus, scored by lumber eval
+// and lumber calibrate and optionally seeded as taxonomy examples.
+package corpus
 
 import (
 	_ "embed"
@@ -26,3 +28,18 @@ func LoadCorpus() ([]CorpusEntry, error) {
 	}
 	return entries, nil
 }
+
+// Examples groups the corpus raw lines by expected label path
+// (e.g. "ERROR.timeout"), for seeding taxonomy exemplars.
+func Examples() (map[string][]string, error) {
+	entries, err := LoadCorpus()
+	if err != nil {
+		return nil, err
+	}
+	examples := make(map[string][]string)
+	for _, e := range entries {
+		path := e.ExpectedType + "." + e.ExpectedCategory
+		examples[path] = append(examples[path], e.Raw)
+	}
+	return examples, nil
+}
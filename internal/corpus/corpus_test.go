@@ -1,4 +1,4 @@
-package testdata
+package corpus
 
 import (
 	"testing"
@@ -95,3 +95,24 @@ func TestCorpusSeverityValues(t *testing.T) {
 		}
 	}
 }
+
+func TestExamples(t *testing.T) {
+	entries, err := LoadCorpus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	examples, err := Examples()
+	if err != nil {
+		t.Fatalf("Examples() error: %v", err)
+	}
+	total := 0
+	for _, raws := range examples {
+		total += len(raws)
+	}
+	if total != len(entries) {
+		t.Fatalf("expected %d examples, got %d", len(entries), total)
+	}
+	if len(examples["ERROR.connection_failure"]) == 0 {
+		t.Error("expected examples for ERROR.connection_failure")
+	}
+}
@@ -0,0 +1,116 @@
+package attributes
+
+import "strings"
+
+// curated maps each connector's metadata keys to the attribute names kept on
+// events by default. Connector metadata not listed here (ids, raw timestamps,
+// free-form provider fields) is only kept at Full verbosity.
+var curated = map[string]map[string]string{
+	"vercel": {
+		"level":       "level",
+		"source":      "runtime", // build, edge, lambda, static
+		"status_code": "status",
+		"method":      "method",
+		"path":        "path",
+		"host":        "host",
+	},
+	"flyio": {
+		"level":    "level",
+		"instance": "instance",
+		"region":   "region",
+	},
+	"supabase": {
+		"table": "table",
+	},
+	"file": {
+		"file":   "file",
+		"level":  "level",  // parsed input formats (JSON, logfmt, syslog, CRI)
+		"stream": "stream", // stdout or stderr (CRI)
+		"host":   "host",   // syslog
+		"app":    "app",    // syslog
+	},
+}
+
+// Extractor builds an event's attributes from a raw log's metadata.
+// A nil *Extractor applies the default curated mapping.
+type Extractor struct {
+	allow []string
+	drop  map[string]bool
+	all   bool
+}
+
+// New creates an Extractor. When allow is non-empty, only those attributes
+// are kept, looked up by attribute name and then by raw metadata key.
+// Keys in drop are always removed. When all is true, metadata outside the
+// curated mapping is kept under its original key (Full verbosity).
+func New(allow, drop []string, all bool) *Extractor {
+	x := &Extractor{allow: allow, all: all}
+	if len(drop) > 0 {
+		x.drop = make(map[string]bool, len(drop))
+		for _, k := range drop {
+			x.drop[k] = true
+		}
+	}
+	return x
+}
+
+// Extract returns the attributes for a log from source with metadata md, or
+// nil when there are none. Sources without a curated mapping (such as logs
+// passed to the library) keep all of their metadata.
+func (x *Extractor) Extract(source string, md map[string]any) map[string]any {
+	if len(md) == 0 {
+		return nil
+	}
+	if x == nil {
+		x = &Extractor{}
+	}
+
+	mapping, known := curated[source]
+	attrs := make(map[string]any, len(md))
+	for key, val := range md {
+		if name, ok := mapping[key]; ok {
+			attrs[name] = val
+		} else if !known || x.all {
+			if _, taken := attrs[key]; !taken {
+				attrs[key] = val
+			}
+		}
+	}
+
+	if len(x.allow) > 0 {
+		allowed := make(map[string]any, len(x.allow))
+		for _, key := range x.allow {
+			if val, ok := attrs[key]; ok {
+				allowed[key] = val
+			} else if val, ok := md[key]; ok {
+				allowed[key] = val
+			}
+		}
+		attrs = allowed
+	}
+	for key := range x.drop {
+		delete(attrs, key)
+	}
+
+	for key, val := range attrs {
+		if isEmpty(val) {
+			delete(attrs, key)
+		}
+	}
+	if len(attrs) == 0 {
+		return nil
+	}
+	return attrs
+}
+
+// isEmpty reports whether val carries no information: nil or a blank
+// string, as set by connectors for absent fields.
+func isEmpty(val any) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+	return false
+}
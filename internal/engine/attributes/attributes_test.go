@@ -0,0 +1,78 @@
+package attributes
+
+import (
+	"reflect"
+	"testing"
+)
+
+func vercelMetadata() map[string]any {
+	return map[string]any{
+		"level":       "error",
+		"source":      "lambda",
+		"id":          "log_123",
+		"status_code": 502,
+		"method":      "GET",
+		"path":        "/api/users",
+		"host":        "",
+	}
+}
+
+func TestExtractCurated(t *testing.T) {
+	var x *Extractor // nil applies the default mapping
+	got := x.Extract("vercel", vercelMetadata())
+	want := map[string]any{
+		"level":   "error",
+		"runtime": "lambda",
+		"status":  502,
+		"method":  "GET",
+		"path":    "/api/users",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Extract() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractAll(t *testing.T) {
+	got := New(nil, nil, true).Extract("vercel", vercelMetadata())
+	if got["id"] != "log_123" || got["runtime"] != "lambda" {
+		t.Errorf("expected uncurated id alongside curated fields, got %v", got)
+	}
+	if _, ok := got["source"]; ok {
+		t.Error("curated key should only appear under its attribute name")
+	}
+}
+
+func TestExtractUnknownSourceKeepsMetadata(t *testing.T) {
+	md := map[string]any{"service": "billing", "pod": "billing-7f9c"}
+	got := New(nil, nil, false).Extract("myapp", md)
+	if !reflect.DeepEqual(got, md) {
+		t.Errorf("Extract() = %v, want all metadata %v", got, md)
+	}
+}
+
+func TestExtractAllowAndDrop(t *testing.T) {
+	x := New([]string{"status", "id", "missing"}, nil, false)
+	got := x.Extract("vercel", vercelMetadata())
+	want := map[string]any{"status": 502, "id": "log_123"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("allow: Extract() = %v, want %v", got, want)
+	}
+
+	x = New(nil, []string{"path", "method"}, false)
+	got = x.Extract("vercel", vercelMetadata())
+	if _, ok := got["path"]; ok {
+		t.Errorf("drop: path should be removed, got %v", got)
+	}
+	if got["status"] != 502 {
+		t.Errorf("drop: status should be kept, got %v", got)
+	}
+}
+
+func TestExtractEmpty(t *testing.T) {
+	if got := New(nil, nil, false).Extract("vercel", nil); got != nil {
+		t.Errorf("nil metadata: got %v, want nil", got)
+	}
+	if got := New(nil, nil, false).Extract("flyio", map[string]any{"region": "", "id": "x"}); got != nil {
+		t.Errorf("only blank or uncurated fields: got %v, want nil", got)
+	}
+}
@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/kaminocorp/lumber/internal/engine/classifier"
+)
+
+// Stats is a snapshot of cache activity.
+type Stats struct {
+	Hits     uint64 `json:"hits"`
+	Misses   uint64 `json:"misses"`
+	Entries  int    `json:"entries"`
+	Capacity int    `json:"capacity"`
+}
+
+// HitRate returns hits / (hits + misses), or 0 before any lookups.
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Cache is a fixed-size LRU of classification results keyed by log template.
+// Safe for concurrent use. A nil *Cache is a valid, always-empty cache.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used
+	items    map[string]*list.Element
+	hits     uint64
+	misses   uint64
+}
+
+type entry struct {
+	key    string
+	result classifier.Result
+}
+
+// New creates a cache holding up to size results. Returns nil when size <= 0.
+func New(size int) *Cache {
+	if size <= 0 {
+		return nil
+	}
+	return &Cache{
+		capacity: size,
+		order:    list.New(),
+		items:    make(map[string]*list.Element, size),
+	}
+}
+
+// Get returns the cached result for key and marks it recently used.
+func (c *Cache) Get(key string) (classifier.Result, bool) {
+	if c == nil {
+		return classifier.Result{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return classifier.Result{}, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*entry).result, true
+}
+
+// Put stores result under key, evicting the least recently used entry when
+// the cache is full.
+func (c *Cache) Put(key string, result classifier.Result) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		el.Value.(*entry).result = result
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry{key: key, result: result})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*entry).key)
+	}
+}
+
+// Stats returns the current hit/miss counters and occupancy.
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Hits: c.hits, Misses: c.misses, Entries: c.order.Len(), Capacity: c.capacity}
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/kaminocorp/lumber/internal/engine/classifier"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func result(path string) classifier.Result {
+	return classifier.Result{Label: model.EmbeddedLabel{Path: path}, Confidence: 0.9}
+}
+
+func TestGetPut(t *testing.T) {
+	c := New(2)
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("expected miss on empty cache")
+	}
+	c.Put("a", result("A"))
+	got, ok := c.Get("a")
+	if !ok || got.Label.Path != "A" {
+		t.Fatalf("Get(a) = %+v, %v", got, ok)
+	}
+
+	s := c.Stats()
+	if s.Hits != 1 || s.Misses != 1 || s.Entries != 1 || s.Capacity != 2 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.HitRate() != 0.5 {
+		t.Errorf("hit rate = %f, want 0.5", s.HitRate())
+	}
+}
+
+func TestEvictsLeastRecentlyUsed(t *testing.T) {
+	c := New(2)
+	c.Put("a", result("A"))
+	c.Put("b", result("B"))
+	c.Get("a") // b is now least recently used
+	c.Put("c", result("C"))
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(key); !ok {
+			t.Errorf("expected %s to be cached", key)
+		}
+	}
+	if n := c.Stats().Entries; n != 2 {
+		t.Errorf("entries = %d, want 2", n)
+	}
+}
+
+func TestPutReplaces(t *testing.T) {
+	c := New(2)
+	c.Put("a", result("A"))
+	c.Put("a", result("B"))
+	got, _ := c.Get("a")
+	if got.Label.Path != "B" || c.Stats().Entries != 1 {
+		t.Errorf("expected replaced single entry, got %+v (%d entries)", got, c.Stats().Entries)
+	}
+}
+
+func TestNilCache(t *testing.T) {
+	c := New(0)
+	if c != nil {
+		t.Fatal("expected nil cache for size 0")
+	}
+	c.Put("a", result("A"))
+	if _, ok := c.Get("a"); ok {
+		t.Error("nil cache should never hit")
+	}
+	if c.Stats() != (Stats{}) {
+		t.Error("nil cache should report zero stats")
+	}
+}
+
+func TestConcurrentUse(t *testing.T) {
+	c := New(16)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := fmt.Sprintf("k%d", (g+i)%32)
+				if got, ok := c.Get(key); ok && got.Label.Path != key {
+					t.Errorf("Get(%s) returned %s", key, got.Label.Path)
+					return
+				}
+				c.Put(key, result(key))
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	s := c.Stats()
+	if s.Hits+s.Misses != 8000 {
+		t.Errorf("lookups = %d, want 8000", s.Hits+s.Misses)
+	}
+	if s.Entries > 16 {
+		t.Errorf("entries = %d exceeds capacity 16", s.Entries)
+	}
+}
@@ -0,0 +1,81 @@
+package classifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Calibration holds a fitted softmax temperature and thresholds, all on the
+// calibrated confidence scale. It is produced by "lumber calibrate" and
+// applied with Classifier.Calibrate.
+type Calibration struct {
+	Temperature float64            `json:"temperature,omitempty"`
+	Threshold   float64            `json:"threshold,omitempty"` // for labels without a fitted threshold
+	Thresholds  map[string]float64 `json:"thresholds,omitempty"`
+}
+
+// LoadCalibration reads and validates a calibration JSON file.
+func LoadCalibration(path string) (Calibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Calibration{}, fmt.Errorf("calibration: %w", err)
+	}
+	var cal Calibration
+	if err := json.Unmarshal(data, &cal); err != nil {
+		return Calibration{}, fmt.Errorf("calibration: %s: %w", path, err)
+	}
+	if err := cal.Validate(); err != nil {
+		return Calibration{}, fmt.Errorf("calibration: %s: %w", path, err)
+	}
+	return cal, nil
+}
+
+// Save writes the calibration as indented JSON.
+func (cal Calibration) Save(path string) error {
+	data, err := json.MarshalIndent(cal, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Validate checks that the temperature is non-negative and every threshold
+// is within 0-1.
+func (cal Calibration) Validate() error {
+	var errs []string
+	if math.IsNaN(cal.Temperature) || cal.Temperature < 0 {
+		errs = append(errs, fmt.Sprintf("temperature must be non-negative, got %g", cal.Temperature))
+	}
+	if math.IsNaN(cal.Threshold) || cal.Threshold < 0 || cal.Threshold > 1 {
+		errs = append(errs, fmt.Sprintf("threshold must be 0-1, got %g", cal.Threshold))
+	}
+	paths := make([]string, 0, len(cal.Thresholds))
+	for path := range cal.Thresholds {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if t := cal.Thresholds[path]; math.IsNaN(t) || t < 0 || t > 1 {
+			errs = append(errs, fmt.Sprintf("%s: threshold must be 0-1, got %g", path, t))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid calibration:\n  - " + strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// Calibrate applies a calibration: its temperature and per-label thresholds
+// replace the classifier's, as does its global threshold when set.
+func (c *Classifier) Calibrate(cal Calibration) {
+	c.Temperature = cal.Temperature
+	c.Thresholds = cal.Thresholds
+	if cal.Threshold > 0 {
+		c.Threshold = cal.Threshold
+	}
+}
@@ -0,0 +1,59 @@
+package classifier
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCalibrationSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	cal := Calibration{Temperature: 0.05, Threshold: 0.4, Thresholds: map[string]float64{"ERROR.timeout": 0.7}}
+	if err := cal.Save(path); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+
+	loaded, err := LoadCalibration(path)
+	if err != nil {
+		t.Fatalf("LoadCalibration() error: %v", err)
+	}
+	if loaded.Temperature != 0.05 || loaded.Threshold != 0.4 || loaded.Thresholds["ERROR.timeout"] != 0.7 {
+		t.Fatalf("round-trip mismatch: %+v", loaded)
+	}
+
+	c := New(0.5)
+	c.Calibrate(loaded)
+	if c.Temperature != 0.05 || c.Threshold != 0.4 || c.Thresholds["ERROR.timeout"] != 0.7 {
+		t.Errorf("Calibrate() did not apply: %+v", c)
+	}
+}
+
+func TestCalibrateKeepsGlobalThreshold(t *testing.T) {
+	c := New(0.5)
+	c.Calibrate(Calibration{Thresholds: map[string]float64{"A": 0.6}})
+	if c.Threshold != 0.5 {
+		t.Errorf("threshold = %f, want 0.5 when the calibration has none", c.Threshold)
+	}
+}
+
+func TestLoadCalibrationInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	data := `{"temperature": -1, "thresholds": {"A": 1.5}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadCalibration(path)
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{"temperature", "A: threshold must be 0-1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got: %v", want, err)
+		}
+	}
+
+	if _, err := LoadCalibration(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
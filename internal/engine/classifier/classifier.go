@@ -10,11 +10,58 @@ import (
 type Result struct {
 	Label      model.EmbeddedLabel
 	Confidence float64
+
+	// Alternatives, Margin and Ambiguous are only populated when the
+	// classifier's TopK is greater than 1.
+	Alternatives []model.Alternative // runner-up labels, best first
+	Margin       float64             // best score minus runner-up score
+	Ambiguous    bool                // Margin below the classifier's AmbiguityMargin
+
+	// Vector is the embedding that was classified.
+	Vector []float32
 }
 
+// Scoring selects how a label's prototypes are combined into one score.
+type Scoring string
+
+const (
+	// ScoreNearest scores a label by its most similar prototype: the
+	// description vector or any exemplar (1-nearest-neighbor).
+	ScoreNearest Scoring = "knn"
+	// ScoreCentroid scores a label against the centroid of its description
+	// and exemplars.
+	ScoreCentroid Scoring = "centroid"
+)
+
 // Classifier scores a log embedding against pre-embedded taxonomy labels.
 type Classifier struct {
+	// Threshold is the minimum confidence for labels without their own
+	// threshold. Per-label thresholds come from Thresholds, then from
+	// EmbeddedLabel.Threshold. A label's own threshold is on the raw
+	// similarity scale, so it is ignored once Temperature is set.
 	Threshold float64
+
+	// Thresholds overrides the threshold for specific label paths, e.g. as
+	// fitted by a calibration run.
+	Thresholds map[string]float64
+
+	// Temperature, when > 0, turns raw similarities into a softmax
+	// distribution over all labels: confidence = exp(s/T) / Σ exp(s_j/T).
+	// Thresholds then apply to the calibrated confidence.
+	Temperature float64
+
+	// Scoring selects nearest-prototype or centroid scoring. The zero value
+	// is ScoreNearest, which for labels without exemplars is plain cosine
+	// similarity to the description.
+	Scoring Scoring
+
+	// TopK is the number of ranked labels to consider, including the best
+	// match. Values <= 1 disable alternatives and margin reporting.
+	TopK int
+
+	// AmbiguityMargin flags a classified result as ambiguous when the best
+	// score beats the runner-up by less than this amount. Requires TopK > 1.
+	AmbiguityMargin float64
 }
 
 // New creates a Classifier with the given confidence threshold.
@@ -22,28 +69,158 @@ func New(threshold float64) *Classifier {
 	return &Classifier{Threshold: threshold}
 }
 
+// scored pairs a label with its similarity to the log embedding.
+type scored struct {
+	label model.EmbeddedLabel
+	score float64
+}
+
 // Classify finds the best-matching taxonomy label for the given embedding vector.
-// Returns the top match. If confidence is below threshold, Label.Path will be "UNCLASSIFIED".
+// Returns the top match. If confidence is below the label's threshold,
+// Label.Path will be "UNCLASSIFIED".
+//
+// With TopK > 1 the result also carries up to TopK-1 alternatives and the
+// margin between the two best scores. For an UNCLASSIFIED result the
+// alternatives are the best candidates that fell below the threshold.
 func (c *Classifier) Classify(vector []float32, labels []model.EmbeddedLabel) Result {
 	if len(labels) == 0 {
-		return Result{Label: model.EmbeddedLabel{Path: "UNCLASSIFIED"}, Confidence: 0}
+		return Result{Label: model.EmbeddedLabel{Path: "UNCLASSIFIED"}, Confidence: 0, Vector: vector}
 	}
 
-	best := Result{Confidence: -1}
-	for _, lbl := range labels {
-		sim := cosineSimilarity(vector, lbl.Vector)
-		if sim > best.Confidence {
-			best = Result{Label: lbl, Confidence: sim}
+	k := c.TopK
+	if k < 1 {
+		k = 1
+	}
+	scores := c.Scores(vector, labels)
+	if c.Temperature > 0 {
+		scores = Softmax(scores, c.Temperature)
+	}
+	top := make([]scored, 0, k+1)
+	for i, lbl := range labels {
+		top = insertTop(top, scored{label: lbl, score: scores[i]}, k)
+	}
+
+	best := top[0]
+	classified := best.score >= c.threshold(best.label)
+	var result Result
+	if classified {
+		result = Result{Label: best.label, Confidence: best.score}
+	} else {
+		result = Result{Label: model.EmbeddedLabel{Path: "UNCLASSIFIED"}, Confidence: best.score}
+	}
+	result.Vector = vector
+
+	if c.TopK <= 1 {
+		return result
+	}
+
+	rest := top[1:]
+	if !classified {
+		rest = top[:len(top)-1]
+	}
+	result.Alternatives = make([]model.Alternative, len(rest))
+	for i, s := range rest {
+		result.Alternatives[i] = model.Alternative{Path: s.label.Path, Confidence: s.score}
+	}
+	if len(top) > 1 {
+		result.Margin = best.score - top[1].score
+		result.Ambiguous = classified && result.Margin < c.AmbiguityMargin
+	}
+	return result
+}
+
+// threshold returns the confidence threshold for lbl.
+func (c *Classifier) threshold(lbl model.EmbeddedLabel) float64 {
+	if t, ok := c.Thresholds[lbl.Path]; ok {
+		return t
+	}
+	if lbl.Threshold > 0 && c.Temperature <= 0 {
+		return lbl.Threshold
+	}
+	return c.Threshold
+}
+
+// Scores returns the uncalibrated similarity of vector to each label, in
+// label order.
+func (c *Classifier) Scores(vector []float32, labels []model.EmbeddedLabel) []float64 {
+	scores := make([]float64, len(labels))
+	for i, lbl := range labels {
+		scores[i] = c.score(vector, lbl)
+	}
+	return scores
+}
+
+// Softmax converts scores to probabilities at the given temperature.
+func Softmax(scores []float64, temperature float64) []float64 {
+	if len(scores) == 0 {
+		return nil
+	}
+	maxScore := scores[0]
+	for _, s := range scores[1:] {
+		maxScore = math.Max(maxScore, s)
+	}
+	probs := make([]float64, len(scores))
+	var sum float64
+	for i, s := range scores {
+		// Subtract the max before exponentiating to avoid overflow.
+		probs[i] = math.Exp((s - maxScore) / temperature)
+		sum += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= sum
+	}
+	return probs
+}
+
+// score returns the similarity between vector and a label's prototypes.
+// When the vector is closer to one of the label's negative examples than to
+// its prototypes, the score is reduced by the difference.
+func (c *Classifier) score(vector []float32, lbl model.EmbeddedLabel) float64 {
+	var pos float64
+	if c.Scoring == ScoreCentroid && lbl.Centroid != nil {
+		pos = CosineSimilarity(vector, lbl.Centroid)
+	} else {
+		pos = CosineSimilarity(vector, lbl.Vector)
+		for _, ex := range lbl.Exemplars {
+			pos = math.Max(pos, CosineSimilarity(vector, ex))
 		}
 	}
 
-	if best.Confidence < c.Threshold {
-		return Result{Label: model.EmbeddedLabel{Path: "UNCLASSIFIED"}, Confidence: best.Confidence}
+	if len(lbl.Negatives) == 0 {
+		return pos
+	}
+	neg := math.Inf(-1)
+	for _, n := range lbl.Negatives {
+		neg = math.Max(neg, CosineSimilarity(vector, n))
+	}
+	if neg > pos {
+		pos -= neg - pos
+	}
+	return pos
+}
+
+// insertTop inserts s into top (sorted by descending score), keeping at most
+// k entries. Earlier labels win ties, matching single-best behavior.
+func insertTop(top []scored, s scored, k int) []scored {
+	i := len(top)
+	for i > 0 && s.score > top[i-1].score {
+		i--
+	}
+	if i >= k {
+		return top
+	}
+	top = append(top, scored{})
+	copy(top[i+1:], top[i:])
+	top[i] = s
+	if len(top) > k {
+		top = top[:k]
 	}
-	return best
+	return top
 }
 
-func cosineSimilarity(a, b []float32) float64 {
+// CosineSimilarity returns the cosine of the angle between a and b, or 0
+// when their lengths differ or either is zero.
+func CosineSimilarity(a, b []float32) float64 {
 	if len(a) != len(b) || len(a) == 0 {
 		return 0
 	}
@@ -93,43 +93,249 @@ func TestClassify_ZeroThreshold(t *testing.T) {
 }
 
 func TestCosineSimilarity_Orthogonal(t *testing.T) {
-	sim := cosineSimilarity([]float32{1, 0}, []float32{0, 1})
+	sim := CosineSimilarity([]float32{1, 0}, []float32{0, 1})
 	if math.Abs(sim) > 1e-6 {
 		t.Errorf("orthogonal vectors: got %f, want 0", sim)
 	}
 }
 
 func TestCosineSimilarity_Identical(t *testing.T) {
-	sim := cosineSimilarity([]float32{3, 4}, []float32{3, 4})
+	sim := CosineSimilarity([]float32{3, 4}, []float32{3, 4})
 	if math.Abs(sim-1.0) > 1e-6 {
 		t.Errorf("identical vectors: got %f, want 1", sim)
 	}
 }
 
 func TestCosineSimilarity_Opposite(t *testing.T) {
-	sim := cosineSimilarity([]float32{1, 0}, []float32{-1, 0})
+	sim := CosineSimilarity([]float32{1, 0}, []float32{-1, 0})
 	if math.Abs(sim+1.0) > 1e-6 {
 		t.Errorf("opposite vectors: got %f, want -1", sim)
 	}
 }
 
 func TestCosineSimilarity_DifferentLengths(t *testing.T) {
-	sim := cosineSimilarity([]float32{1, 0}, []float32{1, 0, 0})
+	sim := CosineSimilarity([]float32{1, 0}, []float32{1, 0, 0})
 	if sim != 0 {
 		t.Errorf("different lengths: got %f, want 0", sim)
 	}
 }
 
 func TestCosineSimilarity_Empty(t *testing.T) {
-	sim := cosineSimilarity([]float32{}, []float32{})
+	sim := CosineSimilarity([]float32{}, []float32{})
 	if sim != 0 {
 		t.Errorf("empty: got %f, want 0", sim)
 	}
 }
 
 func TestCosineSimilarity_ZeroNorm(t *testing.T) {
-	sim := cosineSimilarity([]float32{0, 0}, []float32{1, 0})
+	sim := CosineSimilarity([]float32{0, 0}, []float32{1, 0})
 	if sim != 0 {
 		t.Errorf("zero norm: got %f, want 0", sim)
 	}
 }
+
+func TestClassify_TopKDisabledByDefault(t *testing.T) {
+	labels := []model.EmbeddedLabel{
+		label("A", []float32{1, 0}),
+		label("B", []float32{0, 1}),
+	}
+	c := New(0.0)
+
+	result := c.Classify([]float32{1, 0.1}, labels)
+	if result.Alternatives != nil || result.Margin != 0 || result.Ambiguous {
+		t.Errorf("expected no alternatives without TopK, got %+v", result)
+	}
+}
+
+func TestClassify_TopKAlternatives(t *testing.T) {
+	labels := []model.EmbeddedLabel{
+		label("A", []float32{0, 0, 1}),
+		label("B", []float32{1, 0, 0}),
+		label("C", []float32{1, 1, 0}),
+		label("D", []float32{0, 1, 0}),
+	}
+	c := New(0.5)
+	c.TopK = 3
+
+	result := c.Classify([]float32{1, 0.2, 0}, labels)
+	if result.Label.Path != "B" {
+		t.Fatalf("got %q, want B", result.Label.Path)
+	}
+	if len(result.Alternatives) != 2 {
+		t.Fatalf("expected 2 alternatives, got %d", len(result.Alternatives))
+	}
+	if result.Alternatives[0].Path != "C" || result.Alternatives[1].Path != "D" {
+		t.Errorf("alternatives = %+v, want C then D", result.Alternatives)
+	}
+	want := result.Confidence - result.Alternatives[0].Confidence
+	if math.Abs(result.Margin-want) > 1e-9 {
+		t.Errorf("margin = %f, want %f", result.Margin, want)
+	}
+}
+
+func TestClassify_Ambiguous(t *testing.T) {
+	labels := []model.EmbeddedLabel{
+		label("A", []float32{1, 0.1}),
+		label("B", []float32{1, -0.1}),
+	}
+	c := New(0.5)
+	c.TopK = 2
+	c.AmbiguityMargin = 0.05
+
+	result := c.Classify([]float32{1, 0.01}, labels)
+	if result.Label.Path != "A" {
+		t.Fatalf("got %q, want A", result.Label.Path)
+	}
+	if !result.Ambiguous {
+		t.Errorf("expected near-tie to be ambiguous, margin=%f", result.Margin)
+	}
+
+	c.AmbiguityMargin = 0.001
+	if c.Classify([]float32{1, 0.01}, labels).Ambiguous {
+		t.Error("expected clear result with a smaller ambiguity margin")
+	}
+}
+
+func TestClassify_TopKUnclassified(t *testing.T) {
+	labels := []model.EmbeddedLabel{
+		label("A", []float32{1, 0}),
+		label("B", []float32{0, 1}),
+	}
+	c := New(0.99)
+	c.TopK = 2
+	c.AmbiguityMargin = 1
+
+	result := c.Classify([]float32{0.7, 0.6}, labels)
+	if result.Label.Path != "UNCLASSIFIED" {
+		t.Fatalf("got %q, want UNCLASSIFIED", result.Label.Path)
+	}
+	if len(result.Alternatives) != 1 || result.Alternatives[0].Path != "A" {
+		t.Errorf("expected best below-threshold candidate A, got %+v", result.Alternatives)
+	}
+	if result.Ambiguous {
+		t.Error("UNCLASSIFIED results should not be flagged ambiguous")
+	}
+}
+
+func TestClassify_TopKLargerThanLabels(t *testing.T) {
+	labels := []model.EmbeddedLabel{label("A", []float32{1, 0})}
+	c := New(0.0)
+	c.TopK = 5
+
+	result := c.Classify([]float32{1, 0}, labels)
+	if len(result.Alternatives) != 0 || result.Margin != 0 {
+		t.Errorf("single label should have no alternatives, got %+v", result)
+	}
+}
+
+func TestClassify_NearestExemplar(t *testing.T) {
+	// The description vector of B is far from the input, but one of its
+	// exemplars is a close match.
+	a := label("A", []float32{1, 0, 0})
+	b := label("B", []float32{0, 1, 0})
+	b.Exemplars = [][]float32{{0.8, 0, 0.6}}
+	c := New(0.5)
+
+	result := c.Classify([]float32{0.8, 0, 0.6}, []model.EmbeddedLabel{a, b})
+	if result.Label.Path != "B" {
+		t.Errorf("got %q, want B via exemplar", result.Label.Path)
+	}
+}
+
+func TestClassify_CentroidScoring(t *testing.T) {
+	a := label("A", []float32{1, 0, 0})
+	b := label("B", []float32{0, 1, 0})
+	b.Exemplars = [][]float32{{0.8, 0, 0.6}}
+	b.Centroid = []float32{0.5, 0.7, 0.5}
+	c := New(0.5)
+	c.Scoring = ScoreCentroid
+
+	// Under centroid scoring the single outlying exemplar no longer wins.
+	result := c.Classify([]float32{0.8, 0, 0.6}, []model.EmbeddedLabel{a, b})
+	if result.Label.Path != "A" {
+		t.Errorf("got %q, want A under centroid scoring", result.Label.Path)
+	}
+}
+
+func TestClassify_NegativeExamplePenalty(t *testing.T) {
+	a := label("A", []float32{1, 0.3})
+	a.Negatives = [][]float32{{1, 0}}
+	b := label("B", []float32{1, -0.4})
+	c := New(0.0)
+
+	// Without the negative, A is the closer label.
+	plain := label("A", []float32{1, 0.3})
+	if got := c.Classify([]float32{1, 0}, []model.EmbeddedLabel{plain, b}).Label.Path; got != "A" {
+		t.Fatalf("baseline got %q, want A", got)
+	}
+	// The input matches A's negative example exactly, so A is penalized.
+	if got := c.Classify([]float32{1, 0}, []model.EmbeddedLabel{a, b}).Label.Path; got != "B" {
+		t.Errorf("got %q, want B after negative penalty", got)
+	}
+}
+
+func TestClassify_PerLabelThreshold(t *testing.T) {
+	strict := label("A", []float32{1, 0})
+	strict.Threshold = 0.99
+	c := New(0.5)
+
+	// 0.894 similarity passes the global threshold but not A's own.
+	result := c.Classify([]float32{1, 0.5}, []model.EmbeddedLabel{strict})
+	if result.Label.Path != "UNCLASSIFIED" {
+		t.Errorf("got %q, want UNCLASSIFIED under label threshold", result.Label.Path)
+	}
+
+	// A calibration threshold takes precedence over the label's.
+	c.Thresholds = map[string]float64{"A": 0.8}
+	result = c.Classify([]float32{1, 0.5}, []model.EmbeddedLabel{strict})
+	if result.Label.Path != "A" {
+		t.Errorf("got %q, want A under calibrated threshold", result.Label.Path)
+	}
+}
+
+func TestClassify_LabelThresholdIgnoredUnderSoftmax(t *testing.T) {
+	// A's 0.99 is a raw similarity threshold. Its softmax confidence of
+	// about 0.989 is on another scale and only the global 0.5 applies.
+	strict := label("A", []float32{1, 0})
+	strict.Threshold = 0.99
+	c := New(0.5)
+	c.Temperature = 0.1
+	b := label("B", []float32{0, 1})
+
+	result := c.Classify([]float32{1, 0.5}, []model.EmbeddedLabel{strict, b})
+	if result.Label.Path != "A" {
+		t.Errorf("got %q (confidence %f), want A under the global threshold", result.Label.Path, result.Confidence)
+	}
+}
+
+func TestClassify_Temperature(t *testing.T) {
+	labels := []model.EmbeddedLabel{
+		label("A", []float32{1, 0}),
+		label("B", []float32{0, 1}),
+	}
+	c := New(0.0)
+	c.TopK = 2
+	c.Temperature = 0.1
+
+	result := c.Classify([]float32{1, 0.2}, labels)
+	if result.Label.Path != "A" {
+		t.Fatalf("got %q, want A", result.Label.Path)
+	}
+	sum := result.Confidence + result.Alternatives[0].Confidence
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("softmax confidences should sum to 1 over all labels, got %f", sum)
+	}
+	if result.Confidence < 0.99 {
+		t.Errorf("low temperature should sharpen confidence, got %f", result.Confidence)
+	}
+}
+
+func TestSoftmax(t *testing.T) {
+	probs := Softmax([]float64{1000, 1000}, 1)
+	if math.Abs(probs[0]-0.5) > 1e-9 || math.Abs(probs[1]-0.5) > 1e-9 {
+		t.Errorf("softmax of large equal scores = %v, want [0.5 0.5]", probs)
+	}
+	if Softmax(nil, 1) != nil {
+		t.Error("softmax of no scores should be nil")
+	}
+}
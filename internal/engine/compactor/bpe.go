@@ -0,0 +1,184 @@
+package compactor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// BPE encodings supported by LoadBPE.
+const (
+	CL100K = "cl100k" // GPT-4, GPT-3.5
+	O200K  = "o200k"  // GPT-4o and later
+)
+
+// Pre-tokenization patterns of the tiktoken encodings. Go's regexp has no
+// lookahead, so the trailing `\s+(?!\S)` alternative is applied by hand in
+// pieces.
+var bpePatterns = map[string]*regexp.Regexp{
+	CL100K: regexp.MustCompile(`^(?:(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+)`),
+	O200K: regexp.MustCompile(`^(?:[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|` +
+		`\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+)`),
+}
+
+// bpeCacheSize bounds the memoized piece counts; the cache is reset when
+// it fills.
+const bpeCacheSize = 1 << 16
+
+// BPE counts tokens with a byte-pair encoding vocabulary, as used by
+// OpenAI models. Safe for concurrent use.
+type BPE struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// LoadBPE reads a tiktoken vocabulary file (cl100k_base.tiktoken,
+// o200k_base.tiktoken: one base64 token and its rank per line) for
+// encoding CL100K or O200K.
+func LoadBPE(path, encoding string) (*BPE, error) {
+	if _, ok := bpePatterns[encoding]; !ok {
+		return nil, fmt.Errorf("bpe: unknown encoding %q (must be %s or %s)", encoding, CL100K, O200K)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("bpe: %w", err)
+	}
+	defer f.Close()
+
+	ranks := make(map[string]int, 200000)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		tok, rank, ok := bytes.Cut(line, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("bpe: %s:%d: want \"<base64 token> <rank>\"", path, n)
+		}
+		b, err := base64.StdEncoding.DecodeString(string(tok))
+		if err != nil {
+			return nil, fmt.Errorf("bpe: %s:%d: %w", path, n, err)
+		}
+		r, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, fmt.Errorf("bpe: %s:%d: %w", path, n, err)
+		}
+		ranks[string(b)] = r
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("bpe: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("bpe: %s: empty vocabulary", path)
+	}
+	return NewBPE(ranks, encoding)
+}
+
+// NewBPE creates a BPE counter from token ranks (lower ranks merge first)
+// for encoding CL100K or O200K.
+func NewBPE(ranks map[string]int, encoding string) (*BPE, error) {
+	pattern, ok := bpePatterns[encoding]
+	if !ok {
+		return nil, fmt.Errorf("bpe: unknown encoding %q (must be %s or %s)", encoding, CL100K, O200K)
+	}
+	return &BPE{ranks: ranks, pattern: pattern, cache: make(map[string]int)}, nil
+}
+
+// CountTokens returns the number of tokens s encodes to.
+func (b *BPE) CountTokens(s string) int {
+	n := 0
+	for _, piece := range b.pieces(s) {
+		n += b.countPiece(piece)
+	}
+	return n
+}
+
+// pieces splits s with the encoding's pre-tokenization pattern.
+func (b *BPE) pieces(s string) []string {
+	var out []string
+	for pos := 0; pos < len(s); {
+		loc := b.pattern.FindStringIndex(s[pos:])
+		if loc == nil || loc[1] == 0 {
+			// Not reachable with the tiktoken patterns; guard anyway.
+			_, size := utf8.DecodeRuneInString(s[pos:])
+			out = append(out, s[pos:pos+size])
+			pos += size
+			continue
+		}
+		end := pos + loc[1]
+		// `\s+(?!\S)`: a whitespace run followed by text leaves its last
+		// character to start the next piece.
+		if end < len(s) && isSpaceRun(s[pos:end]) {
+			if _, size := utf8.DecodeLastRuneInString(s[pos:end]); end-size > pos {
+				end -= size
+			}
+		}
+		out = append(out, s[pos:end])
+		pos = end
+	}
+	return out
+}
+
+// isSpaceRun reports whether s is whitespace without line breaks, the
+// pieces matched by the `\s+` alternatives (ASCII whitespace in Go).
+func isSpaceRun(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c != ' ' && c != '\t' && c != '\f' {
+			return false
+		}
+	}
+	return true
+}
+
+// countPiece returns the tokens of one piece after byte-pair merging.
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+	b.mu.Lock()
+	n, ok := b.cache[piece]
+	b.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	// Start from single bytes and repeatedly merge the adjacent pair with
+	// the lowest rank.
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if r, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && r < bestRank {
+				best, bestRank = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	n = len(bounds) - 1
+
+	b.mu.Lock()
+	if len(b.cache) >= bpeCacheSize {
+		b.cache = make(map[string]int)
+	}
+	b.cache[piece] = n
+	b.mu.Unlock()
+	return n
+}
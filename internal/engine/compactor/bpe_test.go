@@ -0,0 +1,103 @@
+package compactor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRanks is a tiny vocabulary: every single byte, then a few merges.
+func testRanks() map[string]int {
+	ranks := make(map[string]int)
+	for i := 0; i < 256; i++ {
+		ranks[string([]byte{byte(i)})] = i
+	}
+	for i, tok := range []string{"he", "ll", "hell", " w", "or", " wor", "ld"} {
+		ranks[tok] = 256 + i
+	}
+	return ranks
+}
+
+func TestBPEPieces(t *testing.T) {
+	b, err := NewBPE(testRanks(), CL100K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"hello  world\n", []string{"hello", " ", " world", "\n"}},
+		{"don't 12345", []string{"don", "'t", " ", "123", "45"}},
+		{"GET /api", []string{"GET", " /", "api"}},
+		{"a   ", []string{"a", "   "}},
+	}
+	for _, tt := range tests {
+		if got := b.pieces(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pieces(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBPECountTokens(t *testing.T) {
+	b, _ := NewBPE(testRanks(), CL100K)
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{"", 0},
+		{"hello", 2},        // hell + o
+		{" world", 2},       // " wor" + ld
+		{"hello world", 4},  // hell o " wor" ld
+		{"hello worlds", 5}, // ... + s
+	}
+	for _, tt := range tests {
+		if got := b.CountTokens(tt.in); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBPE(t *testing.T) {
+	var lines []string
+	for tok, rank := range testRanks() {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte(tok)), rank))
+	}
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadBPE(path, O200K)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.CountTokens("hello world"); got != 4 {
+		t.Errorf("CountTokens = %d, want 4", got)
+	}
+}
+
+func TestLoadBPEErrors(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.tiktoken")
+	os.WriteFile(bad, []byte("aGVsbG8=\n"), 0o644)
+	empty := filepath.Join(dir, "empty.tiktoken")
+	os.WriteFile(empty, nil, 0o644)
+
+	tests := []struct {
+		path, encoding, want string
+	}{
+		{bad, "p50k", "unknown encoding"},
+		{filepath.Join(dir, "missing"), CL100K, "no such file"},
+		{bad, CL100K, "bad.tiktoken:1"},
+		{empty, CL100K, "empty vocabulary"},
+	}
+	for _, tt := range tests {
+		if _, err := LoadBPE(tt.path, tt.encoding); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadBPE(%s, %s) error = %v, want %q", filepath.Base(tt.path), tt.encoding, err, tt.want)
+		}
+	}
+}
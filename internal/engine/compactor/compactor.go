@@ -2,10 +2,10 @@ package compactor
 
 import (
 	"encoding/json"
-	"fmt"
-	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/kaminocorp/lumber/internal/engine/stacktrace"
 )
 
 // Verbosity controls how much detail is retained after compaction.
@@ -33,10 +33,43 @@ func WithStripFields(fields []string) Option {
 	}
 }
 
+// WithPolicy sets a compaction policy that overrides the verbosity,
+// truncation and stack frame limits for the events it matches.
+func WithPolicy(p *Policy) Option {
+	return func(c *Compactor) {
+		c.Policy = p
+	}
+}
+
+// WithTokenCounter sets how tokens are counted for budgets and TokenStats.
+// Default: Heuristic.
+func WithTokenCounter(tc TokenCounter) Option {
+	return func(c *Compactor) {
+		c.Counter = tc
+	}
+}
+
+// WithTokenBudget compacts to token budgets instead of the fixed rune
+// limits of Minimal and Standard: each event's raw text is cut to at most
+// perEvent tokens, and the events of one batch (see FitBatch) to perBatch
+// tokens together. Zero disables either budget.
+func WithTokenBudget(perEvent, perBatch int) Option {
+	return func(c *Compactor) {
+		c.TokenBudget = perEvent
+		c.BatchBudget = perBatch
+	}
+}
+
 // Compactor performs token-aware compaction on log event fields.
 type Compactor struct {
 	Verbosity   Verbosity
 	StripFields []string
+	Policy      *Policy      // per-type/category/severity overrides; nil = Verbosity for all
+	Counter     TokenCounter // token counting for budgets and stats; nil = Heuristic
+	TokenBudget int          // max tokens of raw text per event below Full; 0 = rune limits
+	BatchBudget int          // max tokens of raw text per batch; 0 = none
+
+	tokens *tokenCounts
 }
 
 // New creates a Compactor with the given verbosity level.
@@ -44,6 +77,7 @@ func New(v Verbosity, opts ...Option) *Compactor {
 	c := &Compactor{
 		Verbosity:   v,
 		StripFields: defaultStripFields,
+		tokens:      new(tokenCounts),
 	}
 	for _, opt := range opts {
 		opt(c)
@@ -55,40 +89,71 @@ func New(v Verbosity, opts ...Option) *Compactor {
 // eventType is the classified event type (e.g. "ERROR") used for type-aware logic.
 // Returns the compacted text and a one-line summary.
 func (c *Compactor) Compact(raw, eventType string) (compacted string, summary string) {
-	result := raw
+	return c.CompactProfile(raw, c.Profile(eventType, "", ""))
+}
 
-	// Strip high-cardinality JSON fields at Minimal/Standard.
-	if c.Verbosity != Full {
+// Profile returns the compaction for an event: the first matching policy
+// rule applied over the verbosity's defaults, or the compactor's verbosity
+// when no rule matches.
+func (c *Compactor) Profile(eventType, category, severity string) Profile {
+	r, ok := c.Policy.match(eventType, category, severity)
+	if !ok {
+		return c.budgeted(defaultProfile(c.Verbosity, eventType))
+	}
+	v := c.Verbosity
+	if parsed, known := ParseVerbosity(r.Verbosity); known {
+		v = parsed
+	} else if r.Verbosity == Summary {
+		v = Minimal
+	}
+	p := c.budgeted(defaultProfile(v, eventType))
+	p.SummaryOnly = r.Verbosity == Summary
+	if r.MaxRaw > 0 {
+		p.MaxRaw = r.MaxRaw
+	}
+	if r.MaxFrames > 0 {
+		p.MaxFrames = r.MaxFrames
+	}
+	if r.MaxTokens > 0 {
+		p.MaxTokens = r.MaxTokens
+	}
+	return p
+}
+
+// budgeted replaces the rune limit of a Minimal or Standard profile with
+// the compactor's token budget, if any.
+func (c *Compactor) budgeted(p Profile) Profile {
+	if c.TokenBudget > 0 && p.Verbosity != Full {
+		p.MaxRaw = 0
+		p.MaxTokens = c.TokenBudget
+	}
+	return p
+}
+
+// CompactProfile compacts the raw log text with profile p. Returns the
+// compacted text ("" for summary-only profiles) and a one-line summary.
+func (c *Compactor) CompactProfile(raw string, p Profile) (compacted string, summary string) {
+	summary = summarize(raw)
+	if p.SummaryOnly {
+		return "", summary
+	}
+
+	result := raw
+	// Strip high-cardinality JSON fields below Full.
+	if p.Verbosity != Full {
 		result = stripFields(result, c.StripFields)
 	}
 
-	// Apply truncation.
-	switch c.Verbosity {
-	case Minimal:
-		if eventType == "ERROR" {
-			if t := truncateStackTrace(result, 5); t != result {
-				result = t
-			} else {
-				result = truncate(result, 200)
-			}
-		} else {
-			result = truncate(result, 200)
+	// Shorten stack traces; fall back to plain truncation when there is none.
+	if p.MaxFrames > 0 {
+		if t := truncateStackTrace(result, p.MaxFrames); t != result {
+			return fitTokens(t, p.MaxTokens, c.counter()), summary
 		}
-	case Standard:
-		if eventType == "ERROR" {
-			if t := truncateStackTrace(result, 10); t != result {
-				result = t
-			} else {
-				result = truncate(result, 2000)
-			}
-		} else {
-			result = truncate(result, 2000)
-		}
-	case Full:
-		// preserve everything
 	}
-
-	return result, summarize(raw)
+	if p.MaxRaw > 0 {
+		result = truncate(result, p.MaxRaw)
+	}
+	return fitTokens(result, p.MaxTokens, c.counter()), summary
 }
 
 // truncate cuts the string at maxRunes rune boundary, appending "..." if truncated.
@@ -139,47 +204,16 @@ func summarize(raw string) string {
 	return line[:cutByte] + "..."
 }
 
-// Stack trace frame patterns.
-var (
-	javaFrameRe = regexp.MustCompile(`^\s+at `)
-	goFrameRe   = regexp.MustCompile(`^\s+.+\.go:\d+`)
-	goRoutineRe = regexp.MustCompile(`^goroutine \d+`)
-)
-
-// truncateStackTrace detects stack trace patterns and preserves first maxFrames
-// and last 2 frames, replacing the middle with an omission message.
-// Uses range-based cut: everything between the last kept first-frame and the
-// first kept last-frame is replaced wholesale, including interleaved non-frame
-// lines (e.g. Go function signatures paired with source locations).
+// truncateStackTrace keeps the first maxFrames frames of a stack trace,
+// preferring app-owned frames over library frames, plus the last 2, and
+// replaces each run of dropped frames with an omission message. Text
+// without a recognized stack trace is returned unchanged.
 func truncateStackTrace(raw string, maxFrames int) string {
-	lines := strings.Split(raw, "\n")
-
-	// Find frame line indices.
-	var frames []int
-	for i, line := range lines {
-		if javaFrameRe.MatchString(line) || goFrameRe.MatchString(line) || goRoutineRe.MatchString(line) {
-			frames = append(frames, i)
-		}
-	}
-
-	// Not enough frames to truncate.
-	const tailFrames = 2
-	if len(frames) <= maxFrames+tailFrames {
+	t, ok := stacktrace.Parse(raw)
+	if !ok {
 		return raw
 	}
-
-	// Range cut: keep lines[0..lastKeptFirst] and lines[firstKeptLast..end].
-	lastKeptFirst := frames[maxFrames-1]
-	firstKeptLast := frames[len(frames)-tailFrames]
-	omitted := len(frames) - maxFrames - tailFrames
-	omissionMsg := fmt.Sprintf("\t... (%d frames omitted) ...", omitted)
-
-	var result []string
-	result = append(result, lines[:lastKeptFirst+1]...)
-	result = append(result, omissionMsg)
-	result = append(result, lines[firstKeptLast:]...)
-
-	return strings.Join(result, "\n")
+	return t.Truncate(maxFrames)
 }
 
 // stripFields removes high-cardinality keys from JSON-formatted log lines.
@@ -306,3 +306,15 @@ func TestCompactFull(t *testing.T) {
 		t.Fatalf("Full should preserve input unchanged, got %q", compacted)
 	}
 }
+
+func TestStackTracePython(t *testing.T) {
+	lines := []string{"Traceback (most recent call last):"}
+	for i := 0; i < 12; i++ {
+		lines = append(lines, `  File "/app/handlers.py", line 10, in handle`, "    return next_handler(req)")
+	}
+	lines = append(lines, "TimeoutError: upstream timed out")
+	result := truncateStackTrace(strings.Join(lines, "\n"), 5)
+	if !strings.Contains(result, "(5 frames omitted)") || !strings.HasSuffix(result, "TimeoutError: upstream timed out") {
+		t.Fatalf("expected truncated traceback ending in the exception, got:\n%s", result)
+	}
+}
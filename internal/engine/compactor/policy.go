@@ -0,0 +1,190 @@
+package compactor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Summary is the policy verbosity that keeps only the summary and extracted
+// fields of an event: Minimal formatting without raw text.
+const Summary = "summary"
+
+// String returns the verbosity name: minimal, standard or full.
+func (v Verbosity) String() string {
+	switch v {
+	case Minimal:
+		return "minimal"
+	case Full:
+		return "full"
+	default:
+		return "standard"
+	}
+}
+
+// ParseVerbosity maps minimal, standard or full to a Verbosity.
+func ParseVerbosity(s string) (Verbosity, bool) {
+	switch s {
+	case "minimal":
+		return Minimal, true
+	case "standard":
+		return Standard, true
+	case "full":
+		return Full, true
+	}
+	return Standard, false
+}
+
+// Rule chooses how events matching Type, Category and Severity are
+// compacted. Empty match fields match any event.
+type Rule struct {
+	Type     string `json:"type,omitempty" yaml:"type,omitempty"`         // e.g. "ERROR"
+	Category string `json:"category,omitempty" yaml:"category,omitempty"` // e.g. "success"
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"` // error, warning, info, debug
+
+	// Verbosity is minimal, standard, full or summary. Empty keeps the
+	// compactor's verbosity.
+	Verbosity string `json:"verbosity,omitempty" yaml:"verbosity,omitempty"`
+	// MaxRaw truncates the raw text to this many runes; 0 uses the
+	// verbosity's default (200 minimal, 2000 standard, none full).
+	MaxRaw int `json:"max_raw,omitempty" yaml:"max_raw,omitempty"`
+	// MaxFrames keeps this many leading stack frames; 0 uses the
+	// verbosity's default (5 minimal, 10 standard, all full; ERROR only).
+	MaxFrames int `json:"max_frames,omitempty" yaml:"max_frames,omitempty"`
+	// MaxTokens cuts the raw text to this many tokens, as counted by the
+	// compactor's TokenCounter; 0 uses the compactor's token budget.
+	MaxTokens int `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+}
+
+func (r Rule) matches(eventType, category, severity string) bool {
+	return (r.Type == "" || r.Type == eventType) &&
+		(r.Category == "" || r.Category == category) &&
+		(r.Severity == "" || r.Severity == severity)
+}
+
+// Policy is an ordered table of compaction rules. The first matching rule
+// wins; events no rule matches use the compactor's verbosity. A nil
+// *Policy matches nothing.
+type Policy struct {
+	rules []Rule
+}
+
+// policyFile is the on-disk policy format.
+type policyFile struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+var validSeverities = map[string]bool{"error": true, "warning": true, "info": true, "debug": true}
+
+// NewPolicy validates rules into a Policy, reporting every invalid rule.
+func NewPolicy(rules []Rule) (*Policy, error) {
+	var errs []string
+	for i, r := range rules {
+		if r.Severity != "" && !validSeverities[r.Severity] {
+			errs = append(errs, fmt.Sprintf("rule %d: invalid severity %q (must be error|warning|info|debug)", i, r.Severity))
+		}
+		if _, ok := ParseVerbosity(r.Verbosity); !ok && r.Verbosity != "" && r.Verbosity != Summary {
+			errs = append(errs, fmt.Sprintf("rule %d: invalid verbosity %q (must be minimal|standard|full|summary)", i, r.Verbosity))
+		}
+		if r.MaxRaw < 0 || r.MaxFrames < 0 || r.MaxTokens < 0 {
+			errs = append(errs, fmt.Sprintf("rule %d: max_raw, max_frames and max_tokens must be non-negative", i))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return &Policy{rules: rules}, nil
+}
+
+// LoadPolicy reads a policy file. The format is chosen by extension: .json,
+// or .yaml/.yml.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("compaction policy: %w", err)
+	}
+	var f policyFile
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&f)
+	default:
+		return nil, fmt.Errorf("compaction policy: %s: unsupported file extension %q (must be .json, .yaml or .yml)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("compaction policy: %s: parse: %w", path, err)
+	}
+	p, err := NewPolicy(f.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("compaction policy: %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Len returns the number of rules.
+func (p *Policy) Len() int {
+	if p == nil {
+		return 0
+	}
+	return len(p.rules)
+}
+
+func (p *Policy) match(eventType, category, severity string) (Rule, bool) {
+	if p == nil {
+		return Rule{}, false
+	}
+	for _, r := range p.rules {
+		if r.matches(eventType, category, severity) {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Profile is the compaction applied to one event.
+type Profile struct {
+	Verbosity   Verbosity
+	SummaryOnly bool // drop the raw text entirely
+	MaxRaw      int  // raw text truncation in runes; 0 keeps it whole
+	MaxFrames   int  // leading stack frames kept; 0 leaves stack traces alone
+	MaxTokens   int  // raw text truncation in tokens; 0 = no token limit
+}
+
+// Name returns the profile's verbosity name, or "summary".
+func (p Profile) Name() string {
+	if p.SummaryOnly {
+		return Summary
+	}
+	return p.Verbosity.String()
+}
+
+// defaultProfile is the compaction of verbosity v without a policy:
+// ERROR stack traces keep 5 (Minimal) or 10 (Standard) leading frames,
+// other text is cut at 200 or 2000 runes, and Full keeps everything.
+func defaultProfile(v Verbosity, eventType string) Profile {
+	p := Profile{Verbosity: v}
+	switch v {
+	case Minimal:
+		p.MaxRaw = 200
+		if eventType == "ERROR" {
+			p.MaxFrames = 5
+		}
+	case Standard:
+		p.MaxRaw = 2000
+		if eventType == "ERROR" {
+			p.MaxFrames = 10
+		}
+	}
+	return p
+}
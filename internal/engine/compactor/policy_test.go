@@ -0,0 +1,154 @@
+package compactor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func javaTrace(frames int) string {
+	lines := []string{"java.lang.IllegalStateException: boom"}
+	for i := 0; i < frames; i++ {
+		lines = append(lines, fmt.Sprintf("\tat com.example.Service.call%d(Service.java:%d)", i, i+1))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestProfile(t *testing.T) {
+	policy, err := NewPolicy([]Rule{
+		{Type: "ERROR", Verbosity: "full", MaxFrames: 20},
+		{Type: "REQUEST", Category: "success", Verbosity: "summary"},
+		{Severity: "debug", MaxRaw: 50},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := New(Standard, WithPolicy(policy))
+
+	tests := []struct {
+		typ, cat, sev string
+		want          Profile
+	}{
+		{"ERROR", "timeout", "error", Profile{Verbosity: Full, MaxFrames: 20}},
+		{"REQUEST", "success", "info", Profile{Verbosity: Minimal, SummaryOnly: true, MaxRaw: 200}},
+		{"REQUEST", "server_error", "error", Profile{Verbosity: Standard, MaxRaw: 2000}},
+		{"DEPLOY", "build_started", "debug", Profile{Verbosity: Standard, MaxRaw: 50}},
+	}
+	for _, tt := range tests {
+		if got := c.Profile(tt.typ, tt.cat, tt.sev); got != tt.want {
+			t.Errorf("Profile(%s.%s, %s) = %+v, want %+v", tt.typ, tt.cat, tt.sev, got, tt.want)
+		}
+	}
+
+	// Without a policy every event gets the verbosity defaults.
+	if got := New(Minimal).Profile("ERROR", "timeout", "error"); got != (Profile{Verbosity: Minimal, MaxRaw: 200, MaxFrames: 5}) {
+		t.Errorf("default Minimal ERROR profile = %+v", got)
+	}
+}
+
+func TestCompactProfile(t *testing.T) {
+	c := New(Standard)
+	trace := javaTrace(30)
+
+	out, summary := c.CompactProfile(trace, Profile{Verbosity: Full, MaxFrames: 20})
+	if !strings.Contains(out, "(8 frames omitted)") {
+		t.Errorf("expected 20+2 frames kept, got:\n%s", out)
+	}
+	if summary != "java.lang.IllegalStateException: boom" {
+		t.Errorf("summary = %q", summary)
+	}
+
+	out, summary = c.CompactProfile(`{"msg":"GET /health 200","trace_id":"abc"}`, Profile{Verbosity: Minimal, SummaryOnly: true})
+	if out != "" || summary == "" {
+		t.Errorf("summary-only profile = %q / %q, want no raw text and a summary", out, summary)
+	}
+
+	out, _ = c.CompactProfile(strings.Repeat("x", 100), Profile{Verbosity: Standard, MaxRaw: 50})
+	if len(out) != 53 {
+		t.Errorf("expected 50 runes + ..., got %d", len(out))
+	}
+}
+
+func TestNewPolicyErrors(t *testing.T) {
+	_, err := NewPolicy([]Rule{
+		{Severity: "fatal"},
+		{Verbosity: "verbose"},
+		{MaxRaw: -1},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"rule 0: invalid severity", "rule 1: invalid verbosity", "rule 2: max_raw"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"policy.json": `{"rules":[{"type":"ERROR","verbosity":"full"},{"type":"REQUEST","category":"success","verbosity":"summary"}]}`,
+		"policy.yaml": "rules:\n  - type: ERROR\n    verbosity: full\n  - type: REQUEST\n    category: success\n    verbosity: summary\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		p, err := LoadPolicy(path)
+		if err != nil {
+			t.Fatalf("LoadPolicy(%s): %v", name, err)
+		}
+		if p.Len() != 2 {
+			t.Errorf("%s: expected 2 rules, got %d", name, p.Len())
+		}
+		if got := New(Minimal, WithPolicy(p)).Profile("REQUEST", "success", "info"); !got.SummaryOnly {
+			t.Errorf("%s: REQUEST.success profile = %+v, want summary only", name, got)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"rules":[{"type":"ERROR","verbose":true}]}`), 0o644)
+	if _, err := LoadPolicy(bad); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Errorf("expected parse error for unknown field, got %v", err)
+	}
+	toml := filepath.Join(dir, "policy.toml")
+	os.WriteFile(toml, []byte(`[[rules]]`), 0o644)
+	if _, err := LoadPolicy(toml); err == nil || !strings.Contains(err.Error(), "unsupported file extension") {
+		t.Errorf("expected unsupported extension error, got %v", err)
+	}
+}
+
+func TestTokenBudget(t *testing.T) {
+	policy, _ := NewPolicy([]Rule{{Type: "ERROR", MaxTokens: 40}})
+	c := New(Standard, WithTokenCounter(runeCounter{}), WithTokenBudget(10, 0), WithPolicy(policy))
+
+	if got := c.Profile("REQUEST", "success", "info"); got != (Profile{Verbosity: Standard, MaxTokens: 10}) {
+		t.Errorf("budgeted profile = %+v", got)
+	}
+	if got := c.Profile("ERROR", "timeout", "error"); got != (Profile{Verbosity: Standard, MaxFrames: 10, MaxTokens: 40}) {
+		t.Errorf("max_tokens rule profile = %+v", got)
+	}
+	if got := New(Full, WithTokenBudget(10, 0)).Profile("REQUEST", "success", "info"); got.MaxTokens != 0 {
+		t.Errorf("Full verbosity should ignore the token budget: %+v", got)
+	}
+
+	out, _ := c.CompactProfile(strings.Repeat("x", 100), c.Profile("REQUEST", "success", "info"))
+	if out != "xxxxxxx..." {
+		t.Errorf("CompactProfile = %q, want 7 runes + ...", out)
+	}
+}
+
+func TestFitBatchBudget(t *testing.T) {
+	texts := []string{strings.Repeat("a", 30), strings.Repeat("b", 5)}
+	if got := New(Standard, WithTokenCounter(runeCounter{})).FitBatch(texts); got[0] != texts[0] {
+		t.Error("no batch budget should leave texts alone")
+	}
+	got := New(Standard, WithTokenCounter(runeCounter{}), WithTokenBudget(0, 20)).FitBatch(texts)
+	if len(got[0]) != 15 || got[1] != texts[1] {
+		t.Errorf("FitBatch = %q", got)
+	}
+}
@@ -3,8 +3,24 @@ package compactor
 import (
 	"math"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
+// TokenCounter counts the tokens a text costs a downstream model.
+// Implementations must be safe for concurrent use.
+type TokenCounter interface {
+	CountTokens(s string) int
+}
+
+// Heuristic is the default TokenCounter: EstimateTokens.
+type Heuristic struct{}
+
+// CountTokens returns EstimateTokens(s).
+func (Heuristic) CountTokens(s string) int {
+	return EstimateTokens(s)
+}
+
 // EstimateTokens returns an approximate token count using a whitespace heuristic.
 // Splits on whitespace, applies a 1.3x subword expansion factor (rounded up).
 // Not a real tokenizer — accurate within ~20% of BPE counts, sufficient for
@@ -16,3 +32,122 @@ func EstimateTokens(s string) int {
 	words := len(strings.Fields(s))
 	return int(math.Ceil(float64(words) * 1.3))
 }
+
+// fitTokens truncates s so that it, including the "..." suffix, costs at
+// most maxTokens. A maxTokens <= 0 leaves s alone.
+func fitTokens(s string, maxTokens int, counter TokenCounter) string {
+	if maxTokens <= 0 || counter.CountTokens(s) <= maxTokens {
+		return s
+	}
+	// Binary search the longest rune prefix that fits.
+	lo, hi := 0, utf8.RuneCountInString(s)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if counter.CountTokens(truncate(s, mid)) <= maxTokens {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	if lo == 0 {
+		return ""
+	}
+	return truncate(s, lo)
+}
+
+// fitBatch truncates the longest texts so that all of them together cost
+// at most budget: every text is cut to the same token cap, the largest
+// that fits, and texts under the cap are left whole.
+func fitBatch(texts []string, budget int, counter TokenCounter) []string {
+	counts := make([]int, len(texts))
+	total, largest := 0, 0
+	for i, t := range texts {
+		counts[i] = counter.CountTokens(t)
+		total += counts[i]
+		largest = max(largest, counts[i])
+	}
+	if budget <= 0 || total <= budget {
+		return texts
+	}
+	// Binary search the largest per-text cap that fits the budget.
+	lo, hi := 0, largest
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		sum := 0
+		for _, n := range counts {
+			sum += min(n, mid)
+		}
+		if sum <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	out := make([]string, len(texts))
+	for i, t := range texts {
+		switch {
+		case counts[i] <= lo:
+			out[i] = t
+		case lo == 0:
+			out[i] = ""
+		default:
+			out[i] = fitTokens(t, lo, counter)
+		}
+	}
+	return out
+}
+
+// TokenStats reports the tokens of the raw logs processed and of the text
+// kept for their events, as counted by the compactor's TokenCounter.
+type TokenStats struct {
+	Raw       int64
+	Compacted int64
+}
+
+// Reduction returns the share of raw tokens removed by compaction, 0-1.
+func (s TokenStats) Reduction() float64 {
+	if s.Raw == 0 {
+		return 0
+	}
+	return 1 - float64(s.Compacted)/float64(s.Raw)
+}
+
+// tokenCounts accumulates TokenStats.
+type tokenCounts struct {
+	raw, compacted atomic.Int64
+}
+
+// Record adds the tokens of a raw log and of the text kept for its event
+// to the compactor's TokenStats.
+func (c *Compactor) Record(raw, kept string) {
+	if c == nil || c.tokens == nil {
+		return
+	}
+	counter := c.counter()
+	c.tokens.raw.Add(int64(counter.CountTokens(raw)))
+	c.tokens.compacted.Add(int64(counter.CountTokens(kept)))
+}
+
+// TokenStats reports the tokens recorded so far.
+func (c *Compactor) TokenStats() TokenStats {
+	if c == nil || c.tokens == nil {
+		return TokenStats{}
+	}
+	return TokenStats{Raw: c.tokens.raw.Load(), Compacted: c.tokens.compacted.Load()}
+}
+
+// FitBatch truncates texts to the compactor's batch token budget (see
+// fitBatch). Returns texts unchanged when no batch budget is set.
+func (c *Compactor) FitBatch(texts []string) []string {
+	if c == nil || c.BatchBudget <= 0 {
+		return texts
+	}
+	return fitBatch(texts, c.BatchBudget, c.counter())
+}
+
+func (c *Compactor) counter() TokenCounter {
+	if c.Counter == nil {
+		return Heuristic{}
+	}
+	return c.Counter
+}
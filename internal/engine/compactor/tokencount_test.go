@@ -45,3 +45,49 @@ func TestEstimateTokensWhitespaceOnly(t *testing.T) {
 		t.Fatalf("expected 0 for whitespace-only, got %d", n)
 	}
 }
+
+func TestFitTokens(t *testing.T) {
+	s := "a b c d e f g h i j" // 13 tokens
+	if got := fitTokens(s, 7, Heuristic{}); got != "a b c d e..." {
+		t.Errorf("fitTokens(7) = %q", got)
+	}
+	if got := fitTokens(s, 13, Heuristic{}); got != s {
+		t.Errorf("text within budget changed: %q", got)
+	}
+	if got := fitTokens(s, 0, Heuristic{}); got != s {
+		t.Errorf("zero budget should leave text alone: %q", got)
+	}
+	if got := fitTokens("alpha beta", 1, Heuristic{}); got != "" {
+		t.Errorf("expected nothing to fit, got %q", got)
+	}
+}
+
+func TestFitBatch(t *testing.T) {
+	texts := []string{"a b c d e f g h i j", "x", "y z"} // 13 + 2 + 3 tokens
+	got := fitBatch(texts, 10, Heuristic{})
+	want := []string{"a b c...", "x", "y z"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("fitBatch = %q, want %q", got, want)
+	}
+	if got := fitBatch(texts, 18, Heuristic{}); &got[0] != &texts[0] {
+		t.Error("batch within budget should be returned as is")
+	}
+}
+
+// runeCounter counts one token per rune.
+type runeCounter struct{}
+
+func (runeCounter) CountTokens(s string) int { return len([]rune(s)) }
+
+func TestTokenStats(t *testing.T) {
+	c := New(Standard, WithTokenCounter(runeCounter{}))
+	c.Record("0123456789", "0123")
+	c.Record("abcdefghij", "")
+	got := c.TokenStats()
+	if got.Raw != 20 || got.Compacted != 4 || got.Reduction() != 0.8 {
+		t.Errorf("TokenStats = %+v (reduction %.2f)", got, got.Reduction())
+	}
+	if (TokenStats{}).Reduction() != 0 {
+		t.Error("empty stats should report no reduction")
+	}
+}
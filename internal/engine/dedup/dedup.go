@@ -2,84 +2,239 @@ package dedup
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/kaminocorp/lumber/internal/engine/classifier"
 	"github.com/kaminocorp/lumber/internal/model"
 )
 
+// Default limits on what a merged event lists.
+const (
+	DefaultMaxVariants = 5
+	DefaultMaxSamples  = 3
+)
+
 // Config controls deduplication behavior.
 type Config struct {
 	Window time.Duration // grouping window (default 5s)
+
+	// Keys are the event properties that must match for events to merge
+	// (see ValidateKey). Default: type, category.
+	Keys []string
+	// Similarity, when > 0, additionally requires the embeddings of merged
+	// events to have at least this cosine similarity, so "db timeout" and
+	// "Stripe timeout" stay apart. Events without an embedding (rule hits)
+	// merge on Keys alone.
+	Similarity float64
+
+	MaxVariants int // distinct variants listed on a merged event (default 5)
+	MaxSamples  int // distinct raw samples listed on a merged event (default 3)
+
+	// Rollup is how often a Tracker reports a group that keeps occurring
+	// (default 1m). ResolveAfter is how long a group must go quiet before
+	// the Tracker resolves it (default 2m). Batch dedup ignores both.
+	Rollup       time.Duration
+	ResolveAfter time.Duration
 }
 
-// Deduplicator collapses identical event types within a time window.
+// Deduplicator collapses events with the same key within a time window.
 type Deduplicator struct {
 	cfg Config
 }
 
 // New creates a Deduplicator with the given config.
 func New(cfg Config) *Deduplicator {
+	if len(cfg.Keys) == 0 {
+		cfg.Keys = []string{"type", "category"}
+	}
+	if cfg.MaxVariants <= 0 {
+		cfg.MaxVariants = DefaultMaxVariants
+	}
+	if cfg.MaxSamples <= 0 {
+		cfg.MaxSamples = DefaultMaxSamples
+	}
+	if cfg.Rollup <= 0 {
+		cfg.Rollup = time.Minute
+	}
+	if cfg.ResolveAfter <= 0 {
+		cfg.ResolveAfter = 2 * time.Minute
+	}
 	return &Deduplicator{cfg: cfg}
 }
 
+// ValidateKey reports whether key is a supported dedup key: type,
+// category, source, severity, template, field.<name> (an extracted field)
+// or attr.<name> (a connector attribute).
+func ValidateKey(key string) error {
+	switch key {
+	case "type", "category", "source", "severity", "template":
+		return nil
+	}
+	for _, prefix := range []string{"field.", "attr."} {
+		if name, ok := strings.CutPrefix(key, prefix); ok && name != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown dedup key %q (must be type, category, source, severity, template, field.<name> or attr.<name>)", key)
+}
+
+// key returns the dedup key of e.
+func (d *Deduplicator) key(e model.CanonicalEvent) string {
+	parts := make([]string, len(d.cfg.Keys))
+	for i, k := range d.cfg.Keys {
+		switch {
+		case k == "type":
+			parts[i] = e.Type
+		case k == "category":
+			parts[i] = e.Category
+		case k == "source":
+			parts[i] = e.Source
+		case k == "severity":
+			parts[i] = e.Severity
+		case k == "template":
+			parts[i] = e.TemplateID
+		case strings.HasPrefix(k, "field."):
+			parts[i] = valueString(e.Fields, k[len("field."):])
+		case strings.HasPrefix(k, "attr."):
+			parts[i] = valueString(e.Attributes, k[len("attr."):])
+		}
+	}
+	return strings.Join(parts, "\x00")
+}
+
+func valueString(m map[string]any, name string) string {
+	v, ok := m[name]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
 // group accumulates events with the same dedup key.
 type group struct {
 	event    model.CanonicalEvent
 	count    int
 	firstTS  time.Time
 	latestTS time.Time
+	variants []string
+	samples  []string
+}
+
+func newGroup(e model.CanonicalEvent, cfg Config) *group {
+	g := &group{event: e, firstTS: e.Timestamp, latestTS: e.Timestamp}
+	g.add(e, cfg)
+	return g
 }
 
-// DeduplicateBatch collapses events with identical Type+Category within Window
-// of each other. Returns events in first-occurrence order.
-// Sets Count on merged events and rewrites Summary to include count.
+// add merges e into g, recording its variant and raw text when new.
+func (g *group) add(e model.CanonicalEvent, cfg Config) {
+	g.count++
+	if e.Timestamp.After(g.latestTS) {
+		g.latestTS = e.Timestamp
+	}
+
+	variant := e.Template
+	if variant == "" {
+		variant = e.Summary
+	}
+	g.variants = appendDistinct(g.variants, variant, cfg.MaxVariants)
+	g.samples = appendDistinct(g.samples, e.Raw, cfg.MaxSamples)
+}
+
+// merged returns the group's first event annotated with the merge: Count,
+// a Summary with the count, and the Variants and Samples when they differ.
+// A group of one is returned unchanged.
+func (g *group) merged() model.CanonicalEvent {
+	e := g.event
+	if g.count > 1 {
+		e.Count = g.count
+		dur := g.latestTS.Sub(g.firstTS)
+		e.Summary = fmt.Sprintf("%s (x%d in %s)", e.Summary, e.Count, formatDuration(dur))
+		if len(g.variants) > 1 {
+			e.Variants = g.variants
+		}
+		if len(g.samples) > 1 {
+			e.Samples = g.samples
+		}
+	}
+	return e
+}
+
+func appendDistinct(list []string, s string, max int) []string {
+	if s == "" || len(list) >= max {
+		return list
+	}
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+// matches reports whether e may join g: within the window and, in
+// similarity mode, close enough to the group's first event.
+func (d *Deduplicator) matches(g *group, e model.CanonicalEvent, window time.Duration) bool {
+	if e.Timestamp.Sub(g.firstTS) > window {
+		return false
+	}
+	return d.similar(g, e)
+}
+
+// similar reports whether e is close enough to g's first event in
+// similarity mode. Always true otherwise.
+func (d *Deduplicator) similar(g *group, e model.CanonicalEvent) bool {
+	if d.cfg.Similarity <= 0 || g.event.Vector == nil || e.Vector == nil {
+		return true
+	}
+	return classifier.CosineSimilarity(g.event.Vector, e.Vector) >= d.cfg.Similarity
+}
+
+// DeduplicateBatch collapses events with the same key (Type+Category by
+// default) within Window of each other. Returns events in first-occurrence
+// order. Sets Count on merged events, rewrites Summary to include the
+// count, and lists distinct Variants (templates or summaries) and raw
+// Samples when the merged events differ.
 func (d *Deduplicator) DeduplicateBatch(events []model.CanonicalEvent) []model.CanonicalEvent {
+	return d.DeduplicateWindow(events, d.cfg.Window)
+}
+
+// DeduplicateWindow is DeduplicateBatch with a window other than the
+// configured one, for buffers that hold some events longer than others.
+func (d *Deduplicator) DeduplicateWindow(events []model.CanonicalEvent, window time.Duration) []model.CanonicalEvent {
 	if len(events) == 0 {
 		return nil
 	}
 
-	// Ordered map: preserve first-occurrence order.
-	type groupEntry struct {
-		key string
-		grp *group
-	}
-	var order []groupEntry
-	groups := make(map[string]*groupEntry)
+	// Groups in first-occurrence order, and the open groups for each key.
+	var order []*group
+	open := make(map[string][]*group)
 
 	for _, e := range events {
-		key := e.Type + "." + e.Category
-
-		entry, exists := groups[key]
-		if exists && e.Timestamp.Sub(entry.grp.firstTS) <= d.cfg.Window {
-			// Within window — merge.
-			entry.grp.count++
-			if e.Timestamp.After(entry.grp.latestTS) {
-				entry.grp.latestTS = e.Timestamp
+		key := d.key(e)
+
+		var target *group
+		for _, g := range open[key] {
+			if d.matches(g, e, window) {
+				target = g
+				break
 			}
+		}
+		if target != nil {
+			target.add(e, d.cfg)
 			continue
 		}
 
-		// New group: either new key or outside window.
-		g := &group{
-			event:    e,
-			count:    1,
-			firstTS:  e.Timestamp,
-			latestTS: e.Timestamp,
-		}
-		ge := &groupEntry{key: key, grp: g}
-		groups[key] = ge
-		order = append(order, *ge)
+		// New group: new key, outside the window, or not similar enough.
+		g := newGroup(e, d.cfg)
+		open[key] = append(open[key], g)
+		order = append(order, g)
 	}
 
 	result := make([]model.CanonicalEvent, 0, len(order))
-	for _, entry := range order {
-		e := entry.grp.event
-		if entry.grp.count > 1 {
-			e.Count = entry.grp.count
-			dur := entry.grp.latestTS.Sub(entry.grp.firstTS)
-			e.Summary = fmt.Sprintf("%s (x%d in %s)", e.Summary, e.Count, formatDuration(dur))
-		}
-		result = append(result, e)
+	for _, g := range order {
+		result = append(result, g.merged())
 	}
 	return result
 }
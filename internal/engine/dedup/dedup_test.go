@@ -1,6 +1,7 @@
 package dedup
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -145,3 +146,106 @@ func TestDeduplicateBatchPreservesTimestamp(t *testing.T) {
 		t.Fatalf("expected first timestamp %v, got %v", t0, result[0].Timestamp)
 	}
 }
+
+func TestDeduplicateBatchKeys(t *testing.T) {
+	db := event("ERROR", "timeout", 0)
+	db.Source, db.TemplateID, db.Fields = "api", "aaaa", map[string]any{"host": "db.internal"}
+	stripe := event("ERROR", "timeout", time.Second)
+	stripe.Source, stripe.TemplateID, stripe.Fields = "api", "bbbb", map[string]any{"host": "api.stripe.com"}
+	db2 := db
+	db2.Timestamp = t0.Add(2 * time.Second)
+	events := []model.CanonicalEvent{db, stripe, db2}
+
+	tests := []struct {
+		keys []string
+		want []int // counts per resulting group
+	}{
+		{nil, []int{3}},
+		{[]string{"type", "category", "source"}, []int{3}},
+		{[]string{"type", "category", "template"}, []int{2, 0}},
+		{[]string{"type", "category", "field.host"}, []int{2, 0}},
+		{[]string{"type", "category", "attr.region"}, []int{3}},
+	}
+	for _, tt := range tests {
+		result := New(Config{Window: 5 * time.Second, Keys: tt.keys}).DeduplicateBatch(events)
+		if len(result) != len(tt.want) {
+			t.Errorf("keys %v: expected %d groups, got %d", tt.keys, len(tt.want), len(result))
+			continue
+		}
+		for i, c := range tt.want {
+			if result[i].Count != c {
+				t.Errorf("keys %v: group %d count = %d, want %d", tt.keys, i, result[i].Count, c)
+			}
+		}
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, k := range []string{"type", "category", "source", "severity", "template", "field.status", "attr.region"} {
+		if err := ValidateKey(k); err != nil {
+			t.Errorf("ValidateKey(%q) = %v", k, err)
+		}
+	}
+	for _, k := range []string{"", "summary", "field.", "attr"} {
+		if err := ValidateKey(k); err == nil {
+			t.Errorf("ValidateKey(%q) = nil, want error", k)
+		}
+	}
+}
+
+func TestDeduplicateBatchSimilarity(t *testing.T) {
+	withVec := func(summary string, vec []float32, offset time.Duration) model.CanonicalEvent {
+		e := event("ERROR", "timeout", offset)
+		e.Summary, e.Raw, e.Vector = summary, summary, vec
+		return e
+	}
+	events := []model.CanonicalEvent{
+		withVec("db query timeout after 30s", []float32{1, 0, 0}, 0),
+		withVec("stripe request timeout", []float32{0, 1, 0}, time.Second),
+		withVec("db query timeout after 31s", []float32{0.95, 0.05, 0}, 2*time.Second),
+		withVec("rule hit", nil, 3*time.Second),
+	}
+
+	result := New(Config{Window: 5 * time.Second, Similarity: 0.9}).DeduplicateBatch(events)
+	if len(result) != 2 {
+		t.Fatalf("expected 2 groups, got %d: %+v", len(result), result)
+	}
+	if result[0].Count != 3 || !strings.HasPrefix(result[0].Summary, "db query timeout") {
+		t.Errorf("result[0] = %q x%d, want db timeouts (plus the vector-less event) x3", result[0].Summary, result[0].Count)
+	}
+	if result[1].Count != 0 || result[1].Summary != "stripe request timeout" {
+		t.Errorf("result[1] = %q x%d, want the unmerged stripe timeout", result[1].Summary, result[1].Count)
+	}
+
+	// Without similarity everything with the same key merges.
+	if result := New(Config{Window: 5 * time.Second}).DeduplicateBatch(events); len(result) != 1 {
+		t.Errorf("expected 1 group without similarity, got %d", len(result))
+	}
+}
+
+func TestDeduplicateBatchVariantsAndSamples(t *testing.T) {
+	var events []model.CanonicalEvent
+	for i, tmpl := range []string{"timeout calling <*>", "timeout calling <*>", "deadline exceeded on <*>", "timeout calling <*>"} {
+		e := event("ERROR", "timeout", time.Duration(i)*time.Second)
+		e.Template = tmpl
+		e.Raw = fmt.Sprintf("raw %d", i)
+		events = append(events, e)
+	}
+
+	result := New(Config{Window: 5 * time.Second, MaxSamples: 2}).DeduplicateBatch(events)
+	if len(result) != 1 || result[0].Count != 4 {
+		t.Fatalf("expected 1 group x4, got %+v", result)
+	}
+	if fmt.Sprint(result[0].Variants) != "[timeout calling <*> deadline exceeded on <*>]" {
+		t.Errorf("Variants = %q", result[0].Variants)
+	}
+	if fmt.Sprint(result[0].Samples) != "[raw 0 raw 1]" {
+		t.Errorf("Samples = %q, want the first 2 raws", result[0].Samples)
+	}
+
+	// Identical merged events list no variants or samples.
+	same := []model.CanonicalEvent{event("ERROR", "timeout", 0), event("ERROR", "timeout", time.Second)}
+	if r := New(Config{Window: 5 * time.Second}).DeduplicateBatch(same); r[0].Variants != nil || r[0].Samples != nil {
+		t.Errorf("expected no variants/samples for identical events, got %q / %q", r[0].Variants, r[0].Samples)
+	}
+}
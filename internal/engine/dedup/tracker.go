@@ -0,0 +1,167 @@
+package dedup
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Tracker is a stateful deduplicator for streams. Unlike DeduplicateBatch,
+// which only sees one buffer at a time, it keeps groups open across
+// flushes: the first occurrence of a group is emitted immediately, further
+// occurrences are reported as rollups every Config.Rollup, and a final
+// resolved event is emitted once the group has been quiet for
+// Config.ResolveAfter. Events carry a model.Rollup with the group's state.
+// Safe for concurrent use.
+type Tracker struct {
+	d *Deduplicator
+
+	mu     sync.Mutex
+	groups []*tracked            // open groups, first-occurrence order
+	open   map[string][]*tracked // open groups by dedup key
+}
+
+// tracked is an open group and its reporting state. Scheduling uses the
+// wall-clock times passed to Add and Tick; first/last seen use the event
+// timestamps.
+type tracked struct {
+	*group
+	key      string
+	id       string
+	reported int       // count at the last emitted event
+	seenAt   time.Time // when the group last occurred
+	emitAt   time.Time // when the group was last reported
+}
+
+// NewTracker creates a Tracker that groups events with d's keys and
+// similarity cutoff. d's Window is not used.
+func NewTracker(d *Deduplicator) *Tracker {
+	return &Tracker{d: d, open: make(map[string][]*tracked)}
+}
+
+// Add records e, seen at now. When e starts a new group it is returned,
+// marked as the group's first occurrence, to be emitted right away;
+// otherwise it is merged into its open group and ok is false.
+func (t *Tracker) Add(e model.CanonicalEvent, now time.Time) (first model.CanonicalEvent, ok bool) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = now
+	}
+	key := t.d.key(e)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, g := range t.open[key] {
+		if t.d.similar(g.group, e) {
+			g.add(e, t.d.cfg)
+			g.seenAt = now
+			return model.CanonicalEvent{}, false
+		}
+	}
+
+	g := &tracked{group: newGroup(e, t.d.cfg), key: key, seenAt: now, emitAt: now}
+	g.id = groupID(key, e.Timestamp)
+	t.open[key] = append(t.open[key], g)
+	t.groups = append(t.groups, g)
+	return g.emit(model.RollupFirst), true
+}
+
+// Tick returns the events due at now: an ongoing rollup for each group that
+// occurred again since it was last reported at least Rollup ago, and a
+// resolved event for each group quiet for ResolveAfter. Quiet groups that
+// only ever occurred once are closed without a resolved event.
+func (t *Tracker) Tick(now time.Time) []model.CanonicalEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []model.CanonicalEvent
+	kept := t.groups[:0]
+	for _, g := range t.groups {
+		switch {
+		case now.Sub(g.seenAt) >= t.d.cfg.ResolveAfter:
+			if g.count > 1 {
+				out = append(out, g.emit(model.RollupResolved))
+			}
+			t.remove(g)
+			continue
+		case g.count > g.reported && now.Sub(g.emitAt) >= t.d.cfg.Rollup:
+			out = append(out, g.emit(model.RollupOngoing))
+			g.emitAt = now
+		}
+		kept = append(kept, g)
+	}
+	clear(t.groups[len(kept):])
+	t.groups = kept
+	return out
+}
+
+// Close resolves every open group that occurred more than once and returns
+// the resolved events. The Tracker is empty afterwards.
+func (t *Tracker) Close() []model.CanonicalEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var out []model.CanonicalEvent
+	for _, g := range t.groups {
+		if g.count > 1 {
+			out = append(out, g.emit(model.RollupResolved))
+		}
+	}
+	t.groups = nil
+	t.open = make(map[string][]*tracked)
+	return out
+}
+
+// Len returns the number of open groups.
+func (t *Tracker) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.groups)
+}
+
+// remove drops g from the key index. Caller holds t.mu.
+func (t *Tracker) remove(g *tracked) {
+	list := t.open[g.key]
+	for i, other := range list {
+		if other == g {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(t.open, g.key)
+	} else {
+		t.open[g.key] = list
+	}
+}
+
+// emit returns the group's merged event in the given state and marks the
+// occurrences so far as reported.
+func (g *tracked) emit(state string) model.CanonicalEvent {
+	e := g.merged()
+	r := &model.Rollup{
+		GroupID:   g.id,
+		State:     state,
+		Count:     g.count,
+		New:       g.count - g.reported,
+		FirstSeen: g.firstTS,
+		LastSeen:  g.latestTS,
+	}
+	if elapsed := g.latestTS.Sub(g.firstTS); elapsed > 0 {
+		r.RatePerMin = float64(g.count) / elapsed.Minutes()
+	}
+	e.Rollup = r
+	g.reported = g.count
+	return e
+}
+
+// groupID identifies a group by its key and first occurrence.
+func groupID(key string, first time.Time) string {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h.Write([]byte(first.UTC().Format(time.RFC3339Nano)))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
@@ -0,0 +1,116 @@
+package dedup
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func TestTrackerLifecycle(t *testing.T) {
+	tr := NewTracker(New(Config{Rollup: time.Minute, ResolveAfter: 2 * time.Minute}))
+	now := t0
+
+	first, ok := tr.Add(event("ERROR", "timeout", 0), now)
+	if !ok || first.Rollup == nil || first.Rollup.State != model.RollupFirst || first.Rollup.Count != 1 {
+		t.Fatalf("expected first occurrence, got ok=%v %+v", ok, first.Rollup)
+	}
+	if first.Count != 0 || first.Summary != "ERROR.timeout" {
+		t.Errorf("first occurrence should be unmerged, got count=%d summary=%q", first.Count, first.Summary)
+	}
+
+	// Repeats are absorbed until the rollup is due.
+	for i := 1; i <= 5; i++ {
+		if _, ok := tr.Add(event("ERROR", "timeout", time.Duration(i)*10*time.Second), now.Add(time.Duration(i)*10*time.Second)); ok {
+			t.Fatalf("repeat %d started a new group", i)
+		}
+	}
+	if out := tr.Tick(now.Add(30 * time.Second)); len(out) != 0 {
+		t.Fatalf("expected no rollup before the cadence, got %d", len(out))
+	}
+
+	out := tr.Tick(now.Add(time.Minute))
+	if len(out) != 1 {
+		t.Fatalf("expected 1 rollup, got %d", len(out))
+	}
+	r := out[0].Rollup
+	if r.State != model.RollupOngoing || r.Count != 6 || r.New != 5 || r.GroupID != first.Rollup.GroupID {
+		t.Errorf("unexpected rollup: %+v", r)
+	}
+	if !r.FirstSeen.Equal(t0) || !r.LastSeen.Equal(t0.Add(50*time.Second)) || math.Abs(r.RatePerMin-7.2) > 1e-9 {
+		t.Errorf("unexpected first/last seen or rate: %+v", r)
+	}
+	if out[0].Count != 6 {
+		t.Errorf("Count = %d, want 6", out[0].Count)
+	}
+
+	// No new occurrences: no further rollups, then resolved once quiet.
+	if out := tr.Tick(now.Add(2 * time.Minute)); len(out) != 0 {
+		t.Errorf("expected no rollup without new occurrences, got %+v", out)
+	}
+	out = tr.Tick(now.Add(50*time.Second + 2*time.Minute))
+	if len(out) != 1 || out[0].Rollup.State != model.RollupResolved || out[0].Rollup.New != 0 || out[0].Rollup.Count != 6 {
+		t.Fatalf("expected resolved event, got %+v", out)
+	}
+	if tr.Len() != 0 {
+		t.Errorf("expected no open groups, got %d", tr.Len())
+	}
+
+	// The next occurrence opens a new group.
+	again, ok := tr.Add(event("ERROR", "timeout", 10*time.Minute), now.Add(10*time.Minute))
+	if !ok || again.Rollup.GroupID == first.Rollup.GroupID {
+		t.Errorf("expected a new group, got ok=%v %+v", ok, again.Rollup)
+	}
+}
+
+func TestTrackerSingleOccurrenceClosesQuietly(t *testing.T) {
+	tr := NewTracker(New(Config{ResolveAfter: time.Minute}))
+	tr.Add(event("ERROR", "timeout", 0), t0)
+	if out := tr.Tick(t0.Add(time.Minute)); len(out) != 0 {
+		t.Errorf("expected no resolved event for a single occurrence, got %+v", out)
+	}
+	if tr.Len() != 0 {
+		t.Errorf("expected the group to close, got %d open", tr.Len())
+	}
+}
+
+func TestTrackerKeysAndClose(t *testing.T) {
+	tr := NewTracker(New(Config{}))
+	for i, cat := range []string{"timeout", "connection_failure", "timeout", "connection_failure", "timeout"} {
+		tr.Add(event("ERROR", cat, time.Duration(i)*time.Second), t0.Add(time.Duration(i)*time.Second))
+	}
+	tr.Add(event("REQUEST", "success", 0), t0)
+	if tr.Len() != 3 {
+		t.Fatalf("expected 3 open groups, got %d", tr.Len())
+	}
+
+	out := tr.Close()
+	if len(out) != 2 {
+		t.Fatalf("expected 2 resolved groups, got %d", len(out))
+	}
+	if out[0].Category != "timeout" || out[0].Rollup.Count != 3 || out[1].Category != "connection_failure" || out[1].Rollup.Count != 2 {
+		t.Errorf("unexpected resolved events: %+v / %+v", out[0].Rollup, out[1].Rollup)
+	}
+	if tr.Len() != 0 {
+		t.Errorf("expected no open groups after Close, got %d", tr.Len())
+	}
+}
+
+func TestTrackerSimilarity(t *testing.T) {
+	tr := NewTracker(New(Config{Similarity: 0.9}))
+	db := event("ERROR", "timeout", 0)
+	db.Vector = []float32{1, 0}
+	stripe := event("ERROR", "timeout", time.Second)
+	stripe.Vector = []float32{0, 1}
+
+	if _, ok := tr.Add(db, t0); !ok {
+		t.Fatal("expected first group")
+	}
+	if _, ok := tr.Add(stripe, t0); !ok {
+		t.Error("dissimilar event joined the open group")
+	}
+	if _, ok := tr.Add(db, t0); ok {
+		t.Error("similar event started a new group")
+	}
+}
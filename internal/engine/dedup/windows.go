@@ -0,0 +1,66 @@
+package dedup
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/engine/severity"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Windows overrides the dedup window per event type (ERROR, REQUEST, ...)
+// or per severity (error, warning, info, debug). A zero window writes the
+// event without waiting.
+type Windows map[string]time.Duration
+
+var typeNameRe = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+
+// ParseWindows parses "key=duration" entries such as "error=0",
+// "info=10s" or "REQUEST=30s". Keys are severities (lower-case) or event
+// types (upper-case).
+func ParseWindows(entries []string) (Windows, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	w := make(Windows, len(entries))
+	for _, entry := range entries {
+		key, value, ok := strings.Cut(entry, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" || value == "" {
+			return nil, fmt.Errorf("invalid dedup window %q (want key=duration)", entry)
+		}
+		switch key {
+		case severity.Error, severity.Warning, severity.Info, severity.Debug:
+		default:
+			if !typeNameRe.MatchString(key) {
+				return nil, fmt.Errorf("invalid dedup window key %q (must be a severity or an upper-case event type)", key)
+			}
+		}
+		d := time.Duration(0)
+		if value != "0" {
+			var err error
+			if d, err = time.ParseDuration(value); err != nil {
+				return nil, fmt.Errorf("invalid dedup window %q: %w", entry, err)
+			}
+		}
+		if d < 0 {
+			return nil, fmt.Errorf("invalid dedup window %q: must be non-negative", entry)
+		}
+		w[key] = d
+	}
+	return w, nil
+}
+
+// For returns the window for e: its type's override, else its severity's,
+// else fallback.
+func (w Windows) For(e model.CanonicalEvent, fallback time.Duration) time.Duration {
+	if d, ok := w[e.Type]; ok {
+		return d
+	}
+	if d, ok := w[e.Severity]; ok {
+		return d
+	}
+	return fallback
+}
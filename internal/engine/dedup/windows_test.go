@@ -0,0 +1,48 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func TestParseWindows(t *testing.T) {
+	w, err := ParseWindows([]string{"error=0", " info = 10s", "REQUEST=30s"})
+	if err != nil {
+		t.Fatalf("ParseWindows: %v", err)
+	}
+	if len(w) != 3 || w["error"] != 0 || w["info"] != 10*time.Second || w["REQUEST"] != 30*time.Second {
+		t.Errorf("unexpected windows: %v", w)
+	}
+
+	if w, err := ParseWindows(nil); w != nil || err != nil {
+		t.Errorf("ParseWindows(nil) = %v, %v", w, err)
+	}
+	for _, bad := range []string{"error", "=5s", "fatal=1s", "Request=1s", "info=soon", "info=-1s"} {
+		if _, err := ParseWindows([]string{bad}); err == nil {
+			t.Errorf("ParseWindows(%q) = nil error", bad)
+		}
+	}
+}
+
+func TestWindowsFor(t *testing.T) {
+	w := Windows{"error": 0, "info": 10 * time.Second, "REQUEST": 30 * time.Second}
+	tests := []struct {
+		e    model.CanonicalEvent
+		want time.Duration
+	}{
+		{model.CanonicalEvent{Type: "ERROR", Severity: "error"}, 0},
+		{model.CanonicalEvent{Type: "REQUEST", Severity: "info"}, 30 * time.Second}, // type wins
+		{model.CanonicalEvent{Type: "DEPLOY", Severity: "info"}, 10 * time.Second},
+		{model.CanonicalEvent{Type: "DEPLOY", Severity: "warning"}, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := w.For(tt.e, 5*time.Second); got != tt.want {
+			t.Errorf("For(%s/%s) = %v, want %v", tt.e.Type, tt.e.Severity, got, tt.want)
+		}
+	}
+	if got := Windows(nil).For(model.CanonicalEvent{Severity: "error"}, time.Second); got != time.Second {
+		t.Errorf("nil Windows.For = %v, want fallback", got)
+	}
+}
@@ -0,0 +1,238 @@
+package drain
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kaminocorp/lumber/internal/engine/normalize"
+)
+
+// Wildcard marks a variable token in a template.
+const Wildcard = "<*>"
+
+// Config tunes the miner. Zero values use the defaults.
+type Config struct {
+	// Depth is the parse tree depth as in Drain3: the root, the
+	// token-count layer and Depth-2 further layers, the last holding the
+	// clusters, so lines are routed on Depth-3 leading tokens. Default: 4.
+	// Depth 3 routes on token count alone.
+	Depth int
+	// Similarity is the fraction of tokens a line must share with a
+	// template to join its cluster. Default: 0.4.
+	Similarity float64
+	// MaxChildren bounds the children of a tree node; further tokens share
+	// a wildcard child. Default: 100.
+	MaxChildren int
+	// MaxClusters bounds the number of templates tracked. Lines that would
+	// start a new cluster beyond it are reported with their own masked text
+	// but not remembered. Default: 5000.
+	MaxClusters int
+}
+
+func (c *Config) defaults() {
+	if c.Depth < 3 {
+		c.Depth = 4
+	}
+	if c.Similarity <= 0 {
+		c.Similarity = 0.4
+	}
+	if c.MaxChildren <= 0 {
+		c.MaxChildren = 100
+	}
+	if c.MaxClusters <= 0 {
+		c.MaxClusters = 5000
+	}
+}
+
+// Match is the template a line was assigned to. ID is fixed when the
+// template is first seen; Template is its current text, which generalizes
+// as lines join it.
+type Match struct {
+	ID       string
+	Template string
+}
+
+// Cluster is a learned template and the number of lines it matched.
+type Cluster struct {
+	ID       string `json:"id"`
+	Template string `json:"template"`
+	Count    int    `json:"count"`
+	Example  string `json:"example"` // first line that started the cluster
+}
+
+type cluster struct {
+	id      string
+	tokens  []string
+	count   int
+	example string
+}
+
+type node struct {
+	children map[string]*node
+	clusters []*cluster
+}
+
+// Miner incrementally clusters log lines into templates with the Drain
+// algorithm: lines are routed through a fixed-depth tree by token count
+// and leading tokens, then matched against the templates at the leaf by
+// token similarity. Tokens that differ within a cluster become Wildcard.
+// Safe for concurrent use. A nil *Miner assigns no templates.
+type Miner struct {
+	cfg Config
+
+	mu       sync.Mutex
+	root     *node
+	clusters []*cluster
+}
+
+// New creates a Miner.
+func New(cfg Config) *Miner {
+	cfg.defaults()
+	return &Miner{cfg: cfg, root: &node{children: make(map[string]*node)}}
+}
+
+// Add assigns line to a template, learning from it, and returns the
+// template as it stands after the update. Variable values (numbers, ids,
+// IPs, timestamps) are masked before mining. Returns a zero Match for
+// blank lines.
+func (m *Miner) Add(line string) Match {
+	if m == nil {
+		return Match{}
+	}
+	tokens := Tokenize(line)
+	if len(tokens) == 0 {
+		return Match{}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	leaf := m.leaf(tokens)
+	if c := m.bestMatch(leaf.clusters, tokens); c != nil {
+		for i, tok := range tokens {
+			if c.tokens[i] != tok {
+				c.tokens[i] = Wildcard
+			}
+		}
+		c.count++
+		return c.match()
+	}
+
+	c := &cluster{id: ID(strings.Join(tokens, " ")), tokens: tokens, count: 1, example: line}
+	if len(m.clusters) < m.cfg.MaxClusters {
+		leaf.clusters = append(leaf.clusters, c)
+		m.clusters = append(m.clusters, c)
+	}
+	return c.match()
+}
+
+// Clusters returns the learned templates, most frequent first.
+func (m *Miner) Clusters() []Cluster {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	out := make([]Cluster, len(m.clusters))
+	for i, c := range m.clusters {
+		match := c.match()
+		out[i] = Cluster{ID: match.ID, Template: match.Template, Count: c.count, Example: c.example}
+	}
+	m.mu.Unlock()
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+// leaf walks (and grows) the tree to the leaf for tokens.
+func (m *Miner) leaf(tokens []string) *node {
+	n := m.child(m.root, fmt.Sprint(len(tokens)))
+	depth := m.cfg.Depth - 3
+	if depth > len(tokens) {
+		depth = len(tokens)
+	}
+	for _, tok := range tokens[:depth] {
+		if hasDigit(tok) || strings.Contains(tok, Wildcard) {
+			tok = Wildcard
+		}
+		if _, ok := n.children[tok]; !ok && len(n.children) >= m.cfg.MaxChildren {
+			tok = Wildcard
+		}
+		n = m.child(n, tok)
+	}
+	return n
+}
+
+func (m *Miner) child(n *node, key string) *node {
+	c, ok := n.children[key]
+	if !ok {
+		c = &node{children: make(map[string]*node)}
+		n.children[key] = c
+	}
+	return c
+}
+
+// bestMatch returns the cluster most similar to tokens, or nil when none
+// reaches the similarity threshold. Ties go to the template with more
+// wildcards, the more general one.
+func (m *Miner) bestMatch(clusters []*cluster, tokens []string) *cluster {
+	var best *cluster
+	bestSim, bestWild := -1.0, -1
+	for _, c := range clusters {
+		same, wild := 0, 0
+		for i, tok := range c.tokens {
+			switch {
+			case tok == Wildcard:
+				wild++
+			case tok == tokens[i]:
+				same++
+			}
+		}
+		sim := float64(same) / float64(len(tokens))
+		if sim > bestSim || sim == bestSim && wild > bestWild {
+			best, bestSim, bestWild = c, sim, wild
+		}
+	}
+	if bestSim < m.cfg.Similarity {
+		return nil
+	}
+	return best
+}
+
+func (c *cluster) match() Match {
+	return Match{ID: c.id, Template: strings.Join(c.tokens, " ")}
+}
+
+// ID returns the id of a template: a hash of the text it started from, the
+// masked first line of its cluster. A cluster keeps its id as its template
+// generalizes, and a pattern first seen in the same form gets the same id
+// across runs and hosts.
+func ID(template string) string {
+	h := fnv.New32a()
+	h.Write([]byte(template))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// placeholders are the normalize masks, rewritten to Wildcard.
+var placeholders = strings.NewReplacer(
+	normalize.Timestamp, Wildcard,
+	normalize.UUID, Wildcard,
+	normalize.IP, Wildcard,
+	normalize.Hex, Wildcard,
+	normalize.Number, Wildcard,
+)
+
+// Tokenize masks the variable values in line and splits it on whitespace.
+// Only the first line of multi-line input is used.
+func Tokenize(line string) []string {
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return strings.Fields(placeholders.Replace(normalize.Template(line)))
+}
+
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
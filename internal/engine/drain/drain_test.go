@@ -0,0 +1,146 @@
+package drain
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMinerLearnsTemplates(t *testing.T) {
+	m := New(Config{})
+
+	first := m.Add("connection to 10.0.0.1:5432 refused after 3012ms")
+	if first.Template != "connection to <*> refused after <*>ms" {
+		t.Fatalf("template = %q", first.Template)
+	}
+	second := m.Add("connection to 10.0.0.7:6379 refused after 12ms")
+	if second != first {
+		t.Errorf("second line matched %+v, want %+v", second, first)
+	}
+
+	m.Add("session started for alice")
+	got := m.Add("session started for bob")
+	if got.Template != "session started for <*>" {
+		t.Errorf("template = %q, want session started for <*>", got.Template)
+	}
+
+	other := m.Add("cache warmed")
+	if other.ID == got.ID || other.Template != "cache warmed" {
+		t.Errorf("unrelated line joined a cluster: %+v", other)
+	}
+
+	clusters := m.Clusters()
+	if len(clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %d: %+v", len(clusters), clusters)
+	}
+	if clusters[0].Count != 2 || clusters[2].Template != "cache warmed" || clusters[2].Count != 1 {
+		t.Errorf("unexpected clusters: %+v", clusters)
+	}
+	if clusters[0].Example != "connection to 10.0.0.1:5432 refused after 3012ms" {
+		t.Errorf("example = %q, want the first line", clusters[0].Example)
+	}
+}
+
+func TestMinerSeparatesDifferentLengthsAndPrefixes(t *testing.T) {
+	m := New(Config{})
+	a := m.Add("GET /api/users done")
+	b := m.Add("GET /api/users done quickly")
+	c := m.Add("POST /api/users done")
+	if a.ID == b.ID || a.ID == c.ID {
+		t.Errorf("lines with different lengths or leading tokens share a template: %+v %+v %+v", a, b, c)
+	}
+}
+
+func TestMinerStableIDs(t *testing.T) {
+	lines := []string{"job 1 finished in 20ms", "job 2 finished in 35ms", "disk full on /dev/sda1"}
+	m1, m2 := New(Config{}), New(Config{})
+	for _, l := range lines {
+		m1.Add(l)
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		m2.Add(lines[i])
+	}
+	if a, b := m1.Add(lines[0]), m2.Add(lines[0]); a.ID != b.ID || a.ID != ID(a.Template) {
+		t.Errorf("ids differ across miners: %+v vs %+v", a, b)
+	}
+}
+
+func TestMinerIDSurvivesGeneralization(t *testing.T) {
+	m := New(Config{})
+	first := m.Add("login failed for alice")
+	second := m.Add("login failed for bob")
+	third := m.Add("login failed for carol")
+	if second.Template != "login failed for <*>" {
+		t.Fatalf("template = %q", second.Template)
+	}
+	if first.ID != second.ID || second.ID != third.ID {
+		t.Errorf("ids changed as the template generalized: %s, %s, %s", first.ID, second.ID, third.ID)
+	}
+	if c := m.Clusters(); len(c) != 1 || c[0].ID != first.ID {
+		t.Errorf("clusters = %+v, want one with id %s", c, first.ID)
+	}
+}
+
+func TestMinerRoutesOnFirstToken(t *testing.T) {
+	// At the default depth only the first token routes, as in Drain3, so
+	// a variable second token still lets the lines merge.
+	m := New(Config{})
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		m.Add("user " + user + " logged in from web")
+	}
+	if c := m.Clusters(); len(c) != 1 || c[0].Template != "user <*> logged in from web" {
+		t.Errorf("clusters = %+v, want one user <*> template", c)
+	}
+}
+
+func TestMinerMaxClusters(t *testing.T) {
+	m := New(Config{MaxClusters: 2})
+	m.Add("alpha beta gamma")
+	m.Add("one two")
+	got := m.Add("completely different line here")
+	if got.Template != "completely different line here" {
+		t.Errorf("template = %q", got.Template)
+	}
+	if n := len(m.Clusters()); n != 2 {
+		t.Errorf("expected 2 tracked clusters, got %d", n)
+	}
+}
+
+func TestMinerBlankAndNil(t *testing.T) {
+	if got := New(Config{}).Add("   "); got != (Match{}) {
+		t.Errorf("blank line matched %+v", got)
+	}
+	var m *Miner
+	if got := m.Add("anything"); got != (Match{}) {
+		t.Errorf("nil miner matched %+v", got)
+	}
+	if m.Clusters() != nil {
+		t.Error("nil miner returned clusters")
+	}
+}
+
+func TestTokenizeFirstLine(t *testing.T) {
+	got := Tokenize("panic: boom at 0xdeadbeef\n\tgoroutine 1 [running]")
+	if fmt.Sprint(got) != "[panic: boom at <*>]" {
+		t.Errorf("Tokenize = %q", got)
+	}
+}
+
+func TestMinerConcurrent(t *testing.T) {
+	m := New(Config{})
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				m.Add(fmt.Sprintf("worker %d processed item %d", g, i))
+			}
+		}(g)
+	}
+	wg.Wait()
+	clusters := m.Clusters()
+	if len(clusters) != 1 || clusters[0].Count != 1600 {
+		t.Errorf("expected one cluster of 1600, got %+v", clusters)
+	}
+}
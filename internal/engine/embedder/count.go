@@ -0,0 +1,22 @@
+package embedder
+
+// WordPiece counts tokens with the embedding model's BERT WordPiece
+// vocabulary. Unlike inference, texts are not truncated to the model's
+// sequence length and [CLS]/[SEP] are not counted. Safe for concurrent use.
+type WordPiece struct {
+	tok *tokenizer
+}
+
+// NewWordPiece loads a WordPiece counter from a vocab.txt file.
+func NewWordPiece(vocabPath string) (*WordPiece, error) {
+	tok, err := newTokenizer(vocabPath)
+	if err != nil {
+		return nil, err
+	}
+	return &WordPiece{tok: tok}, nil
+}
+
+// CountTokens returns the number of WordPiece tokens in s.
+func (w *WordPiece) CountTokens(s string) int {
+	return len(w.tok.wordpiece(w.tok.basicTokenize(s)))
+}
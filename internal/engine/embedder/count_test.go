@@ -0,0 +1,31 @@
+package embedder
+
+import "testing"
+
+func TestWordPieceCountTokens(t *testing.T) {
+	skipIfNoVocab(t)
+	w, err := NewWordPiece(testVocabPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := w.CountTokens(""); n != 0 {
+		t.Errorf("empty text = %d tokens, want 0", n)
+	}
+	if n := w.CountTokens("connection refused"); n != 2 {
+		t.Errorf("CountTokens = %d, want 2", n)
+	}
+	// Unlike inference, counting does not stop at the sequence length.
+	long := ""
+	for i := 0; i < 200; i++ {
+		long += "error "
+	}
+	if n := w.CountTokens(long); n != 200 {
+		t.Errorf("long text = %d tokens, want 200", n)
+	}
+}
+
+func TestNewWordPieceMissingVocab(t *testing.T) {
+	if _, err := NewWordPiece("/nonexistent/vocab.txt"); err == nil {
+		t.Fatal("expected error")
+	}
+}
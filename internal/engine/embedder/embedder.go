@@ -1,10 +1,14 @@
 package embedder
 
 import (
+	"errors"
 	"fmt"
+	"runtime"
 	"sync"
 )
 
+var errClosed = errors.New("embedder: closed")
+
 // Embedder produces vector embeddings from text.
 type Embedder interface {
 	Embed(text string) ([]float32, error)
@@ -12,43 +16,105 @@ type Embedder interface {
 	Close() error
 }
 
-// ONNXEmbedder wraps the ONNX runtime, tokenizer, and projection layer for
-// local embedding inference. All methods are safe for concurrent use.
+// Option configures an ONNXEmbedder.
+type Option func(*options)
+
+type options struct {
+	sessions int
+	intraOp  int
+	interOp  int
+}
+
+// WithSessions sets the number of ONNX sessions in the pool. Each session
+// holds its own copy of the model, and up to n Embed/EmbedBatch calls run
+// in parallel. Values < 1 are treated as 1. Default: 1.
+func WithSessions(n int) Option {
+	return func(o *options) {
+		o.sessions = n
+	}
+}
+
+// WithThreads sets the ONNX Runtime thread counts for each session: intraOp
+// threads parallelize a single operator, interOp threads run independent
+// operators concurrently. Zero keeps the default: intraOp splits the CPUs
+// across sessions (at most 4 per session), interOp is 1.
+func WithThreads(intraOp, interOp int) Option {
+	return func(o *options) {
+		o.intraOp = intraOp
+		o.interOp = interOp
+	}
+}
+
+// resolve fills in defaults for unset options.
+func (o options) resolve(numCPU int) options {
+	if o.sessions < 1 {
+		o.sessions = 1
+	}
+	if o.intraOp <= 0 {
+		o.intraOp = min(4, max(1, numCPU/o.sessions))
+	}
+	if o.interOp <= 0 {
+		o.interOp = 1
+	}
+	return o
+}
+
+// ONNXEmbedder wraps a pool of ONNX Runtime sessions, the tokenizer, and
+// the projection layer for local embedding inference. Each call borrows one
+// session for its duration, so concurrent calls run in parallel up to the
+// pool size and queue beyond it. All methods are safe for concurrent use.
 type ONNXEmbedder struct {
-	mu      sync.Mutex
-	session *onnxSession
-	tok     *tokenizer
-	proj    *projection
+	mu       sync.RWMutex // held for reading by in-flight calls, for writing by Close
+	closed   bool
+	sessions chan *onnxSession
+	all      []*onnxSession
+	embedDim int64
+	tok      *tokenizer
+	proj     *projection
 }
 
 // New creates an ONNXEmbedder by loading the ONNX model, vocabulary, and
 // projection weights. The full embedding pipeline is:
 // tokenize → ONNX inference → mean pool → dense projection → 1024-dim vector.
-func New(modelPath, vocabPath, projectionPath string) (*ONNXEmbedder, error) {
-	sess, err := newONNXSession(modelPath)
-	if err != nil {
-		return nil, fmt.Errorf("embedder: %w", err)
+func New(modelPath, vocabPath, projectionPath string, opts ...Option) (*ONNXEmbedder, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
+	o = o.resolve(runtime.NumCPU())
 
 	tok, err := newTokenizer(vocabPath)
 	if err != nil {
-		sess.close()
 		return nil, fmt.Errorf("embedder: %w", err)
 	}
 
 	proj, err := loadProjection(projectionPath)
 	if err != nil {
-		sess.close()
 		return nil, fmt.Errorf("embedder: %w", err)
 	}
 
-	if int(sess.embedDim) != proj.inDim {
-		sess.close()
-		return nil, fmt.Errorf("embedder: ONNX output dim %d != projection input dim %d",
-			sess.embedDim, proj.inDim)
+	e := &ONNXEmbedder{
+		sessions: make(chan *onnxSession, o.sessions),
+		tok:      tok,
+		proj:     proj,
+	}
+	for i := 0; i < o.sessions; i++ {
+		sess, err := newONNXSession(modelPath, o.intraOp, o.interOp)
+		if err != nil {
+			e.Close()
+			return nil, fmt.Errorf("embedder: %w", err)
+		}
+		e.all = append(e.all, sess)
+		e.sessions <- sess
 	}
 
-	return &ONNXEmbedder{session: sess, tok: tok, proj: proj}, nil
+	e.embedDim = e.all[0].embedDim
+	if int(e.embedDim) != proj.inDim {
+		e.Close()
+		return nil, fmt.Errorf("embedder: ONNX output dim %d != projection input dim %d",
+			e.embedDim, proj.inDim)
+	}
+	return e, nil
 }
 
 // EmbedDim returns the final embedding dimensionality (after projection).
@@ -56,25 +122,20 @@ func (e *ONNXEmbedder) EmbedDim() int {
 	return e.proj.outDim
 }
 
+// Sessions returns the number of ONNX sessions in the pool.
+func (e *ONNXEmbedder) Sessions() int {
+	return len(e.all)
+}
+
 // Embed produces a single embedding vector for the given text.
 // Routes through tokenizeBatch for dynamic padding to actual sequence length.
 // Safe for concurrent use.
 func (e *ONNXEmbedder) Embed(text string) ([]float32, error) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
-	batch := e.tok.tokenizeBatch([]string{text})
-
-	hidden, err := e.session.infer(
-		batch.inputIDs, batch.attentionMask, batch.tokenTypeIDs,
-		batch.batchSize, batch.seqLen,
-	)
+	vecs, err := e.EmbedBatch([]string{text})
 	if err != nil {
-		return nil, fmt.Errorf("embedder: %w", err)
+		return nil, err
 	}
-
-	pooled := meanPool(hidden, batch.attentionMask, 1, batch.seqLen, e.session.embedDim)
-	return e.proj.apply(pooled), nil
+	return vecs[0], nil
 }
 
 // EmbedBatch produces embedding vectors for multiple texts.
@@ -84,22 +145,27 @@ func (e *ONNXEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
 		return nil, nil
 	}
 
-	e.mu.Lock()
-	defer e.mu.Unlock()
-
+	// Tokenization runs outside the pool; only inference needs a session.
 	batch := e.tok.tokenizeBatch(texts)
 
-	hidden, err := e.session.infer(
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closed {
+		return nil, errClosed
+	}
+	sess := <-e.sessions
+	hidden, err := sess.infer(
 		batch.inputIDs, batch.attentionMask, batch.tokenTypeIDs,
 		batch.batchSize, batch.seqLen,
 	)
+	e.sessions <- sess
 	if err != nil {
 		return nil, fmt.Errorf("embedder: %w", err)
 	}
 
-	pooled := meanPool(hidden, batch.attentionMask, batch.batchSize, batch.seqLen, e.session.embedDim)
+	pooled := meanPool(hidden, batch.attentionMask, batch.batchSize, batch.seqLen, e.embedDim)
 
-	dim := e.session.embedDim
+	dim := e.embedDim
 	results := make([][]float32, batch.batchSize)
 	for i := int64(0); i < batch.batchSize; i++ {
 		vec := pooled[i*dim : (i+1)*dim]
@@ -108,10 +174,20 @@ func (e *ONNXEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
 	return results, nil
 }
 
-// Close releases ONNX Runtime resources.
+// Close waits for in-flight calls to finish and releases ONNX Runtime
+// resources. Calls after Close return an error.
 func (e *ONNXEmbedder) Close() error {
-	if e.session != nil {
-		return e.session.close()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	var errs []error
+	for _, sess := range e.all {
+		if err := sess.close(); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
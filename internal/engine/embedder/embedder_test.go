@@ -0,0 +1,119 @@
+package embedder
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestOptionsResolve(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     options
+		numCPU int
+		want   options
+	}{
+		{"defaults", options{}, 8, options{sessions: 1, intraOp: 4, interOp: 1}},
+		{"split cpus across sessions", options{sessions: 4}, 8, options{sessions: 4, intraOp: 2, interOp: 1}},
+		{"at least one thread", options{sessions: 8}, 2, options{sessions: 8, intraOp: 1, interOp: 1}},
+		{"explicit threads kept", options{sessions: 2, intraOp: 6, interOp: 2}, 4, options{sessions: 2, intraOp: 6, interOp: 2}},
+		{"non-positive sessions", options{sessions: -3}, 2, options{sessions: 1, intraOp: 2, interOp: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.resolve(tt.numCPU); got != tt.want {
+				t.Errorf("resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestEmbedder(tb testing.TB, opts ...Option) *ONNXEmbedder {
+	tb.Helper()
+	if _, err := os.Stat(testModelPath); os.IsNotExist(err) {
+		tb.Skip("model files not found; run 'make download-model' first")
+	}
+	e, err := New(testModelPath, testVocabPath, testProjectionPath, opts...)
+	if err != nil {
+		tb.Fatalf("New() error: %v", err)
+	}
+	return e
+}
+
+func TestSessionPoolConcurrentEmbed(t *testing.T) {
+	e := newTestEmbedder(t, WithSessions(3), WithThreads(1, 1))
+	defer e.Close()
+	if e.Sessions() != 3 {
+		t.Fatalf("Sessions() = %d, want 3", e.Sessions())
+	}
+
+	texts := []string{
+		"connection refused to db-primary:5432",
+		"GET /api/users 200 12ms",
+		"deploy succeeded in 42s",
+		"panic: runtime error: index out of range",
+	}
+	want, err := e.EmbedBatch(texts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 12; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			i := g % len(texts)
+			got, err := e.Embed(texts[i])
+			if err != nil {
+				t.Errorf("Embed() error: %v", err)
+				return
+			}
+			for d := range got {
+				if diff := got[d] - want[i][d]; diff > 1e-4 || diff < -1e-4 {
+					t.Errorf("text %d dim %d: concurrent %f != batch %f", i, d, got[d], want[i][d])
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestEmbedAfterClose(t *testing.T) {
+	e := newTestEmbedder(t, WithSessions(2))
+	if err := e.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatalf("second Close() error: %v", err)
+	}
+	if _, err := e.Embed("hello"); err == nil {
+		t.Fatal("expected error embedding after Close")
+	}
+}
+
+// BenchmarkEmbedParallel measures Embed throughput from concurrent callers
+// as the session pool grows. Compare ns/op across sub-benchmarks, e.g.:
+//
+//	go test ./internal/engine/embedder -run '^$' -bench EmbedParallel -cpu 8
+func BenchmarkEmbedParallel(b *testing.B) {
+	const line = "ERROR [2026-02-28] UserService — connection refused (host=db-primary, port=5432)"
+	for _, n := range []int{1, 2, 4} {
+		b.Run(fmt.Sprintf("sessions=%d", n), func(b *testing.B) {
+			e := newTestEmbedder(b, WithSessions(n))
+			defer e.Close()
+			b.SetParallelism(2) // 2*GOMAXPROCS callers keep every session busy
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := e.Embed(line); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -55,9 +55,10 @@ type onnxSession struct {
 	embedDim   int64
 }
 
-// newONNXSession loads the ONNX model and creates an inference session.
-// It validates the model's input/output tensor names and shapes.
-func newONNXSession(modelPath string) (*onnxSession, error) {
+// newONNXSession loads the ONNX model and creates an inference session
+// running on intraOp threads within an operator and interOp threads across
+// operators. It validates the model's input/output tensor names and shapes.
+func newONNXSession(modelPath string, intraOp, interOp int) (*onnxSession, error) {
 	// Resolve the ONNX Runtime shared library path. We ship it alongside the
 	// model files in the models/ directory.
 	modelDir := filepath.Dir(modelPath)
@@ -95,8 +96,8 @@ func newONNXSession(modelPath string) (*onnxSession, error) {
 		return nil, fmt.Errorf("onnx: failed to create session options: %w", err)
 	}
 	defer opts.Destroy()
-	opts.SetIntraOpNumThreads(4)
-	opts.SetInterOpNumThreads(1)
+	opts.SetIntraOpNumThreads(intraOp)
+	opts.SetInterOpNumThreads(interOp)
 
 	session, err := ort.NewDynamicAdvancedSession(
 		modelPath,
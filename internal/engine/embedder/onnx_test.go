@@ -17,7 +17,7 @@ func skipIfNoModel(t *testing.T) {
 func TestONNXSessionLoad(t *testing.T) {
 	skipIfNoModel(t)
 
-	sess, err := newONNXSession(testModelPath)
+	sess, err := newONNXSession(testModelPath, 4, 1)
 	if err != nil {
 		t.Fatalf("failed to load ONNX session: %v", err)
 	}
@@ -35,7 +35,7 @@ func TestONNXSessionLoad(t *testing.T) {
 func TestONNXInference(t *testing.T) {
 	skipIfNoModel(t)
 
-	sess, err := newONNXSession(testModelPath)
+	sess, err := newONNXSession(testModelPath, 4, 1)
 	if err != nil {
 		t.Fatalf("failed to load ONNX session: %v", err)
 	}
@@ -75,7 +75,7 @@ func TestONNXInference(t *testing.T) {
 func TestONNXBatchInference(t *testing.T) {
 	skipIfNoModel(t)
 
-	sess, err := newONNXSession(testModelPath)
+	sess, err := newONNXSession(testModelPath, 4, 1)
 	if err != nil {
 		t.Fatalf("failed to load ONNX session: %v", err)
 	}
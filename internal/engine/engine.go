@@ -3,9 +3,18 @@ package engine
 import (
 	"strings"
 
+	"github.com/kaminocorp/lumber/internal/engine/attributes"
+	"github.com/kaminocorp/lumber/internal/engine/cache"
 	"github.com/kaminocorp/lumber/internal/engine/classifier"
 	"github.com/kaminocorp/lumber/internal/engine/compactor"
+	"github.com/kaminocorp/lumber/internal/engine/drain"
 	"github.com/kaminocorp/lumber/internal/engine/embedder"
+	"github.com/kaminocorp/lumber/internal/engine/fields"
+	"github.com/kaminocorp/lumber/internal/engine/normalize"
+	"github.com/kaminocorp/lumber/internal/engine/redact"
+	"github.com/kaminocorp/lumber/internal/engine/rules"
+	"github.com/kaminocorp/lumber/internal/engine/severity"
+	"github.com/kaminocorp/lumber/internal/engine/stacktrace"
 	"github.com/kaminocorp/lumber/internal/engine/taxonomy"
 	"github.com/kaminocorp/lumber/internal/model"
 )
@@ -16,23 +25,131 @@ type Engine struct {
 	taxonomy   *taxonomy.Taxonomy
 	classifier *classifier.Classifier
 	compactor  *compactor.Compactor
+	rules      *rules.Set
+	labels     map[string]model.EmbeddedLabel // by path, for rule hits and status corrections
+	cache      *cache.Cache
+	attributes *attributes.Extractor
+	severity   severity.Policy
+	templates  *drain.Miner
+	redactor   *redact.Redactor
+	stacks     bool
+}
+
+// Option configures optional Engine behavior.
+type Option func(*Engine)
+
+// WithRules evaluates the rule set before embedding. A matching rule
+// classifies the log directly, skipping the model; its event has
+// Method "rule" and Confidence 1.
+func WithRules(set *rules.Set) Option {
+	return func(e *Engine) {
+		e.rules = set
+	}
+}
+
+// WithCache caches classification results for up to size log templates
+// (see normalize.Template). Lines that differ only in numbers, ids, IPs or
+// timestamps reuse the result of the first such line instead of being
+// embedded again. A size <= 0 disables the cache.
+func WithCache(size int) Option {
+	return func(e *Engine) {
+		e.cache = cache.New(size)
+	}
+}
+
+// WithAttributes sets how connector metadata becomes event attributes.
+// By default the curated per-connector fields are kept, plus all other
+// metadata when the compactor runs at Full verbosity.
+func WithAttributes(x *attributes.Extractor) Option {
+	return func(e *Engine) {
+		e.attributes = x
+	}
+}
+
+// WithSeverityPolicy sets how the severity declared by the log itself
+// (a JSON level, an ERROR prefix, connector metadata) is reconciled with
+// the matched label's severity. Default: severity.PolicyTaxonomy.
+func WithSeverityPolicy(p severity.Policy) Option {
+	return func(e *Engine) {
+		e.severity = p
+	}
+}
+
+// WithTemplates mines log templates with m and sets TemplateID and
+// Template on every event. TemplateID is a grouping key that stays the
+// same across the variable parts of a message, even as Template
+// generalizes.
+func WithTemplates(m *drain.Miner) Option {
+	return func(e *Engine) {
+		e.templates = m
+	}
+}
+
+// WithRedaction redacts PII and secrets from the raw text and metadata of
+// every log before rules, caching and embedding run, so the values never
+// reach the model, the summary, extracted fields or any output.
+func WithRedaction(r *redact.Redactor) Option {
+	return func(e *Engine) {
+		e.redactor = r
+	}
+}
+
+// WithStackTraces parses stack traces in ERROR events into Stack: the
+// language, exception, message and frames, cut to the frames the
+// compaction profile keeps.
+func WithStackTraces() Option {
+	return func(e *Engine) {
+		e.stacks = true
+	}
 }
 
 // New creates an Engine with the provided components.
-func New(emb embedder.Embedder, tax *taxonomy.Taxonomy, cls *classifier.Classifier, cmp *compactor.Compactor) *Engine {
-	return &Engine{
+func New(emb embedder.Embedder, tax *taxonomy.Taxonomy, cls *classifier.Classifier, cmp *compactor.Compactor, opts ...Option) *Engine {
+	e := &Engine{
 		embedder:   emb,
 		taxonomy:   tax,
 		classifier: cls,
 		compactor:  cmp,
+		severity:   severity.PolicyTaxonomy,
+	}
+	for _, opt := range opts {
+		opt(e)
 	}
+	if e.attributes == nil {
+		e.attributes = attributes.New(nil, nil, cmp != nil && cmp.Verbosity == compactor.Full)
+	}
+	if tax != nil {
+		e.labels = make(map[string]model.EmbeddedLabel)
+		for _, lbl := range tax.Labels() {
+			e.labels[lbl.Path] = lbl
+		}
+	}
+	return e
 }
 
 // Process classifies and compacts a single raw log into a canonical event.
 func (e *Engine) Process(raw model.RawLog) (model.CanonicalEvent, error) {
+	raw = e.redact(raw)
+	ev, err := e.process(raw)
+	if err != nil {
+		return model.CanonicalEvent{}, err
+	}
+	e.recordTokens(raw, ev)
+	return ev, nil
+}
+
+func (e *Engine) process(raw model.RawLog) (model.CanonicalEvent, error) {
 	// Empty/whitespace input cannot be meaningfully classified.
 	if strings.TrimSpace(raw.Raw) == "" {
-		return emptyInputEvent(raw), nil
+		return e.emptyInputEvent(raw), nil
+	}
+	if ev, ok := e.matchRule(raw); ok {
+		return ev, nil
+	}
+
+	key := e.cacheKey(raw.Raw)
+	if result, ok := e.cache.Get(key); ok {
+		return e.classifiedEvent(raw, result), nil
 	}
 
 	vec, err := e.embedder.Embed(raw.Raw)
@@ -41,56 +158,81 @@ func (e *Engine) Process(raw model.RawLog) (model.CanonicalEvent, error) {
 	}
 
 	result := e.classifier.Classify(vec, e.taxonomy.Labels())
-
-	parts := strings.SplitN(result.Label.Path, ".", 2)
-	eventType := parts[0]
-	category := ""
-	if len(parts) > 1 {
-		category = parts[1]
-	}
-
-	compacted, summary := e.compactor.Compact(raw.Raw, eventType)
-
-	severity := result.Label.Severity
-	if eventType == "UNCLASSIFIED" && severity == "" {
-		severity = "warning"
-	}
-
-	return model.CanonicalEvent{
-		Type:       eventType,
-		Category:   category,
-		Severity:   severity,
-		Timestamp:  raw.Timestamp,
-		Summary:    summary,
-		Confidence: result.Confidence,
-		Raw:        compacted,
-	}, nil
+	e.cache.Put(key, result)
+	return e.classifiedEvent(raw, result), nil
 }
 
 // ProcessBatch classifies and compacts a slice of raw logs using a single
-// batched ONNX inference call. Empty/whitespace inputs are handled without
-// invoking the embedder.
+// batched ONNX inference call. Empty/whitespace inputs, rule hits and cache
+// hits are handled without invoking the embedder, and each distinct text
+// (or template, when the cache is enabled) is embedded only once. With a
+// batch token budget, the events' raw texts are then cut to fit it.
 func (e *Engine) ProcessBatch(raws []model.RawLog) ([]model.CanonicalEvent, error) {
 	if len(raws) == 0 {
 		return nil, nil
 	}
 
+	if e.redactor != nil {
+		redacted := make([]model.RawLog, len(raws))
+		for i, raw := range raws {
+			redacted[i] = e.redact(raw)
+		}
+		raws = redacted
+	}
+
+	events, err := e.processBatch(raws)
+	if err != nil {
+		return nil, err
+	}
+	if e.compactor != nil && e.compactor.BatchBudget > 0 {
+		texts := make([]string, len(events))
+		for i, ev := range events {
+			texts[i] = ev.Raw
+		}
+		for i, text := range e.compactor.FitBatch(texts) {
+			events[i].Raw = text
+		}
+	}
+	for i, ev := range events {
+		e.recordTokens(raws[i], ev)
+	}
+	return events, nil
+}
+
+func (e *Engine) processBatch(raws []model.RawLog) ([]model.CanonicalEvent, error) {
 	events := make([]model.CanonicalEvent, len(raws))
 
-	// Separate non-empty inputs for batched embedding. Track their indices
-	// so we can map vectors back to the original positions.
-	var embedTexts []string
-	var embedIndices []int
+	// Separate the inputs that need embedding, grouped by cache key. Track
+	// each group's indices so we can map vectors back to the original
+	// positions.
+	var embedTexts, embedKeys []string
+	var embedIndices [][]int
+	pending := make(map[string]int) // cache key -> position in embedTexts
 	for i, raw := range raws {
 		if strings.TrimSpace(raw.Raw) == "" {
-			events[i] = emptyInputEvent(raw)
-		} else {
-			embedTexts = append(embedTexts, raw.Raw)
-			embedIndices = append(embedIndices, i)
+			events[i] = e.emptyInputEvent(raw)
+			continue
+		}
+		if ev, ok := e.matchRule(raw); ok {
+			events[i] = ev
+			continue
+		}
+		key := e.cacheKey(raw.Raw)
+		if pos, ok := pending[key]; ok {
+			embedIndices[pos] = append(embedIndices[pos], i)
+			continue
+		}
+		if result, ok := e.cache.Get(key); ok {
+			events[i] = e.classifiedEvent(raw, result)
+			continue
 		}
+		pending[key] = len(embedTexts)
+		embedTexts = append(embedTexts, raw.Raw)
+		embedKeys = append(embedKeys, key)
+		embedIndices = append(embedIndices, []int{i})
 	}
 
-	// If all inputs were empty, we're done.
+	// If all inputs were empty, matched rules or were cached, we're done.
 	if len(embedTexts) == 0 {
 		return events, nil
 	}
@@ -100,45 +242,192 @@ func (e *Engine) ProcessBatch(raws []model.RawLog) ([]model.CanonicalEvent, erro
 		return nil, err
 	}
 
-	for vi, origIdx := range embedIndices {
-		raw := raws[origIdx]
+	for vi, indices := range embedIndices {
 		result := e.classifier.Classify(vecs[vi], e.taxonomy.Labels())
-
-		parts := strings.SplitN(result.Label.Path, ".", 2)
-		eventType := parts[0]
-		category := ""
-		if len(parts) > 1 {
-			category = parts[1]
+		e.cache.Put(embedKeys[vi], result)
+		for _, origIdx := range indices {
+			events[origIdx] = e.classifiedEvent(raws[origIdx], result)
 		}
+	}
+	return events, nil
+}
+
+// Templates returns the templates mined so far, most frequent first, or
+// nil when template mining is disabled.
+func (e *Engine) Templates() []drain.Cluster {
+	return e.templates.Clusters()
+}
+
+// CacheStats reports template cache activity. All zero when the cache is disabled.
+func (e *Engine) CacheStats() cache.Stats {
+	return e.cache.Stats()
+}
+
+// TokenStats reports the tokens of the raw logs processed so far and of
+// the text kept for their events.
+func (e *Engine) TokenStats() compactor.TokenStats {
+	return e.compactor.TokenStats()
+}
+
+// recordTokens counts the tokens of raw and of the text kept for ev: its
+// raw text, or its summary when the raw text was dropped.
+func (e *Engine) recordTokens(raw model.RawLog, ev model.CanonicalEvent) {
+	kept := ev.Raw
+	if kept == "" {
+		kept = ev.Summary
+	}
+	e.compactor.Record(raw.Raw, kept)
+}
+
+// RedactionCounts reports the values redacted so far per detector, or nil
+// when redaction is disabled.
+func (e *Engine) RedactionCounts() map[string]int64 {
+	return e.redactor.Counts()
+}
 
-		compacted, summary := e.compactor.Compact(raw.Raw, eventType)
+// redact returns raw with sensitive values replaced in its text and
+// metadata. The caller's metadata map is not modified.
+func (e *Engine) redact(raw model.RawLog) model.RawLog {
+	if e.redactor == nil {
+		return raw
+	}
+	raw.Raw = e.redactor.Redact(raw.Raw)
+	raw.Metadata = e.redactor.RedactMap(raw.Metadata)
+	return raw
+}
 
-		severity := result.Label.Severity
-		if eventType == "UNCLASSIFIED" && severity == "" {
-			severity = "warning"
+// cacheKey returns the key identifying text for caching and in-batch
+// deduplication: its template when the cache is enabled, otherwise the
+// text itself so that only exact repeats share a result.
+func (e *Engine) cacheKey(text string) string {
+	if e.cache == nil {
+		return text
+	}
+	return normalize.Template(text)
+}
+
+// Labels returns the pre-embedded taxonomy labels the engine classifies against.
+func (e *Engine) Labels() []model.EmbeddedLabel {
+	return e.taxonomy.Labels()
+}
+
+// matchRule classifies raw with the first matching rule, if any.
+func (e *Engine) matchRule(raw model.RawLog) (model.CanonicalEvent, bool) {
+	rule, ok := e.rules.Match(raw.Raw)
+	if !ok {
+		return model.CanonicalEvent{}, false
+	}
+	label := e.labels[rule.Path]
+	label.Path = rule.Path
+	if rule.Severity != "" {
+		label.Severity = rule.Severity
+	}
+	ev := e.buildEvent(raw, classifier.Result{Label: label, Confidence: 1}, fields.ExtractLog(raw.Raw, raw.Metadata))
+	ev.Method = "rule"
+	return ev, true
+}
+
+// statusLabels maps an HTTP status class (status / 100) to the REQUEST label
+// it implies.
+var statusLabels = map[int]string{
+	2: "REQUEST.success",
+	3: "REQUEST.redirect",
+	4: "REQUEST.client_error",
+	5: "REQUEST.server_error",
+}
+
+// classifiedEvent builds the event for a model classification, first
+// correcting a REQUEST outcome label that contradicts the status code found
+// in the line: a 503 never lands in REQUEST.success. Other labels, such as
+// REQUEST.slow_request or ERROR.*, are left alone.
+func (e *Engine) classifiedEvent(raw model.RawLog, result classifier.Result) model.CanonicalEvent {
+	f := fields.ExtractLog(raw.Raw, raw.Metadata)
+	if status, ok := f[fields.Status].(int); ok {
+		want := statusLabels[status/100]
+		if isStatusLabel(result.Label.Path) && result.Label.Path != want {
+			if lbl, ok := e.labels[want]; ok {
+				result.Label = lbl
+			}
 		}
+	}
+	return e.buildEvent(raw, result, f)
+}
 
-		events[origIdx] = model.CanonicalEvent{
-			Type:       eventType,
-			Category:   category,
-			Severity:   severity,
-			Timestamp:  raw.Timestamp,
-			Summary:    summary,
-			Confidence: result.Confidence,
-			Raw:        compacted,
+func isStatusLabel(path string) bool {
+	for _, p := range statusLabels {
+		if p == path {
+			return true
 		}
 	}
-	return events, nil
+	return false
+}
+
+// buildEvent compacts raw and assembles the canonical event for a classifier
+// result and the fields extracted from the line.
+func (e *Engine) buildEvent(raw model.RawLog, result classifier.Result, f map[string]any) model.CanonicalEvent {
+	parts := strings.SplitN(result.Label.Path, ".", 2)
+	eventType := parts[0]
+	category := ""
+	if len(parts) > 1 {
+		category = parts[1]
+	}
+
+	sev := result.Label.Severity
+	if eventType == "UNCLASSIFIED" && sev == "" {
+		sev = "warning"
+	}
+	srcSev := severity.Detect(raw.Raw, raw.Metadata)
+	sev = e.severity.Reconcile(sev, srcSev)
+	tmpl := e.templates.Add(raw.Raw)
+
+	profile := e.compactor.Profile(eventType, category, sev)
+	compacted, summary := e.compactor.CompactProfile(raw.Raw, profile)
+	verbosity := ""
+	if e.compactor.Policy != nil {
+		verbosity = profile.Name()
+	}
+	var stack *model.Stack
+	if e.stacks && eventType == "ERROR" {
+		if t, ok := stacktrace.Parse(raw.Raw); ok {
+			stack = t.Stack(profile.MaxFrames)
+		}
+	}
+
+	return model.CanonicalEvent{
+		Type:           eventType,
+		Category:       category,
+		Severity:       sev,
+		SourceSeverity: srcSev,
+		Timestamp:      raw.Timestamp,
+		Source:         raw.Source,
+		TimeSource:     raw.TimeSource,
+		TemplateID:     tmpl.ID,
+		Template:       tmpl.Template,
+		Summary:        summary,
+		Confidence:     result.Confidence,
+		Alternatives:   result.Alternatives,
+		Margin:         result.Margin,
+		Ambiguous:      result.Ambiguous,
+		Attributes:     e.attributes.Extract(raw.Source, raw.Metadata),
+		Fields:         f,
+		Raw:            compacted,
+		Stack:          stack,
+		Vector:         result.Vector,
+		Verbosity:      verbosity,
+	}
 }
 
 // emptyInputEvent returns an UNCLASSIFIED event for empty/whitespace-only input.
-func emptyInputEvent(raw model.RawLog) model.CanonicalEvent {
+func (e *Engine) emptyInputEvent(raw model.RawLog) model.CanonicalEvent {
 	return model.CanonicalEvent{
 		Type:       "UNCLASSIFIED",
 		Category:   "empty_input",
 		Severity:   "warning",
 		Timestamp:  raw.Timestamp,
+		Source:     raw.Source,
+		TimeSource: raw.TimeSource,
 		Confidence: 0,
+		Attributes: e.attributes.Extract(raw.Source, raw.Metadata),
 		Raw:        raw.Raw,
 	}
 }
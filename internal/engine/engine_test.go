@@ -7,11 +7,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/engine/cache"
 	"github.com/kaminocorp/lumber/internal/engine/classifier"
 	"github.com/kaminocorp/lumber/internal/engine/compactor"
+	"github.com/kaminocorp/lumber/internal/engine/drain"
 	"github.com/kaminocorp/lumber/internal/engine/embedder"
+	"github.com/kaminocorp/lumber/internal/engine/redact"
+	"github.com/kaminocorp/lumber/internal/engine/rules"
+	"github.com/kaminocorp/lumber/internal/engine/severity"
 	"github.com/kaminocorp/lumber/internal/engine/taxonomy"
-	"github.com/kaminocorp/lumber/internal/engine/testdata"
 	"github.com/kaminocorp/lumber/internal/model"
 )
 
@@ -173,7 +178,7 @@ func TestProcessUnclassifiedLog(t *testing.T) {
 func TestCorpusAccuracy(t *testing.T) {
 	eng := newTestEngine(t)
 
-	corpus, err := testdata.LoadCorpus()
+	corpus, err := corpus.LoadCorpus()
 	if err != nil {
 		t.Fatalf("LoadCorpus() error: %v", err)
 	}
@@ -277,7 +282,7 @@ func TestCorpusAccuracy(t *testing.T) {
 func TestCorpusSeverityConsistency(t *testing.T) {
 	eng := newTestEngine(t)
 
-	corpus, err := testdata.LoadCorpus()
+	corpus, err := corpus.LoadCorpus()
 	if err != nil {
 		t.Fatalf("LoadCorpus() error: %v", err)
 	}
@@ -314,7 +319,7 @@ func TestCorpusSeverityConsistency(t *testing.T) {
 func TestCorpusConfidenceDistribution(t *testing.T) {
 	eng := newTestEngine(t)
 
-	corpus, err := testdata.LoadCorpus()
+	corpus, err := corpus.LoadCorpus()
 	if err != nil {
 		t.Fatalf("LoadCorpus() error: %v", err)
 	}
@@ -603,7 +608,7 @@ func TestProcessMetadataNotInOutput(t *testing.T) {
 // which skips the testdata/ package (Go convention).
 
 func TestCorpusStructure(t *testing.T) {
-	corpus, err := testdata.LoadCorpus()
+	corpus, err := corpus.LoadCorpus()
 	if err != nil {
 		t.Fatalf("LoadCorpus() error: %v", err)
 	}
@@ -631,7 +636,7 @@ func TestCorpusStructure(t *testing.T) {
 }
 
 func TestCorpusTaxonomyCoverage(t *testing.T) {
-	corpus, err := testdata.LoadCorpus()
+	corpus, err := corpus.LoadCorpus()
 	if err != nil {
 		t.Fatalf("LoadCorpus() error: %v", err)
 	}
@@ -693,3 +698,537 @@ func minMax(vs []float64) (float64, float64) {
 	}
 	return mn, mx
 }
+
+// --- Rules ---
+
+// fixedEmbedder returns the same unit vector for every text and counts the
+// texts it embeds. Runs without ONNX model files.
+type fixedEmbedder struct{ texts int }
+
+func (f *fixedEmbedder) Embed(string) ([]float32, error) {
+	f.texts++
+	return []float32{1, 0}, nil
+}
+
+func (f *fixedEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	f.texts += len(texts)
+	vecs := make([][]float32, len(texts))
+	for i := range vecs {
+		vecs[i] = []float32{1, 0}
+	}
+	return vecs, nil
+}
+
+func (f *fixedEmbedder) Close() error { return nil }
+
+func newRulesEngine(t *testing.T, emb embedder.Embedder) *Engine {
+	t.Helper()
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := rules.New([]rules.Rule{
+		{Name: "go-panic", Pattern: `^panic: `, Path: "ERROR.runtime_exception"},
+		{Name: "fatal", Field: "level", Equals: []string{"fatal"}, Path: "ERROR.runtime_exception", Severity: "error"},
+		{Name: "bad-gateway", Pattern: `" 502 `, Path: "REQUEST.server_error", Severity: "warning"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard), WithRules(set))
+}
+
+func TestProcessRuleHit_SkipsEmbedder(t *testing.T) {
+	emb := &fixedEmbedder{}
+	eng := newRulesEngine(t, emb)
+	emb.texts = 0 // ignore taxonomy pre-embedding
+
+	event, err := eng.Process(model.RawLog{Raw: "panic: runtime error: index out of range", Timestamp: time.Now()})
+	if err != nil {
+		t.Fatalf("Process() error: %v", err)
+	}
+	if emb.texts != 0 {
+		t.Errorf("embedder called %d times for a rule hit", emb.texts)
+	}
+	if event.Type != "ERROR" || event.Category != "runtime_exception" || event.Method != "rule" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if event.Severity != "error" || event.Confidence != 1 {
+		t.Errorf("severity/confidence = %q/%f, want taxonomy severity error and 1", event.Severity, event.Confidence)
+	}
+	if event.Summary == "" {
+		t.Error("rule hits should still be compacted and summarized")
+	}
+}
+
+func TestProcessBatchMixedRules(t *testing.T) {
+	emb := &fixedEmbedder{}
+	eng := newRulesEngine(t, emb)
+	emb.texts = 0
+
+	events, err := eng.ProcessBatch([]model.RawLog{
+		{Raw: `{"level":"fatal","msg":"cannot bind port"}`},
+		{Raw: "user signed in"},
+		{Raw: `10.0.0.1 - - [19/Feb/2026:12:00:00 +0000] "GET /api HTTP/1.1" 502 157`},
+		{Raw: ""},
+	})
+	if err != nil {
+		t.Fatalf("ProcessBatch() error: %v", err)
+	}
+	if emb.texts != 1 {
+		t.Errorf("embedded %d texts, want 1 (only the non-rule, non-empty line)", emb.texts)
+	}
+	if events[0].Method != "rule" || events[0].Category != "runtime_exception" {
+		t.Errorf("events[0] = %+v, want fatal rule hit", events[0])
+	}
+	if events[1].Method != "" {
+		t.Errorf("events[1].Method = %q, want embedding classification", events[1].Method)
+	}
+	if events[2].Category != "server_error" || events[2].Severity != "warning" {
+		t.Errorf("events[2] = %+v, want 502 rule hit with severity override", events[2])
+	}
+	if events[3].Category != "empty_input" {
+		t.Errorf("events[3].Category = %q, want empty_input", events[3].Category)
+	}
+}
+
+func newCachedEngine(t *testing.T, emb embedder.Embedder, size int) *Engine {
+	t.Helper()
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard), WithCache(size))
+}
+
+func TestProcessCacheHit_SkipsEmbedder(t *testing.T) {
+	emb := &fixedEmbedder{}
+	eng := newCachedEngine(t, emb, 100)
+	emb.texts = 0
+
+	first, err := eng.Process(model.RawLog{Raw: "connection to 10.0.0.1:5432 refused after 3012ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := eng.Process(model.RawLog{Raw: "connection to 10.0.0.7:5432 refused after 97ms"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emb.texts != 1 {
+		t.Errorf("embedded %d texts, want 1 (second line shares the template)", emb.texts)
+	}
+	if first.Type != second.Type || first.Category != second.Category || first.Confidence != second.Confidence {
+		t.Errorf("cached classification differs: %+v vs %+v", first, second)
+	}
+	if !strings.Contains(second.Raw, "10.0.0.7") {
+		t.Errorf("cache hit should keep its own raw text, got %q", second.Raw)
+	}
+
+	s := eng.CacheStats()
+	if s.Hits != 1 || s.Misses != 1 || s.Entries != 1 {
+		t.Errorf("unexpected cache stats: %+v", s)
+	}
+}
+
+func TestProcessBatchDedupesTemplates(t *testing.T) {
+	emb := &fixedEmbedder{}
+	eng := newCachedEngine(t, emb, 100)
+	emb.texts = 0
+
+	raws := []model.RawLog{
+		{Raw: "user 1 signed in"},
+		{Raw: "user 2 signed in"},
+		{Raw: "disk 91% full"},
+		{Raw: "user 3 signed in"},
+	}
+	events, err := eng.ProcessBatch(raws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emb.texts != 2 {
+		t.Errorf("embedded %d texts, want 2 distinct templates", emb.texts)
+	}
+	for i, ev := range events {
+		if ev.Type == "" || !strings.Contains(ev.Raw, strings.Fields(raws[i].Raw)[1]) {
+			t.Errorf("events[%d] = %+v, not mapped back to its input", i, ev)
+		}
+	}
+
+	// A second batch of the same templates is served from the cache.
+	if _, err := eng.ProcessBatch(raws); err != nil {
+		t.Fatal(err)
+	}
+	if emb.texts != 2 {
+		t.Errorf("embedded %d texts after cached batch, want 2", emb.texts)
+	}
+}
+
+func TestProcessBatchWithoutCache_DedupesExactRepeats(t *testing.T) {
+	emb := &fixedEmbedder{}
+	eng := newCachedEngine(t, emb, 0)
+	emb.texts = 0
+
+	_, err := eng.ProcessBatch([]model.RawLog{
+		{Raw: "user 1 signed in"},
+		{Raw: "user 1 signed in"},
+		{Raw: "user 2 signed in"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emb.texts != 2 {
+		t.Errorf("embedded %d texts, want 2 (only exact repeats shared)", emb.texts)
+	}
+	if s := eng.CacheStats(); s != (cache.Stats{}) {
+		t.Errorf("disabled cache reported stats: %+v", s)
+	}
+}
+
+func TestProcessCarriesSourceAndAttributes(t *testing.T) {
+	emb := &fixedEmbedder{}
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard))
+
+	raw := model.RawLog{
+		Source:   "flyio",
+		Raw:      "health check failed",
+		Metadata: map[string]any{"region": "ord", "instance": "e784079b", "id": "01HX"},
+	}
+	events, err := eng.ProcessBatch([]model.RawLog{raw, {Source: "flyio", Raw: " ", Metadata: raw.Metadata}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ev := range events {
+		if ev.Source != "flyio" {
+			t.Errorf("events[%d].Source = %q, want flyio", i, ev.Source)
+		}
+		if ev.Attributes["region"] != "ord" || ev.Attributes["instance"] != "e784079b" {
+			t.Errorf("events[%d].Attributes = %v, want region and instance", i, ev.Attributes)
+		}
+		if _, ok := ev.Attributes["id"]; ok {
+			t.Errorf("events[%d]: uncurated id should be dropped at standard verbosity", i)
+		}
+	}
+}
+
+func TestProcessExtractsFieldsAndCorrectsStatus(t *testing.T) {
+	emb := &fixedEmbedder{}
+	// Every label scores the same, so the model always picks REQUEST.success.
+	tax, err := taxonomy.New([]*model.TaxonomyNode{{
+		Name: "REQUEST",
+		Children: []*model.TaxonomyNode{
+			{Name: "success", Desc: "2xx", Severity: "info"},
+			{Name: "server_error", Desc: "5xx", Severity: "error"},
+			{Name: "slow_request", Desc: "slow", Severity: "warning"},
+		},
+	}}, emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard))
+
+	events, err := eng.ProcessBatch([]model.RawLog{
+		{Raw: "GET /api/users 200 in 12ms"},
+		{Raw: "GET /api/users 503 in 30012ms"},
+		{Raw: `{"msg":"request done","status":502,"path":"/api/pay"}`},
+		{Raw: "user signed in"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if events[0].Category != "success" || events[0].Fields["status"] != 200 || events[0].Fields["duration_ms"] != 12.0 {
+		t.Errorf("events[0] = %s %v, want success with status and duration", events[0].Category, events[0].Fields)
+	}
+	if events[1].Category != "server_error" || events[1].Severity != "error" {
+		t.Errorf("events[1] = %s/%s, want 503 corrected to server_error/error", events[1].Category, events[1].Severity)
+	}
+	if events[2].Category != "server_error" || events[2].Fields["route"] != "/api/pay" {
+		t.Errorf("events[2] = %s %v, want server_error with route", events[2].Category, events[2].Fields)
+	}
+	if events[3].Fields != nil {
+		t.Errorf("events[3].Fields = %v, want nil", events[3].Fields)
+	}
+}
+
+func TestProcessSeverityPolicy(t *testing.T) {
+	raws := []model.RawLog{
+		{Raw: `{"level":"error","msg":"user signed in"}`},
+		{Raw: "user signed in", Source: "vercel", Metadata: map[string]any{"level": "debug"}},
+		{Raw: "user signed in"},
+	}
+	tests := []struct {
+		policy severity.Policy
+		want   []string
+	}{
+		{severity.PolicyTaxonomy, []string{"info", "info", "info"}},
+		{severity.PolicySource, []string{"error", "debug", "info"}},
+		{severity.PolicyMax, []string{"error", "info", "info"}},
+	}
+	for _, tt := range tests {
+		emb := &fixedEmbedder{}
+		tax, err := taxonomy.New([]*model.TaxonomyNode{{
+			Name:     "ACCESS",
+			Children: []*model.TaxonomyNode{{Name: "login_success", Desc: "login", Severity: "info"}},
+		}}, emb)
+		if err != nil {
+			t.Fatal(err)
+		}
+		eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard), WithSeverityPolicy(tt.policy))
+
+		events, err := eng.ProcessBatch(raws)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, ev := range events {
+			if ev.Severity != tt.want[i] {
+				t.Errorf("%s: events[%d].Severity = %q, want %q", tt.policy, i, ev.Severity, tt.want[i])
+			}
+		}
+		if events[0].SourceSeverity != "error" || events[1].SourceSeverity != "debug" || events[2].SourceSeverity != "" {
+			t.Errorf("%s: source severities = %q, %q, %q", tt.policy, events[0].SourceSeverity, events[1].SourceSeverity, events[2].SourceSeverity)
+		}
+	}
+}
+
+func TestProcessAssignsTemplates(t *testing.T) {
+	emb := &fixedEmbedder{}
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	miner := drain.New(drain.Config{})
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard), WithTemplates(miner))
+
+	events, err := eng.ProcessBatch([]model.RawLog{
+		{Raw: "connection to 10.0.0.1:5432 refused after 3012ms"},
+		{Raw: "connection to 10.0.0.9:5432 refused after 17ms"},
+		{Raw: "cache warmed"},
+		{Raw: ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[0].Template != "connection to <*> refused after <*>ms" || events[0].TemplateID != events[1].TemplateID {
+		t.Errorf("events[0..1] templates = %q/%s, %q/%s", events[0].Template, events[0].TemplateID, events[1].Template, events[1].TemplateID)
+	}
+	if events[2].TemplateID == "" || events[2].TemplateID == events[0].TemplateID {
+		t.Errorf("events[2].TemplateID = %q, want its own template", events[2].TemplateID)
+	}
+	if events[3].TemplateID != "" {
+		t.Errorf("empty input got template %q", events[3].Template)
+	}
+	if top := eng.Templates(); len(top) != 2 || top[0].Count != 2 {
+		t.Errorf("Templates() = %+v, want 2 templates, the first seen twice", top)
+	}
+}
+
+func TestProcessCompactionPolicy(t *testing.T) {
+	emb := &fixedEmbedder{}
+	tax, err := taxonomy.New([]*model.TaxonomyNode{{
+		Name:     "REQUEST",
+		Children: []*model.TaxonomyNode{{Name: "success", Desc: "ok", Severity: "info"}},
+	}}, emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy, err := compactor.NewPolicy([]compactor.Rule{
+		{Type: "REQUEST", Category: "success", Severity: "info", Verbosity: "summary"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard, compactor.WithPolicy(policy)),
+		WithSeverityPolicy(severity.PolicySource))
+
+	events, err := eng.ProcessBatch([]model.RawLog{
+		{Raw: "GET /health 200 in 3ms"},
+		{Raw: "ERROR GET /health 200 in 3ms"}, // source level makes it an error: no rule
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if events[0].Raw != "" || events[0].Summary == "" || events[0].Verbosity != compactor.Summary {
+		t.Errorf("events[0] = raw %q, summary %q, verbosity %q; want summary only", events[0].Raw, events[0].Summary, events[0].Verbosity)
+	}
+	if events[1].Raw == "" || events[1].Verbosity != "standard" {
+		t.Errorf("events[1] = raw %q, verbosity %q; want standard", events[1].Raw, events[1].Verbosity)
+	}
+
+	// Without a policy events carry no verbosity.
+	plain := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard))
+	if ev, err := plain.Process(model.RawLog{Raw: "GET /health 200 in 3ms"}); err != nil || ev.Verbosity != "" || ev.Raw == "" {
+		t.Errorf("without policy: %+v, %v", ev, err)
+	}
+}
+
+// recordingEmbedder remembers the texts it was asked to embed.
+type recordingEmbedder struct{ seen []string }
+
+func (r *recordingEmbedder) Embed(text string) ([]float32, error) {
+	r.seen = append(r.seen, text)
+	return []float32{1, 0}, nil
+}
+
+func (r *recordingEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	r.seen = append(r.seen, texts...)
+	vecs := make([][]float32, len(texts))
+	for i := range vecs {
+		vecs[i] = []float32{1, 0}
+	}
+	return vecs, nil
+}
+
+func (r *recordingEmbedder) Close() error { return nil }
+
+func TestProcessRedaction(t *testing.T) {
+	emb := &recordingEmbedder{}
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, err := redact.New(redact.Config{Detectors: []string{"all"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard), WithRedaction(red))
+	emb.seen = nil
+
+	meta := map[string]any{"region": "ord", "instance": "jane@example.com"}
+	raws := []model.RawLog{
+		{Source: "flyio", Raw: "login failed for jane@example.com from 10.0.0.9 password=hunter2", Metadata: meta},
+		{Raw: "GET /charge?api_key=sk12345678abc 500"},
+	}
+	single, err := eng.Process(raws[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	batch, err := eng.ProcessBatch(raws)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ev := range append([]model.CanonicalEvent{single}, batch...) {
+		for _, secret := range []string{"jane@example.com", "10.0.0.9", "hunter2", "sk12345678abc"} {
+			if strings.Contains(ev.Raw, secret) || strings.Contains(ev.Summary, secret) || strings.Contains(fmt.Sprint(ev.Fields, ev.Attributes), secret) {
+				t.Errorf("%q leaked into event %+v", secret, ev)
+			}
+		}
+	}
+	if single.Attributes["instance"] != "[REDACTED:email]" || single.Attributes["region"] != "ord" {
+		t.Errorf("metadata not redacted: %v", single.Attributes)
+	}
+	if meta["instance"] != "jane@example.com" {
+		t.Error("redaction modified the caller's metadata")
+	}
+	for _, text := range emb.seen {
+		if strings.Contains(text, "@") || strings.Contains(text, "hunter2") {
+			t.Errorf("embedder saw unredacted text %q", text)
+		}
+	}
+
+	counts := eng.RedactionCounts()
+	if counts[redact.Email] != 4 || counts[redact.IP] != 2 || counts[redact.Password] != 2 || counts[redact.APIKey] != 1 {
+		t.Errorf("RedactionCounts() = %v", counts)
+	}
+}
+
+func TestProcessStackTraces(t *testing.T) {
+	emb := &fixedEmbedder{}
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set, err := rules.New([]rules.Rule{
+		{Name: "go-panic", Pattern: `^panic: `, Path: "ERROR.runtime_exception"},
+		{Name: "bad-gateway", Pattern: `" 502 `, Path: "REQUEST.server_error"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard), WithRules(set), WithStackTraces())
+
+	lines := []string{"panic: assignment to entry in nil map", "", "goroutine 1 [running]:"}
+	for i := 0; i < 15; i++ {
+		lines = append(lines, fmt.Sprintf("main.step%d()", i), fmt.Sprintf("\t/app/main.go:%d +0x1d", i+1))
+	}
+	events, err := eng.ProcessBatch([]model.RawLog{
+		{Raw: strings.Join(lines, "\n")},
+		{Raw: `10.0.0.1 - - [19/Feb/2026:12:00:00 +0000] "GET /api HTTP/1.1" 502 157`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := events[0].Stack
+	if s == nil || s.Language != "go" || s.Exception != "panic" || s.Message != "assignment to entry in nil map" {
+		t.Fatalf("unexpected stack: %+v", s)
+	}
+	// Standard verbosity keeps 10 leading frames plus the last 2.
+	if len(s.Frames) != 12 || s.Omitted != 3 || s.Frames[0].Function != "main.step0" || s.Frames[0].Line != 1 {
+		t.Errorf("unexpected frames: %d kept, %d omitted, first %+v", len(s.Frames), s.Omitted, s.Frames[0])
+	}
+	if events[1].Stack != nil {
+		t.Errorf("non-ERROR event got a stack: %+v", events[1].Stack)
+	}
+}
+
+func TestProcessTokenBudgets(t *testing.T) {
+	emb := &fixedEmbedder{}
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmp := compactor.New(compactor.Standard, compactor.WithTokenBudget(8, 12))
+	eng := New(emb, tax, classifier.New(0.5), cmp)
+
+	long := strings.Repeat("connection refused retrying ", 10) // 30 words
+	ev, err := eng.Process(model.RawLog{Raw: long})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := compactor.EstimateTokens(ev.Raw); n > 8 || n == 0 {
+		t.Errorf("per-event budget: %d tokens in %q", n, ev.Raw)
+	}
+	stats := eng.TokenStats()
+	if stats.Raw != 39 || stats.Compacted != int64(compactor.EstimateTokens(ev.Raw)) {
+		t.Errorf("TokenStats = %+v", stats)
+	}
+
+	events, err := eng.ProcessBatch([]model.RawLog{{Raw: long}, {Raw: long}, {Raw: "ok"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, ev := range events {
+		total += compactor.EstimateTokens(ev.Raw)
+	}
+	if total > 12 || events[2].Raw != "ok" {
+		t.Errorf("batch budget: %d tokens in %q", total, []string{events[0].Raw, events[1].Raw, events[2].Raw})
+	}
+	if got := eng.TokenStats(); got.Raw != 39*3+2 || got.Compacted != stats.Compacted+int64(total) {
+		t.Errorf("TokenStats after batch = %+v", got)
+	}
+}
+
+func TestProcessKeepsTimeSource(t *testing.T) {
+	emb := &fixedEmbedder{}
+	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eng := New(emb, tax, classifier.New(0.5), compactor.New(compactor.Standard))
+	ts := time.Date(2026, 2, 19, 12, 0, 1, 0, time.UTC)
+	events, err := eng.ProcessBatch([]model.RawLog{
+		{Timestamp: ts, TimeSource: model.TimeParsed, Raw: "connection refused"},
+		{Timestamp: ts, TimeSource: model.TimeInferred, Raw: "  "},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !events[0].Timestamp.Equal(ts) || events[0].TimeSource != model.TimeParsed || events[1].TimeSource != model.TimeInferred {
+		t.Errorf("unexpected timestamps: %s %q / %q", events[0].Timestamp, events[0].TimeSource, events[1].TimeSource)
+	}
+}
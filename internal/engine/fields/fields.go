@@ -0,0 +1,311 @@
+package fields
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Field names set by Extract.
+const (
+	Status     = "status"      // HTTP status code (int)
+	DurationMS = "duration_ms" // request or operation duration in milliseconds (float64)
+	Route      = "route"       // request path, without query string
+	Method     = "method"      // HTTP method, upper case
+	Host       = "host"        // request or upstream host
+	ErrorCode  = "error_code"  // ECONNREFUSED, ERR_*, SQLSTATE, explicit error codes
+	Exception  = "exception"   // exception or error class, e.g. java.lang.NullPointerException
+)
+
+// aliases lists, for each field, the structured keys (lower case, nested
+// objects joined with ".") it is read from, in order of preference.
+var aliases = map[string][]string{
+	Status:     {"status", "status_code", "statuscode", "http_status", "http.status_code", "http.status", "response.status", "res.statuscode", "response_status"},
+	DurationMS: {"duration_ms", "durationms", "latency_ms", "elapsed_ms", "response_time_ms", "took_ms", "duration", "latency", "elapsed", "response_time", "responsetime", "took"},
+	Route:      {"route", "path", "url", "uri", "request_path", "http.route", "http.path", "http.target", "http.url", "req.url", "request.path", "request.url"},
+	Method:     {"method", "http_method", "http.method", "req.method", "request.method"},
+	Host:       {"host", "hostname", "http.host", "req.headers.host", "server.address"},
+	ErrorCode:  {"error_code", "errorcode", "err_code", "errno", "error.code", "err.code", "code"},
+	Exception:  {"exception", "exception_class", "error_class", "error.type", "exception.type", "err.type", "error.kind"},
+}
+
+// order is the order fields are resolved in, so results are deterministic.
+var order = []string{Status, DurationMS, Route, Method, Host, ErrorCode, Exception}
+
+var (
+	logfmtRe = regexp.MustCompile(`(?:^|\s)([A-Za-z_][\w.\-]*)=("(?:[^"\\]|\\.)*"|\S*)`)
+
+	// Request lines: `GET /api/users 503`, `"POST /login HTTP/1.1" 401 12`.
+	requestRe = regexp.MustCompile(`\b(GET|POST|PUT|PATCH|DELETE|HEAD|OPTIONS|CONNECT|TRACE) +(/\S*|https?://\S+)(?: +HTTP/[\d.]+"?)?(?: +(?:-> *)?(\d{3})\b)?`)
+	statusRe  = regexp.MustCompile(`(?i)\b(?:status(?:[ _]?code)?|HTTP/[\d.]+"?)[ :=]+(\d{3})\b`)
+	// Durations after a keyword ("took 1.2s", "latency: 80ms"), else any
+	// number with an ms suffix.
+	durationRe   = regexp.MustCompile(`(?i)\b(?:in|took|duration|latency|elapsed)[ :=]+(\d+(?:\.\d+)?) ?(ms|s|us|µs|ns)\b`)
+	durationMSRe = regexp.MustCompile(`\b(\d+(?:\.\d+)?)ms\b`)
+	urlHostRe    = regexp.MustCompile(`\bhttps?://([^/\s:"'?#]+)`)
+	hostRe       = regexp.MustCompile(`(?i)\bhost[ :=]+"?([\w.-]+\.[a-z]{2,}|[\w.-]+:\d+)\b`)
+	errorCodeRe  = regexp.MustCompile(`\b(E(?:CONNREFUSED|CONNRESET|CONNABORTED|TIMEDOUT|NOTFOUND|ADDRINUSE|ADDRNOTAVAIL|HOSTUNREACH|NETUNREACH|ACCES|PERM|NOENT|PIPE|MFILE|NOSPC|NOMEM|EXIST|BUSY|AI_AGAIN)|ERR_[A-Z0-9_]+)\b` +
+		`|(?i:\b(?:error[ _]code|errno|sqlstate)[ :=]+"?)([\w.-]+)`)
+	exceptionRe = regexp.MustCompile(`\b((?:[a-z_][\w$]*\.)*[A-Z][\w$]*(?:Exception|Error))\b`)
+)
+
+// Extract pulls common fields out of a log line: JSON objects and logfmt
+// key=value pairs are read first, then well-known text shapes (request
+// lines, "took 12ms", ECONNREFUSED, TypeError, ...) fill in what is still
+// missing. Returns nil when nothing was found.
+func Extract(line string) map[string]any {
+	out := make(map[string]any)
+
+	text := line
+	if kv := parseJSON(line); kv != nil {
+		fromStructured(out, kv)
+		// The message of a structured log often holds the request line.
+		text = ""
+		for _, key := range []string{"msg", "message", "error", "err"} {
+			if s, ok := kv[key].(string); ok {
+				text += s + " "
+			}
+		}
+	} else if kv := parseLogfmt(line); kv != nil {
+		fromStructured(out, kv)
+	}
+	fromText(out, text)
+
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// ExtractLog is Extract for a log whose structured fields were moved into
+// metadata, such as a JSON line split by the stdin or file input formats:
+// fields not found in line are read from md.
+func ExtractLog(line string, md map[string]any) map[string]any {
+	out := Extract(line)
+	if len(md) == 0 {
+		return out
+	}
+	flat := make(map[string]any, len(md))
+	flatten(flat, "", md, 0)
+	found := make(map[string]any)
+	fromStructured(found, flat)
+	if len(found) == 0 {
+		return out
+	}
+	if out == nil {
+		out = make(map[string]any, len(found))
+	}
+	for field, val := range found {
+		setDefault(out, field, val)
+	}
+	return out
+}
+
+// fromStructured sets each field from the first alias present in kv.
+func fromStructured(out map[string]any, kv map[string]any) {
+	for _, field := range order {
+		for _, key := range aliases[field] {
+			raw, ok := kv[key]
+			if !ok {
+				continue
+			}
+			if val, ok := convert(field, raw); ok {
+				out[field] = val
+				if field == Route && strings.Contains(toString(raw), "://") {
+					if m := urlHostRe.FindStringSubmatch(toString(raw)); m != nil {
+						setDefault(out, Host, m[1])
+					}
+				}
+				break
+			}
+		}
+	}
+}
+
+// fromText fills fields not yet set from well-known text shapes.
+func fromText(out map[string]any, text string) {
+	if text == "" {
+		return
+	}
+	if m := requestRe.FindStringSubmatch(text); m != nil {
+		setDefault(out, Method, m[1])
+		if route, ok := convert(Route, m[2]); ok {
+			setDefault(out, Route, route)
+		}
+		if m[3] != "" {
+			if status, ok := convert(Status, m[3]); ok {
+				setDefault(out, Status, status)
+			}
+		}
+	}
+	if m := statusRe.FindStringSubmatch(text); m != nil {
+		if status, ok := convert(Status, m[1]); ok {
+			setDefault(out, Status, status)
+		}
+	}
+	if m := durationRe.FindStringSubmatch(text); m != nil {
+		if ms, ok := parseDuration(m[1] + m[2]); ok {
+			setDefault(out, DurationMS, ms)
+		}
+	} else if m := durationMSRe.FindStringSubmatch(text); m != nil {
+		if ms, ok := parseDuration(m[1] + "ms"); ok {
+			setDefault(out, DurationMS, ms)
+		}
+	}
+	if m := urlHostRe.FindStringSubmatch(text); m != nil {
+		setDefault(out, Host, m[1])
+	} else if m := hostRe.FindStringSubmatch(text); m != nil {
+		setDefault(out, Host, m[1])
+	}
+	if m := errorCodeRe.FindStringSubmatch(text); m != nil {
+		setDefault(out, ErrorCode, m[1]+m[2])
+	}
+	if m := exceptionRe.FindStringSubmatch(text); m != nil {
+		setDefault(out, Exception, m[1])
+	}
+}
+
+func setDefault(out map[string]any, field string, val any) {
+	if _, ok := out[field]; !ok {
+		out[field] = val
+	}
+}
+
+// convert normalizes a raw value to the type of field, reporting false
+// when it is not a plausible value for that field.
+func convert(field string, raw any) (any, bool) {
+	switch field {
+	case Status:
+		var code int
+		switch v := raw.(type) {
+		case int:
+			code = v
+		case float64:
+			code = int(v)
+		case string:
+			n, err := strconv.Atoi(strings.TrimSpace(v))
+			if err != nil {
+				return nil, false
+			}
+			code = n
+		default:
+			return nil, false
+		}
+		return code, code >= 100 && code <= 599
+	case DurationMS:
+		switch v := raw.(type) {
+		case float64:
+			return v, v >= 0 // bare numbers are taken as milliseconds
+		case string:
+			return parseDuration(v)
+		}
+		return nil, false
+	case Route:
+		s := toString(raw)
+		if i := strings.Index(s, "://"); i >= 0 {
+			rest := s[i+3:]
+			j := strings.IndexByte(rest, '/')
+			if j < 0 {
+				return nil, false
+			}
+			s = rest[j:]
+		}
+		if i := strings.IndexAny(s, "?#"); i >= 0 {
+			s = s[:i] // query strings carry ids and secrets
+		}
+		return s, strings.HasPrefix(s, "/")
+	case Method:
+		s := strings.ToUpper(toString(raw))
+		switch s {
+		case "GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "CONNECT", "TRACE":
+			return s, true
+		}
+		return nil, false
+	default:
+		s := toString(raw)
+		return s, s != ""
+	}
+}
+
+// parseDuration converts "12", "12ms", "1.5s", "800us" or "250µs" to
+// milliseconds. Numbers without a unit are taken as milliseconds.
+func parseDuration(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	for _, u := range []struct {
+		suffix string
+		scale  float64
+	}{{"ms", 1}, {"µs", 1e-3}, {"us", 1e-3}, {"ns", 1e-6}, {"s", 1e3}} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, scale = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.scale
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v < 0 {
+		return 0, false
+	}
+	return v * scale, true
+}
+
+func toString(raw any) string {
+	switch v := raw.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// parseJSON decodes a JSON object line into a flat map with lower-case,
+// dot-joined keys. Returns nil when line is not a JSON object.
+func parseJSON(line string) map[string]any {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "{") {
+		return nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(line), &obj); err != nil {
+		return nil
+	}
+	flat := make(map[string]any, len(obj))
+	flatten(flat, "", obj, 0)
+	return flat
+}
+
+func flatten(flat map[string]any, prefix string, obj map[string]any, depth int) {
+	for k, v := range obj {
+		key := prefix + strings.ToLower(k)
+		if nested, ok := v.(map[string]any); ok && depth < 3 {
+			flatten(flat, key+".", nested, depth+1)
+			continue
+		}
+		flat[key] = v
+	}
+}
+
+// parseLogfmt returns the key=value pairs in line, with lower-case keys,
+// or nil when it has none.
+func parseLogfmt(line string) map[string]any {
+	matches := logfmtRe.FindAllStringSubmatch(line, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+	kv := make(map[string]any, len(matches))
+	for _, m := range matches {
+		val := m[2]
+		if strings.HasPrefix(val, `"`) {
+			if s, err := strconv.Unquote(val); err == nil {
+				val = s
+			} else {
+				val = strings.Trim(val, `"`)
+			}
+		}
+		kv[strings.ToLower(m[1])] = val
+	}
+	return kv
+}
@@ -0,0 +1,122 @@
+package fields
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want map[string]any
+	}{
+		{
+			name: "json",
+			line: `{"level":"error","msg":"upstream failed","status":503,"duration_ms":1204.5,"path":"/api/checkout?id=7","method":"post"}`,
+			want: map[string]any{Status: 503, DurationMS: 1204.5, Route: "/api/checkout", Method: "POST"},
+		},
+		{
+			name: "json nested",
+			line: `{"http":{"status_code":"404","method":"GET","url":"https://shop.example.com/cart"},"error":{"type":"NotFoundError","code":"E_NOCART"}}`,
+			want: map[string]any{Status: 404, Route: "/cart", Method: "GET", Host: "shop.example.com", ErrorCode: "E_NOCART", Exception: "NotFoundError"},
+		},
+		{
+			name: "json message holds request line",
+			line: `{"level":"info","msg":"GET /healthz 200 in 3ms"}`,
+			want: map[string]any{Status: 200, DurationMS: 3.0, Route: "/healthz", Method: "GET"},
+		},
+		{
+			name: "logfmt",
+			line: `level=warn method=GET path=/api/users status=429 duration=1.5s host=api.internal msg="rate limited"`,
+			want: map[string]any{Status: 429, DurationMS: 1500.0, Route: "/api/users", Method: "GET", Host: "api.internal"},
+		},
+		{
+			name: "common log format",
+			line: `10.0.0.1 - - [19/Feb/2026:12:00:00 +0000] "DELETE /api/items/42 HTTP/1.1" 502 157`,
+			want: map[string]any{Status: 502, Route: "/api/items/42", Method: "DELETE"},
+		},
+		{
+			name: "request line",
+			line: "POST /v1/charges -> 500 (took 812ms)",
+			want: map[string]any{Status: 500, DurationMS: 812.0, Route: "/v1/charges", Method: "POST"},
+		},
+		{
+			name: "node error code",
+			line: "Error: connect ECONNREFUSED 10.0.0.5:5432",
+			want: map[string]any{ErrorCode: "ECONNREFUSED"},
+		},
+		{
+			name: "java exception",
+			line: "Exception in thread \"main\" java.lang.NullPointerException: Cannot invoke method",
+			want: map[string]any{Exception: "java.lang.NullPointerException"},
+		},
+		{
+			name: "python exception and sqlstate",
+			line: "psycopg2.errors.UniqueViolation raised IntegrityError: SQLSTATE 23505 duplicate key",
+			want: map[string]any{Exception: "IntegrityError", ErrorCode: "23505"},
+		},
+		{
+			name: "status outside HTTP range ignored",
+			line: "status=42 exited",
+			want: nil,
+		},
+		{
+			name: "plain text",
+			line: "user signed in",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.line)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Extract(%q)\n got  %v\n want %v", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"12", 12, true},
+		{"12ms", 12, true},
+		{"1.5s", 1500, true},
+		{"800us", 0.8, true},
+		{"250µs", 0.25, true},
+		{"fast", 0, false},
+		{"-3ms", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseDuration(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseDuration(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestExtractLog(t *testing.T) {
+	// A parsed CLF line: the message keeps the request line, the rest is metadata.
+	md := map[string]any{"status": 502, "client_ip": "10.0.0.1", "user_agent": "curl/8.5.0"}
+	got := ExtractLog("GET /api/users?id=7 HTTP/1.1 502", md)
+	want := map[string]any{Status: 502, Route: "/api/users", Method: "GET"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractLog = %v, want %v", got, want)
+	}
+
+	// Fields found in the message win over metadata.
+	md = map[string]any{"http": map[string]any{"status_code": 404, "method": "PUT"}, "error": map[string]any{"code": "E_CART"}}
+	got = ExtractLog("GET /cart 200", md)
+	want = map[string]any{Status: 200, Route: "/cart", Method: "GET", ErrorCode: "E_CART"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractLog = %v, want %v", got, want)
+	}
+
+	if got := ExtractLog("nothing to see", nil); got != nil {
+		t.Errorf("ExtractLog without fields = %v, want nil", got)
+	}
+}
@@ -0,0 +1,92 @@
+package normalize
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Placeholders substituted for variable tokens by Template.
+const (
+	Timestamp = "<ts>"
+	UUID      = "<uuid>"
+	IP        = "<ip>"
+	Hex       = "<hex>"
+	Number    = "<num>"
+)
+
+var (
+	timestampRe = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?` +
+		`|\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec) +\d{1,2} \d{2}:\d{2}:\d{2}\b` +
+		`|\d{2}/(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)/\d{4}:\d{2}:\d{2}:\d{2}(?: [+-]\d{4})?`)
+	uuidRe   = regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`)
+	ipv4Re   = regexp.MustCompile(`\b\d{1,3}(?:\.\d{1,3}){3}(?::\d{1,5})?\b`)
+	ipv6Re   = regexp.MustCompile(`\b(?:[0-9a-fA-F]{1,4}:){3,7}[0-9a-fA-F]{1,4}\b`)
+	hexRe    = regexp.MustCompile(`\b0x[0-9a-fA-F]+\b|\b[0-9a-fA-F]{8,}\b`)
+	numberRe = regexp.MustCompile(`\d+(?:\.\d+)*`)
+)
+
+// Template masks the variable parts of a log line — timestamps, UUIDs, IP
+// addresses, hex ids and numbers — so that lines differing only in those
+// values map to the same string.
+//
+// Standalone three-digit numbers from 100 to 599 are kept: they are usually
+// HTTP status codes, and "GET /api 200" and "GET /api 503" must not share a
+// template.
+func Template(line string) string {
+	s := timestampRe.ReplaceAllString(line, Timestamp)
+	s = uuidRe.ReplaceAllString(s, UUID)
+	s = ipv4Re.ReplaceAllString(s, IP)
+	s = ipv6Re.ReplaceAllStringFunc(s, func(m string) string {
+		if !strings.ContainsAny(m, "abcdefABCDEF") && strings.Count(m, ":") < 4 {
+			return m // clock times such as 12:00:00:123
+		}
+		return IP
+	})
+	s = hexRe.ReplaceAllStringFunc(s, func(m string) string {
+		if !strings.HasPrefix(m, "0x") && !strings.ContainsAny(m, "0123456789") {
+			return m // words made of a-f letters only
+		}
+		return Hex
+	})
+	return maskNumbers(s)
+}
+
+// maskNumbers replaces digit runs (including dotted versions like 1.2.3)
+// with Number, keeping likely HTTP status codes.
+func maskNumbers(s string) string {
+	locs := numberRe.FindAllStringIndex(s, -1)
+	if len(locs) == 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	last := 0
+	for _, loc := range locs {
+		if isStatusCode(s, loc[0], loc[1]) {
+			continue
+		}
+		b.WriteString(s[last:loc[0]])
+		b.WriteString(Number)
+		last = loc[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// isStatusCode reports whether s[start:end] is a standalone number in 100-599.
+func isStatusCode(s string, start, end int) bool {
+	if end-start != 3 || s[start] < '1' || s[start] > '5' || strings.Contains(s[start:end], ".") {
+		return false
+	}
+	if start > 0 && isWordByte(s[start-1]) {
+		return false
+	}
+	if end < len(s) && isWordByte(s[end]) {
+		return false
+	}
+	return true
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,80 @@
+package normalize
+
+import "testing"
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"iso timestamp",
+			"2026-02-19T12:00:00.123Z connection refused",
+			"<ts> connection refused",
+		},
+		{
+			"syslog timestamp",
+			"Feb 19 12:00:00 host sshd: session opened",
+			"<ts> host sshd: session opened",
+		},
+		{
+			"uuid",
+			"request 550e8400-e29b-41d4-a716-446655440000 failed",
+			"request <uuid> failed",
+		},
+		{
+			"ipv4 with port",
+			"dial tcp 10.0.0.12:5432: connect: connection refused",
+			"dial tcp <ip>: connect: connection refused",
+		},
+		{
+			"ipv6",
+			"client 2001:db8:85a3:0:0:8a2e:370:7334 disconnected",
+			"client <ip> disconnected",
+		},
+		{
+			"hex ids",
+			"trace 4bf92f3577b34da6 span 0x1f failed",
+			"trace <hex> span <hex> failed",
+		},
+		{
+			"hex-letter words kept",
+			"deadbeef facade",
+			"deadbeef facade",
+		},
+		{
+			"numbers and versions",
+			"user 48213 took 1.52s on v2.3.1",
+			"user <num> took <num>s on v<num>",
+		},
+		{
+			"status codes kept",
+			`"GET /api/users HTTP/1.1" 503 1024`,
+			`"GET /api/users HTTP/<num>" 503 <num>`,
+		},
+		{
+			"status-like digits inside words masked",
+			"order 12500 rejected, attempt 2",
+			"order <num> rejected, attempt <num>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Template(tt.in); got != tt.want {
+				t.Errorf("Template(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateGroupsVariants(t *testing.T) {
+	a := Template("2026-02-19T12:00:00Z payment 8812 failed for 10.0.0.1 (card 4bf92f3577b34da6)")
+	b := Template("2026-02-20T08:15:42Z payment 93 failed for 192.168.1.7 (card 0a1b2c3d4e5f6789)")
+	if a != b {
+		t.Errorf("templates differ:\n  %s\n  %s", a, b)
+	}
+	if Template("GET /health 200") == Template("GET /health 500") {
+		t.Error("status codes should not share a template")
+	}
+}
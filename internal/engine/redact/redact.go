@@ -0,0 +1,322 @@
+// Package redact removes PII and secrets from log text before it is
+// embedded, compacted or written: emails, IPs, JWTs, bearer tokens, API
+// keys, card numbers, passwords and custom patterns.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Mode is how a detected value is replaced.
+type Mode string
+
+const (
+	// ModeMask replaces the value with [REDACTED:<detector>].
+	ModeMask Mode = "mask"
+	// ModeHash replaces the value with [<detector>:<hash>], a truncated
+	// SHA-256 that keeps equal values correlatable without revealing them.
+	ModeHash Mode = "hash"
+	// ModeDrop removes the value.
+	ModeDrop Mode = "drop"
+)
+
+// Built-in detector names.
+const (
+	Password   = "password"
+	APIKey     = "api_key"
+	Bearer     = "bearer"
+	JWT        = "jwt"
+	Email      = "email"
+	CreditCard = "credit_card"
+	IP         = "ip"
+)
+
+// builtins are the built-in detectors in the order they run: key=value
+// secrets first, so "password=a@b.c" is reported as a password rather than
+// an email. When a pattern has a capture group, only the group is
+// replaced and the key or scheme before it is kept. An isolated match must
+// not touch a letter, digit or underscore, which regexp cannot express for
+// patterns that do not start and end with a word character.
+var builtins = []struct {
+	name     string
+	pattern  string
+	valid    func(string) bool
+	isolated bool
+}{
+	{Password, `(?i)\b(?:password|passwd|pwd|pass)["']?\s*[:=]\s*["']?([^\s&"',;}]+)`, nil, false},
+	{APIKey, `(?i)\b(?:api[_-]?key|apikey|secret|client[_-]?secret|access[_-]?token|auth[_-]?token|token)["']?\s*[:=]\s*["']?([A-Za-z0-9_\-.+/=]{8,})`, nil, false},
+	{APIKey, `\b(AKIA[0-9A-Z]{16}|gh[pousr]_[A-Za-z0-9]{36}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_\-]{35}|sk_(?:live|test)_[A-Za-z0-9]{16,}|sk-[A-Za-z0-9_\-]{20,})\b`, nil, false},
+	{Bearer, `(?i)\bbearer\s+([A-Za-z0-9_\-.~+/]{8,}=*)`, nil, false},
+	{JWT, `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`, nil, false},
+	{Email, `\b[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}\b`, nil, false},
+	{CreditCard, `\b[2-6](?:[ -]?\d){12,18}\b`, luhn, false},
+	{IP, `\b(?:\d{1,3}\.){3}\d{1,3}\b`, validIP, false},
+	{IP, `(?i)[0-9a-f]{0,4}(?::[0-9a-f]{0,4}){2,7}`, validIPv6, true},
+}
+
+// Detectors returns the names of the built-in detectors.
+func Detectors() []string {
+	return []string{Password, APIKey, Bearer, JWT, Email, CreditCard, IP}
+}
+
+// Pattern is a custom detector: values matching Regex are redacted and
+// counted under Name. When Regex has a capture group, only the first
+// group is replaced.
+type Pattern struct {
+	Name  string
+	Regex string
+}
+
+// Config selects the detectors and the replacement mode.
+type Config struct {
+	// Detectors are the built-in detectors to run, or "all" for every
+	// one. Empty runs only the custom patterns.
+	Detectors []string
+	// Patterns are custom detectors, run after the built-ins.
+	Patterns []Pattern
+	// Mode is how values are replaced. Default: ModeMask.
+	Mode Mode
+}
+
+type detector struct {
+	name     string
+	re       *regexp.Regexp
+	valid    func(string) bool
+	isolated bool          // the match must not touch a word character
+	count    *atomic.Int64 // shared by detectors with the same name
+}
+
+// Redactor replaces sensitive values in text and keeps a count of
+// redactions per detector. Safe for concurrent use. A nil *Redactor
+// returns its input unchanged.
+type Redactor struct {
+	mode      Mode
+	detectors []detector
+	counts    map[string]*atomic.Int64
+}
+
+// New compiles the configured detectors, reporting every invalid name,
+// pattern or mode.
+func New(cfg Config) (*Redactor, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = ModeMask
+	}
+	var errs []string
+	switch cfg.Mode {
+	case ModeMask, ModeHash, ModeDrop:
+	default:
+		errs = append(errs, fmt.Sprintf("invalid redaction mode %q (must be mask|hash|drop)", cfg.Mode))
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range cfg.Detectors {
+		enabled[name] = true
+	}
+	all := enabled["all"]
+	delete(enabled, "all")
+
+	r := &Redactor{mode: cfg.Mode, counts: make(map[string]*atomic.Int64)}
+	for _, b := range builtins {
+		if !all && !enabled[b.name] {
+			continue
+		}
+		r.add(b.name, regexp.MustCompile(b.pattern), b.valid, b.isolated)
+	}
+	for _, name := range Detectors() {
+		delete(enabled, name)
+	}
+	for name := range enabled {
+		errs = append(errs, fmt.Sprintf("unknown redaction detector %q (must be all or one of %s)", name, strings.Join(Detectors(), ", ")))
+	}
+
+	for _, p := range cfg.Patterns {
+		if p.Name == "" {
+			errs = append(errs, fmt.Sprintf("redaction pattern %q: missing name", p.Regex))
+			continue
+		}
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("redaction pattern %q: %v", p.Name, err))
+			continue
+		}
+		r.add(p.Name, re, nil, false)
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return nil, errors.New(strings.Join(errs, "; "))
+	}
+	return r, nil
+}
+
+func (r *Redactor) add(name string, re *regexp.Regexp, valid func(string) bool, isolated bool) {
+	c, ok := r.counts[name]
+	if !ok {
+		c = new(atomic.Int64)
+		r.counts[name] = c
+	}
+	r.detectors = append(r.detectors, detector{name: name, re: re, valid: valid, isolated: isolated, count: c})
+}
+
+// Redact returns s with every detected value replaced.
+func (r *Redactor) Redact(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, d := range r.detectors {
+		s = r.apply(d, s)
+	}
+	return s
+}
+
+func (r *Redactor) apply(d detector, s string) string {
+	matches := d.re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3] // first capture group
+		}
+		value := s[start:end]
+		if value == "" || isReplacement(value) || d.valid != nil && !d.valid(value) {
+			continue
+		}
+		if d.isolated && (start > 0 && isWordByte(s[start-1]) || end < len(s) && isWordByte(s[end])) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(r.replacement(d.name, value))
+		last = end
+		d.count.Add(1)
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func (r *Redactor) replacement(name, value string) string {
+	switch r.mode {
+	case ModeHash:
+		sum := sha256.Sum256([]byte(value))
+		return "[" + name + ":" + hex.EncodeToString(sum[:6]) + "]"
+	case ModeDrop:
+		return ""
+	default:
+		return "[REDACTED:" + name + "]"
+	}
+}
+
+// isReplacement reports whether value is the output of an earlier
+// detector, so a masked password is not masked again as an API key.
+func isReplacement(value string) bool {
+	return strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]")
+}
+
+// RedactMap returns a copy of m with string values redacted, recursing
+// into nested maps and slices. Returns m itself when r is nil.
+func (r *Redactor) RedactMap(m map[string]any) map[string]any {
+	if r == nil || m == nil {
+		return m
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = r.redactValue(v)
+	}
+	return out
+}
+
+func (r *Redactor) redactValue(v any) any {
+	switch v := v.(type) {
+	case string:
+		return r.Redact(v)
+	case map[string]any:
+		return r.RedactMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = r.redactValue(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Counts returns the number of values redacted so far per detector,
+// omitting detectors that have not fired.
+func (r *Redactor) Counts() map[string]int64 {
+	if r == nil {
+		return nil
+	}
+	out := make(map[string]int64)
+	for name, c := range r.counts {
+		if n := c.Load(); n > 0 {
+			out[name] = n
+		}
+	}
+	return out
+}
+
+// luhn reports whether the digits in s pass the Luhn checksum used by
+// payment card numbers. The pattern only admits numbers starting with a
+// card network prefix (2-6), so epoch timestamps and most ids never get
+// here.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+func validIP(s string) bool {
+	return net.ParseIP(s) != nil
+}
+
+// validIPv6 accepts an IPv6 address with a digit or at least three hex
+// groups, so a short all-letter match such as "d::" or "::Ba" in
+// "std::io" or "Foo::Bar" is not taken for one.
+func validIPv6(s string) bool {
+	if !validIP(s) {
+		return false
+	}
+	if strings.ContainsAny(s, "0123456789") {
+		return true
+	}
+	groups := 0
+	for _, g := range strings.Split(s, ":") {
+		if g != "" {
+			groups++
+		}
+	}
+	return groups >= 3
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,266 @@
+// Package rules provides declarative pre-classification: regex and JSON-field
+// matchers mapped to taxonomy paths, evaluated before embedding.
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Rule maps logs matching all of its conditions to a taxonomy path.
+// At least one of Pattern or Field must be set.
+type Rule struct {
+	Name     string `json:"name" yaml:"name"`
+	Path     string `json:"path" yaml:"path"`                             // taxonomy path, e.g. "ERROR.runtime_exception"
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"` // overrides the label's severity
+
+	// Pattern is a regular expression matched against the raw log line.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+
+	// Field is a dot-separated key into a JSON log line, e.g. "level" or
+	// "http.status". The field must exist and, when Equals or Match is set,
+	// its value must equal one of Equals (case-insensitive) or match Match.
+	Field  string   `json:"field,omitempty" yaml:"field,omitempty"`
+	Equals []string `json:"equals,omitempty" yaml:"equals,omitempty"`
+	Match  string   `json:"match,omitempty" yaml:"match,omitempty"`
+}
+
+// Set is an ordered, compiled list of rules. The first matching rule wins.
+// A Set is immutable and safe for concurrent use.
+type Set struct {
+	rules []compiled
+}
+
+type compiled struct {
+	Rule
+	pattern *regexp.Regexp
+	field   []string
+	match   *regexp.Regexp
+}
+
+// validSeverities are the severities a rule may set.
+var validSeverities = map[string]bool{"error": true, "warning": true, "info": true, "debug": true}
+
+// fileFormat is the on-disk rules format.
+type fileFormat struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// LoadFile reads a rules file. The format is chosen by extension: .json, or
+// .yaml/.yml.
+func LoadFile(path string) (*Set, error) {
+	var unmarshal func([]byte, *fileFormat) error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		unmarshal = func(data []byte, f *fileFormat) error {
+			dec := json.NewDecoder(bytes.NewReader(data))
+			dec.DisallowUnknownFields()
+			return dec.Decode(f)
+		}
+	case ".yaml", ".yml":
+		unmarshal = func(data []byte, f *fileFormat) error {
+			dec := yaml.NewDecoder(bytes.NewReader(data))
+			dec.KnownFields(true)
+			return dec.Decode(f)
+		}
+	default:
+		return nil, fmt.Errorf("rules: %s: unsupported file extension %q (must be .json, .yaml or .yml)", path, ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+	var f fileFormat
+	if err := unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("rules: %s: parse: %w", path, err)
+	}
+	set, err := New(f.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("rules: %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// New compiles rules into a Set, reporting every invalid rule.
+func New(rules []Rule) (*Set, error) {
+	set := &Set{rules: make([]compiled, 0, len(rules))}
+	var errs []string
+	for i, r := range rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		c := compiled{Rule: r}
+		if r.Pattern == "" && r.Field == "" {
+			errs = append(errs, fmt.Sprintf("rule %s: needs a pattern or a field", name))
+		}
+		if typ, leaf, ok := strings.Cut(r.Path, "."); !ok || typ == "" || leaf == "" {
+			errs = append(errs, fmt.Sprintf("rule %s: path %q must be TYPE.category", name, r.Path))
+		}
+		if r.Severity != "" && !validSeverities[r.Severity] {
+			errs = append(errs, fmt.Sprintf("rule %s: unknown severity %q (must be error|warning|info|debug)", name, r.Severity))
+		}
+		if r.Pattern != "" {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("rule %s: pattern: %s", name, err))
+			}
+			c.pattern = re
+		}
+		if r.Field != "" {
+			c.field = strings.Split(r.Field, ".")
+		} else if len(r.Equals) > 0 || r.Match != "" {
+			errs = append(errs, fmt.Sprintf("rule %s: equals/match require a field", name))
+		}
+		if r.Match != "" {
+			re, err := regexp.Compile(r.Match)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("rule %s: match: %s", name, err))
+			}
+			c.match = re
+		}
+		set.rules = append(set.rules, c)
+	}
+	if len(errs) > 0 {
+		return nil, errors.New("invalid rules:\n  - " + strings.Join(errs, "\n  - "))
+	}
+	return set, nil
+}
+
+// Len returns the number of rules in the set.
+func (s *Set) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// Rules returns the rules in evaluation order.
+func (s *Set) Rules() []Rule {
+	if s == nil {
+		return nil
+	}
+	out := make([]Rule, len(s.rules))
+	for i, r := range s.rules {
+		out[i] = r.Rule
+	}
+	return out
+}
+
+// CheckPaths reports rules whose path is not one of the taxonomy labels.
+func (s *Set) CheckPaths(labels []model.EmbeddedLabel) error {
+	known := make(map[string]bool, len(labels))
+	for _, lbl := range labels {
+		known[lbl.Path] = true
+	}
+	var errs []string
+	for _, r := range s.rules {
+		if !known[r.Path] {
+			errs = append(errs, fmt.Sprintf("rule %q: path %q is not in the taxonomy", r.Name, r.Path))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid rules:\n  - " + strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// Match returns the first rule matching raw.
+func (s *Set) Match(raw string) (Rule, bool) {
+	if s == nil {
+		return Rule{}, false
+	}
+
+	// Decode JSON at most once, and only when a field rule needs it.
+	var doc map[string]any
+	decoded := false
+	for _, r := range s.rules {
+		if r.pattern != nil && !r.pattern.MatchString(raw) {
+			continue
+		}
+		if r.field != nil {
+			if !decoded {
+				doc = decodeJSON(raw)
+				decoded = true
+			}
+			if !r.matchField(doc) {
+				continue
+			}
+		}
+		return r.Rule, true
+	}
+	return Rule{}, false
+}
+
+// matchField reports whether the rule's field exists in doc and satisfies
+// Equals and Match.
+func (c compiled) matchField(doc map[string]any) bool {
+	if doc == nil {
+		return false
+	}
+	var v any = doc
+	for _, key := range c.field {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return false
+		}
+		if v, ok = m[key]; !ok {
+			return false
+		}
+	}
+	value := stringify(v)
+	if len(c.Equals) > 0 {
+		found := false
+		for _, want := range c.Equals {
+			if strings.EqualFold(value, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return c.match == nil || c.match.MatchString(value)
+}
+
+// decodeJSON parses raw as a JSON object, returning nil for anything else.
+func decodeJSON(raw string) map[string]any {
+	trimmed := strings.TrimSpace(raw)
+	if !strings.HasPrefix(trimmed, "{") {
+		return nil
+	}
+	var doc map[string]any
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// stringify renders a decoded JSON value for comparison.
+func stringify(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
@@ -0,0 +1,147 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func mustNew(t *testing.T, rules []Rule) *Set {
+	t.Helper()
+	set, err := New(rules)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return set
+}
+
+func TestMatchPattern(t *testing.T) {
+	set := mustNew(t, []Rule{
+		{Name: "panic", Pattern: `^panic: `, Path: "ERROR.runtime_exception"},
+		{Name: "oom", Pattern: `(?i)out of memory`, Path: "ERROR.out_of_memory"},
+	})
+
+	r, ok := set.Match("panic: runtime error: invalid memory address")
+	if !ok || r.Name != "panic" {
+		t.Fatalf("expected panic rule, got %+v ok=%v", r, ok)
+	}
+	if r, ok := set.Match("fatal error: Out Of Memory"); !ok || r.Name != "oom" {
+		t.Fatalf("expected oom rule, got %+v ok=%v", r, ok)
+	}
+	if _, ok := set.Match("GET /health 200"); ok {
+		t.Fatal("unexpected match")
+	}
+}
+
+func TestMatchFirstRuleWins(t *testing.T) {
+	set := mustNew(t, []Rule{
+		{Name: "first", Pattern: "error", Path: "ERROR.runtime_exception"},
+		{Name: "second", Pattern: "error", Path: "ERROR.dependency_error"},
+	})
+	if r, _ := set.Match("an error"); r.Name != "first" {
+		t.Fatalf("got %q, want first", r.Name)
+	}
+}
+
+func TestMatchField(t *testing.T) {
+	set := mustNew(t, []Rule{
+		{Name: "fatal", Field: "level", Equals: []string{"fatal", "critical"}, Path: "ERROR.runtime_exception"},
+		{Name: "5xx", Field: "http.status", Match: `^5\d\d$`, Path: "REQUEST.server_error"},
+		{Name: "has-trace", Field: "stack", Pattern: "Exception", Path: "ERROR.runtime_exception"},
+	})
+
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{`{"level":"FATAL","msg":"boom"}`, "fatal"},
+		{`{"level":"info","http":{"status":503}}`, "5xx"},
+		{`{"level":"info","http":{"status":200}}`, ""},
+		{`{"stack":"...","msg":"NullPointerException"}`, "has-trace"},
+		{`{"msg":"NullPointerException"}`, ""}, // field missing
+		{`level=fatal msg=boom`, ""},           // not JSON
+		{`{"level": "fatal"`, ""},              // malformed JSON
+	}
+	for _, tt := range tests {
+		r, ok := set.Match(tt.raw)
+		if got := r.Name; ok != (tt.want != "") || got != tt.want {
+			t.Errorf("Match(%s) = %q ok=%v, want %q", tt.raw, got, ok, tt.want)
+		}
+	}
+}
+
+func TestNewInvalid(t *testing.T) {
+	_, err := New([]Rule{
+		{Name: "empty", Path: "ERROR.timeout"},
+		{Name: "bad-path", Pattern: "x", Path: "timeout"},
+		{Name: "bad-regex", Pattern: "(", Path: "ERROR.timeout"},
+		{Name: "bad-severity", Pattern: "x", Path: "ERROR.timeout", Severity: "fatal"},
+		{Name: "equals-no-field", Pattern: "x", Equals: []string{"y"}, Path: "ERROR.timeout"},
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	for _, want := range []string{"rule empty: needs a pattern or a field", "rule bad-path: path", "rule bad-regex: pattern",
+		"rule bad-severity: unknown severity", "rule equals-no-field: equals/match require a field"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestCheckPaths(t *testing.T) {
+	set := mustNew(t, []Rule{{Name: "r", Pattern: "x", Path: "PAYMENTS.refund"}})
+	labels := []model.EmbeddedLabel{{Path: "ERROR.timeout"}}
+	if err := set.CheckPaths(labels); err == nil || !strings.Contains(err.Error(), "not in the taxonomy") {
+		t.Fatalf("expected unknown path error, got: %v", err)
+	}
+	labels = append(labels, model.EmbeddedLabel{Path: "PAYMENTS.refund"})
+	if err := set.CheckPaths(labels); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestNilSet(t *testing.T) {
+	var set *Set
+	if _, ok := set.Match("anything"); ok {
+		t.Fatal("nil set should not match")
+	}
+	if set.Len() != 0 {
+		t.Fatal("nil set should be empty")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"rules.json": `{"rules":[{"name":"panic","pattern":"^panic: ","path":"ERROR.runtime_exception"}]}`,
+		"rules.yaml": "rules:\n  - name: panic\n    pattern: '^panic: '\n    path: ERROR.runtime_exception\n",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		set, err := LoadFile(path)
+		if err != nil {
+			t.Fatalf("LoadFile(%s) error: %v", name, err)
+		}
+		if _, ok := set.Match("panic: boom"); !ok {
+			t.Errorf("%s: expected match", name)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"rules":[{"name":"x","regex":"y"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(bad); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatalf("expected unknown field error, got: %v", err)
+	}
+	if _, err := LoadFile(filepath.Join(dir, "rules.toml")); err == nil || !strings.Contains(err.Error(), "unsupported file extension") {
+		t.Fatalf("expected extension error, got: %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+package severity
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Normalized severities, from most to least severe. These match the
+// severities used by taxonomy leaves.
+const (
+	Error   = "error"
+	Warning = "warning"
+	Info    = "info"
+	Debug   = "debug"
+)
+
+// Policy selects how the event severity is derived from the taxonomy
+// severity and the level declared by the log itself.
+type Policy string
+
+const (
+	// PolicyTaxonomy uses the matched label's severity; the source level is
+	// only reported.
+	PolicyTaxonomy Policy = "taxonomy"
+	// PolicySource uses the source level when the log declares one.
+	PolicySource Policy = "source"
+	// PolicyMax uses the more severe of the two.
+	PolicyMax Policy = "max"
+)
+
+var rank = map[string]int{Debug: 1, Info: 2, Warning: 3, Error: 4}
+
+// Reconcile returns the event severity under policy p. src is the
+// normalized source level, or "" when the log declares none.
+func (p Policy) Reconcile(taxonomy, src string) string {
+	if src == "" {
+		return taxonomy
+	}
+	switch p {
+	case PolicySource:
+		return src
+	case PolicyMax:
+		if rank[src] > rank[taxonomy] {
+			return src
+		}
+	}
+	return taxonomy
+}
+
+// levels maps level names, lower-cased, to normalized severities.
+var levels = map[string]string{
+	"emerg": Error, "emergency": Error, "alert": Error, "crit": Error, "critical": Error,
+	"fatal": Error, "panic": Error, "severe": Error, "error": Error, "err": Error, "e": Error, "f": Error,
+	"warning": Warning, "warn": Warning, "w": Warning,
+	"notice": Info, "info": Info, "information": Info, "informational": Info, "i": Info,
+	"debug": Debug, "trace": Debug, "verbose": Debug, "fine": Debug, "d": Debug,
+}
+
+// Normalize maps a level name (ERROR, warn, Critical, ...) or a numeric
+// level to error, warning, info or debug. Numbers up to 7 are syslog
+// priorities; larger ones are bunyan/pino levels (10 trace ... 60 fatal).
+// Returns "" for unknown values.
+func Normalize(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if sev, ok := levels[level]; ok {
+		return sev
+	}
+	if n, err := strconv.Atoi(level); err == nil {
+		return fromNumber(n)
+	}
+	return ""
+}
+
+func fromNumber(n int) string {
+	switch {
+	case n < 0:
+		return ""
+	case n <= 3: // syslog emerg, alert, crit, err
+		return Error
+	case n == 4:
+		return Warning
+	case n <= 6:
+		return Info
+	case n == 7:
+		return Debug
+	case n <= 20: // pino/bunyan trace, debug
+		return Debug
+	case n <= 30:
+		return Info
+	case n <= 40:
+		return Warning
+	default:
+		return Error
+	}
+}
+
+// levelKeys are the structured keys that carry a log level, in order of
+// preference.
+var levelKeys = []string{"level", "severity", "lvl", "loglevel", "log_level", "levelname", "log.level", "severity_text"}
+
+var (
+	// <PRI> prefix of syslog lines; the severity is PRI mod 8.
+	syslogRe = regexp.MustCompile(`^<(\d{1,3})>`)
+	// glog/klog header: E0219 12:00:00.000000 ...
+	glogRe   = regexp.MustCompile(`^([IWEF])\d{4} \d{2}:\d{2}:\d{2}`)
+	logfmtRe = regexp.MustCompile(`(?i)(?:^|\s)(?:level|lvl|severity)="?(\w+)`)
+	// Upper-case level tokens, optionally bracketed: "ERROR", "[WARN]", "<INFO>".
+	tokenRe = regexp.MustCompile(`(?:^|[\s\[(<|:])(EMERG|ALERT|CRIT|CRITICAL|FATAL|PANIC|SEVERE|ERROR|ERR|WARNING|WARN|NOTICE|INFO|DEBUG|TRACE)(?:$|[\s\])>|:!])`)
+)
+
+// tokenWindow limits level-token detection to the start of the line, where
+// prefixes put the level; "retrying after ERROR from upstream" deep in a
+// message is not a declared level.
+const tokenWindow = 80
+
+// Detect returns the normalized level declared by a log line, falling back
+// to the level fields of its metadata, as set by connectors or by a
+// structured input format that split the line into message and fields. It
+// recognizes JSON level fields, logfmt level=, syslog <PRI> prefixes, glog
+// headers and upper-case level tokens near the start of the line. Returns
+// "" when the log declares no level.
+func Detect(line string, md map[string]any) string {
+	if sev := fromLine(line); sev != "" {
+		return sev
+	}
+	return fromJSON(lowerKeys(md))
+}
+
+func lowerKeys(obj map[string]any) map[string]any {
+	lower := make(map[string]any, len(obj))
+	for k, v := range obj {
+		lower[strings.ToLower(k)] = v
+	}
+	return lower
+}
+
+func fromLine(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "{") {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(line), &obj); err == nil {
+			return fromJSON(lowerKeys(obj))
+		}
+	}
+	if m := syslogRe.FindStringSubmatch(line); m != nil {
+		if pri, err := strconv.Atoi(m[1]); err == nil && pri <= 191 {
+			return fromNumber(pri % 8)
+		}
+	}
+	if m := glogRe.FindStringSubmatch(line); m != nil {
+		return Normalize(m[1])
+	}
+	if m := logfmtRe.FindStringSubmatch(line); m != nil {
+		if sev := Normalize(m[1]); sev != "" {
+			return sev
+		}
+	}
+	head := line
+	if len(head) > tokenWindow {
+		head = head[:tokenWindow]
+	}
+	if m := tokenRe.FindStringSubmatch(head); m != nil {
+		return Normalize(m[1])
+	}
+	return ""
+}
+
+func fromJSON(obj map[string]any) string {
+	for _, key := range levelKeys {
+		val, ok := obj[key]
+		if !ok && strings.Contains(key, ".") {
+			parent, child, _ := strings.Cut(key, ".")
+			if nested, isMap := obj[parent].(map[string]any); isMap {
+				val, ok = nested[child]
+			}
+		}
+		if !ok {
+			continue
+		}
+		switch v := val.(type) {
+		case string:
+			if sev := Normalize(v); sev != "" {
+				return sev
+			}
+		case float64:
+			if sev := fromNumber(int(v)); sev != "" {
+				return sev
+			}
+		case int:
+			if sev := fromNumber(v); sev != "" {
+				return sev
+			}
+		}
+	}
+	return ""
+}
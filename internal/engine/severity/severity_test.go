@@ -0,0 +1,109 @@
+package severity
+
+import (
+	"testing"
+
+	"github.com/kaminocorp/lumber/internal/connector/format"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		md   map[string]any
+		want string
+	}{
+		{"json level", `{"level":"error","msg":"db down"}`, nil, Error},
+		{"json upper-case key", `{"Level":"Warning","msg":"slow"}`, nil, Warning},
+		{"json pino number", `{"level":50,"msg":"boom"}`, nil, Error},
+		{"json nested", `{"log":{"level":"debug"},"message":"tick"}`, nil, Debug},
+		{"json severity", `{"severity":"CRITICAL"}`, nil, Error},
+		{"logfmt", `ts=2026-02-19T12:00:00Z level=warn msg="disk 91%"`, nil, Warning},
+		{"syslog priority", `<11>Feb 19 12:00:00 host app: failed`, nil, Error},
+		{"syslog notice", `<13>Feb 19 12:00:00 host app: started`, nil, Info},
+		{"glog", `W0219 12:00:00.123456    1 reflector.go:324] watch closed`, nil, Warning},
+		{"text token", `2026-02-19 12:00:00 ERROR connection refused`, nil, Error},
+		{"bracketed token", `[WARN] retrying request`, nil, Warning},
+		{"npm", `npm ERR! code ELIFECYCLE`, nil, Error},
+		{"token too deep", "processed 1200 records in batch 42 for tenant acme-corp, continuing with next batch after ERROR", nil, ""},
+		{"lower-case word ignored", "0 errors, 0 warnings", nil, ""},
+		{"metadata fallback", "connection refused", map[string]any{"level": "warning"}, Warning},
+		{"line wins over metadata", "INFO request served", map[string]any{"level": "error"}, Info},
+		{"unknown metadata", "request served", map[string]any{"level": "stdout"}, ""},
+		{"none", "user signed in", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.line, tt.md); got != tt.want {
+				t.Errorf("Detect(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDetect_ParsedFormat covers lines split by the stdin and file
+// connectors, whose level is left only in the metadata.
+func TestDetect_ParsedFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"pino number", `{"level":50,"msg":"db down"}`, Error},
+		{"nested log.level", `{"log":{"level":"warn"},"message":"slow query"}`, Warning},
+		{"upper-case key", `{"Severity":"DEBUG","msg":"tick"}`, Debug},
+		{"syslog priority", `<11>Feb 19 12:00:00 host app[42]: failed`, Error},
+		{"logfmt", `level=warn msg="disk 91%"`, Warning},
+	}
+	p, err := format.New(format.Auto, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, fields := p.Parse(tt.line)
+			if msg == tt.line {
+				t.Fatalf("%q was not parsed", tt.line)
+			}
+			if got := Detect(msg, fields); got != tt.want {
+				t.Errorf("Detect(%q, %v) = %q, want %q", msg, fields, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"ERROR": Error, "fatal": Error, "Crit": Error, "3": Error,
+		"WARN": Warning, "4": Warning, "40": Warning,
+		"notice": Info, "30": Info,
+		"trace": Debug, "7": Debug, "10": Debug,
+		"stdout": "", "": "",
+	}
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	tests := []struct {
+		policy        Policy
+		taxonomy, src string
+		want          string
+	}{
+		{PolicyTaxonomy, Info, Error, Info},
+		{PolicySource, Info, Error, Error},
+		{PolicySource, Error, Debug, Debug},
+		{PolicySource, Error, "", Error},
+		{PolicyMax, Info, Error, Error},
+		{PolicyMax, Error, Warning, Error},
+		{PolicyMax, Warning, "", Warning},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.Reconcile(tt.taxonomy, tt.src); got != tt.want {
+			t.Errorf("%s.Reconcile(%q, %q) = %q, want %q", tt.policy, tt.taxonomy, tt.src, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+// Package stacktrace parses stack traces from Java (and other JVM
+// languages), Go, Python, Node.js, Rust, .NET and Ruby into an exception
+// type, message and frames, and truncates them keeping app-owned frames
+// over library frames.
+package stacktrace
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Languages, as reported in Trace.Language.
+const (
+	Java   = "java"
+	Go     = "go"
+	Python = "python"
+	Node   = "node"
+	Rust   = "rust"
+	DotNet = "dotnet"
+	Ruby   = "ruby"
+)
+
+// tailFrames is the number of trailing frames always kept when truncating:
+// the outermost frames for most languages, the raising frame for Python.
+const tailFrames = 2
+
+// Frame is a parsed stack frame.
+type Frame struct {
+	model.Frame
+	start, end int // lines[start:end] hold the frame
+}
+
+// Trace is a parsed stack trace.
+type Trace struct {
+	Language  string
+	Exception string // exception or panic type, e.g. java.lang.NullPointerException
+	Message   string
+	Frames    []Frame
+
+	text  string
+	lines []string
+}
+
+// Frame patterns. Each language is detected by its own frame syntax, and
+// only that language's patterns are used to parse the trace, so a Rust
+// "at file:line:col" location is never mistaken for a Node.js frame.
+var (
+	pythonHeaderRe = regexp.MustCompile(`^Traceback \(most recent call last\):`)
+	pythonFrameRe  = regexp.MustCompile(`^\s*File "([^"]+)", line (\d+)(?:, in (.+?))?\s*$`)
+	pythonExcRe    = regexp.MustCompile(`^([A-Za-z_][\w.]*)(?::\s?(.*))?$`)
+
+	goRoutineRe  = regexp.MustCompile(`^goroutine \d+ \[`)
+	goLocationRe = regexp.MustCompile(`^\s+(\S+\.(?:go|s)):(\d+)(?:\s+\+0x[0-9a-f]+)?\s*$`)
+	goFuncRe     = regexp.MustCompile(`^(\S.*)\([^()]*\)$`)
+	goPanicRe    = regexp.MustCompile(`^(panic|fatal error): (.*)$`)
+
+	rustPanicRe     = regexp.MustCompile(`panicked at (?:'(.*)', )?(.+?):(\d+):(\d+):?$`)
+	rustBacktraceRe = regexp.MustCompile(`^stack backtrace:`)
+	rustFrameRe     = regexp.MustCompile(`^\s*\d+:\s+(?:0x[0-9a-f]+\s+-\s+)?(.+?)\s*$`)
+	rustLocationRe  = regexp.MustCompile(`^\s+at\s+(.+?):(\d+)(?::\d+)?\s*$`)
+	rustHashRe      = regexp.MustCompile(`::h[0-9a-f]{16}$`)
+
+	rubyFrameRe = regexp.MustCompile("^\\s*(?:from\\s+)?([^\\s:][^:]*?):(\\d+):in [`']([^']+)'")
+	rubyFirstRe = regexp.MustCompile(`:\d+:in [^:]+: (.*) \(([\w:]+)\)\s*$`)
+
+	javaFrameRe = regexp.MustCompile(`^\s*at\s+(?:[\w.$@-]+/)*([\w$.<>]+)\(([^()]*)\)\s*$`)
+	javaFileRe  = regexp.MustCompile(`^(?:[\w$-]+\.(?:java|kt|scala|groovy|clj)(?::\d+)?|Native Method|Unknown Source)$`)
+
+	nodeFrameRe = regexp.MustCompile(`^\s*at\s+(?:(.+?)\s+\()?((?:node:|file://)?[^()\s]+?):(\d+):(\d+)\)?\s*$`)
+
+	dotnetFrameRe = regexp.MustCompile(`^\s*at\s+([^(\s]+)\(([^)]*)\)(?:\s+in\s+(.+):line\s+(\d+))?\s*$`)
+
+	// chainRe matches the headers of chained exceptions.
+	chainRe = regexp.MustCompile(`^\s*(?:Caused by:|Suppressed:|During handling of the above exception|The above exception was the direct cause|---> |--- End of inner exception)`)
+
+	// excRe finds an exception type and message in a header line, e.g.
+	// "Exception in thread "main" java.lang.IllegalStateException: boom"
+	// or "TypeError: x is not a function".
+	excRe = regexp.MustCompile(`(?:^|[\s"'(])((?:[A-Za-z_$][\w$]*\.)*(?:[A-Z][\w$]*)?(?:Exception|Error|Throwable|Fault)[\w$]*)(?::\s*(.*?))?\s*$`)
+)
+
+// Parse detects a stack trace in text and parses it. Returns false when
+// text holds no recognized frames.
+func Parse(text string) (*Trace, bool) {
+	lines := strings.Split(text, "\n")
+	t := &Trace{text: text, lines: lines}
+	switch {
+	case anyMatch(lines, pythonHeaderRe, pythonFrameRe):
+		t.parsePython()
+	case anyMatch(lines, goRoutineRe, goLocationRe):
+		t.parseGo()
+	case anyMatch(lines, rustPanicRe, rustBacktraceRe):
+		t.parseRust()
+	case anyMatch(lines, rubyFrameRe):
+		t.parseRuby()
+	case anyJavaFrame(lines):
+		t.parseJava()
+	case anyMatch(lines, nodeFrameRe):
+		t.parseSimple(Node, nodeFrameRe, nodeFrame)
+	case anyMatch(lines, dotnetFrameRe):
+		t.parseSimple(DotNet, dotnetFrameRe, dotnetFrame)
+	default:
+		return nil, false
+	}
+	if len(t.Frames) == 0 {
+		return nil, false
+	}
+	for i := range t.Frames {
+		t.Frames[i].App = isApp(t.Frames[i].Frame)
+	}
+	return t, true
+}
+
+func anyMatch(lines []string, res ...*regexp.Regexp) bool {
+	for _, line := range lines {
+		for _, re := range res {
+			if re.MatchString(line) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyJavaFrame(lines []string) bool {
+	for _, line := range lines {
+		if m := javaFrameRe.FindStringSubmatch(line); m != nil && javaFileRe.MatchString(m[2]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Trace) add(f model.Frame, start, end int) {
+	t.Frames = append(t.Frames, Frame{Frame: f, start: start, end: end})
+}
+
+// header sets the exception and message from the first line before the
+// first frame that names an exception.
+func (t *Trace) header() {
+	end := len(t.lines)
+	if len(t.Frames) > 0 {
+		end = t.Frames[0].start
+	}
+	for _, line := range t.lines[:end] {
+		if m := excRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			t.Exception, t.Message = m[1], m[2]
+			return
+		}
+	}
+}
+
+func (t *Trace) parsePython() {
+	t.Language = Python
+	last := -1
+	for i := 0; i < len(t.lines); i++ {
+		m := pythonFrameRe.FindStringSubmatch(t.lines[i])
+		if m == nil {
+			continue
+		}
+		end := i + 1
+		// The source line (and 3.11+ caret markers) printed under the
+		// frame belong to it.
+		for end < len(t.lines) && indent(t.lines[end]) > indent(t.lines[i]) && !pythonFrameRe.MatchString(t.lines[end]) {
+			end++
+		}
+		line, _ := strconv.Atoi(m[2])
+		t.add(model.Frame{Function: m[3], File: m[1], Line: line}, i, end)
+		last, i = end, end-1
+	}
+	// The exception follows the last frame: "ValueError: bad value".
+	for _, line := range t.lines[max(last, 0):] {
+		if line == "" || indent(line) > 0 {
+			continue
+		}
+		if m := pythonExcRe.FindStringSubmatch(line); m != nil {
+			t.Exception, t.Message = m[1], m[2]
+			return
+		}
+	}
+}
+
+func (t *Trace) parseGo() {
+	t.Language = Go
+	for i := 0; i < len(t.lines); i++ {
+		line := t.lines[i]
+		if m := goPanicRe.FindStringSubmatch(line); m != nil && t.Exception == "" {
+			t.Exception, t.Message = m[1], m[2]
+			continue
+		}
+		// A frame is a function line followed by its location; a location
+		// without a function line is a frame on its own.
+		if i+1 < len(t.lines) && goFuncRe.MatchString(line) {
+			if loc := goLocationRe.FindStringSubmatch(t.lines[i+1]); loc != nil {
+				fn := goFuncRe.FindStringSubmatch(line)[1]
+				n, _ := strconv.Atoi(loc[2])
+				t.add(model.Frame{Function: fn, File: loc[1], Line: n}, i, i+2)
+				i++
+				continue
+			}
+		}
+		if loc := goLocationRe.FindStringSubmatch(line); loc != nil {
+			n, _ := strconv.Atoi(loc[2])
+			t.add(model.Frame{File: loc[1], Line: n}, i, i+1)
+		}
+	}
+}
+
+func (t *Trace) parseRust() {
+	t.Language = Rust
+	t.Exception = "panic"
+	inBacktrace := false
+	for i := 0; i < len(t.lines); i++ {
+		line := t.lines[i]
+		if m := rustPanicRe.FindStringSubmatch(line); m != nil && t.Message == "" {
+			t.Message = m[1]
+			// Since Rust 1.73 the message follows on the next line.
+			if t.Message == "" && i+1 < len(t.lines) {
+				t.Message = strings.TrimSpace(t.lines[i+1])
+			}
+			// Without RUST_BACKTRACE the panic location is the only frame.
+			if !anyMatch(t.lines, rustBacktraceRe) {
+				n, _ := strconv.Atoi(m[3])
+				t.add(model.Frame{File: m[2], Line: n}, i, i+1)
+			}
+			continue
+		}
+		if rustBacktraceRe.MatchString(line) {
+			inBacktrace = true
+			continue
+		}
+		if !inBacktrace {
+			continue
+		}
+		m := rustFrameRe.FindStringSubmatch(line)
+		if m == nil || rustLocationRe.MatchString(line) {
+			continue
+		}
+		f := model.Frame{Function: rustHashRe.ReplaceAllString(m[1], "")}
+		end := i + 1
+		if end < len(t.lines) {
+			if loc := rustLocationRe.FindStringSubmatch(t.lines[end]); loc != nil {
+				f.File = loc[1]
+				f.Line, _ = strconv.Atoi(loc[2])
+				end++
+			}
+		}
+		t.add(f, i, end)
+		i = end - 1
+	}
+}
+
+func (t *Trace) parseRuby() {
+	t.Language = Ruby
+	for i, line := range t.lines {
+		m := rubyFrameRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		t.add(model.Frame{Function: m[3], File: m[1], Line: n}, i, i+1)
+		if len(t.Frames) == 1 {
+			// app.rb:10:in 'save': boom (RuntimeError)
+			if h := rubyFirstRe.FindStringSubmatch(line); h != nil {
+				t.Exception, t.Message = h[2], h[1]
+			}
+		}
+	}
+}
+
+func (t *Trace) parseJava() {
+	t.Language = Java
+	for i, line := range t.lines {
+		m := javaFrameRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		f := model.Frame{Function: m[1], File: m[2]}
+		if file, n, ok := strings.Cut(m[2], ":"); ok {
+			f.File = file
+			f.Line, _ = strconv.Atoi(n)
+		}
+		t.add(f, i, i+1)
+	}
+	t.header()
+}
+
+// parseSimple parses languages with one frame per line.
+func (t *Trace) parseSimple(lang string, re *regexp.Regexp, frame func([]string) model.Frame) {
+	t.Language = lang
+	for i, line := range t.lines {
+		if m := re.FindStringSubmatch(line); m != nil {
+			t.add(frame(m), i, i+1)
+		}
+	}
+	t.header()
+}
+
+func nodeFrame(m []string) model.Frame {
+	n, _ := strconv.Atoi(m[3])
+	return model.Frame{Function: strings.TrimPrefix(m[1], "async "), File: m[2], Line: n}
+}
+
+func dotnetFrame(m []string) model.Frame {
+	n, _ := strconv.Atoi(m[4])
+	return model.Frame{Function: m[1], File: m[3], Line: n}
+}
+
+func indent(s string) int {
+	return len(s) - len(strings.TrimLeft(s, " \t"))
+}
+
+// Library code, recognized by file path or function prefix. Frames that
+// match neither are app-owned.
+var (
+	libraryPaths = []string{
+		"node_modules/", "node:", "internal/modules/", "internal/process/",
+		"site-packages/", "dist-packages/", "/lib/python", "<frozen ",
+		"/usr/lib/", "/usr/local/lib/", "/rustc/", "/.cargo/registry/",
+		"/go/pkg/mod/", "/usr/local/go/src/", "/gems/", "<internal:",
+	}
+	libraryFuncs = []string{
+		"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin.", "kotlinx.",
+		"scala.", "org.springframework.", "org.apache.", "io.netty.",
+		"System.", "Microsoft.", "std::", "core::", "alloc::", "tokio::",
+		"runtime.", "rust_begin_unwind", "__rust",
+	}
+)
+
+func isApp(f model.Frame) bool {
+	for _, p := range libraryPaths {
+		if strings.Contains(f.File, p) {
+			return false
+		}
+	}
+	for _, p := range libraryFuncs {
+		if strings.HasPrefix(f.Function, p) || strings.HasPrefix(f.Function, "<"+p) {
+			return false
+		}
+	}
+	return true
+}
+
+// keep marks the frames kept when the trace is cut to maxFrames plus the
+// last tailFrames: the first frame, then app-owned frames in order, then
+// library frames if the budget allows. All frames are kept when maxFrames
+// is zero or the trace is short enough.
+func (t *Trace) keep(maxFrames int) []bool {
+	n := len(t.Frames)
+	keep := make([]bool, n)
+	if maxFrames <= 0 || n <= maxFrames+tailFrames {
+		for i := range keep {
+			keep[i] = true
+		}
+		return keep
+	}
+	for i := n - tailFrames; i < n; i++ {
+		keep[i] = true
+	}
+	keep[0] = true
+	budget := maxFrames - 1
+	for _, app := range []bool{true, false} {
+		for i := 1; i < n-tailFrames && budget > 0; i++ {
+			if !keep[i] && t.Frames[i].App == app {
+				keep[i] = true
+				budget--
+			}
+		}
+	}
+	return keep
+}
+
+// Truncate returns the trace text with all but maxFrames frames plus the
+// last two removed (see keep). Each run of removed frames is replaced by
+// one "... (N frames omitted) ..." line. The header, the lines around kept
+// frames and exception chain headers ("Caused by:") are kept. Returns the
+// text unchanged when nothing is removed.
+func (t *Trace) Truncate(maxFrames int) string {
+	keep := t.keep(maxFrames)
+	if len(t.Frames) <= maxFrames+tailFrames || maxFrames <= 0 {
+		return t.text
+	}
+	var out []string
+	pos, omitted := 0, 0
+	flush := func() {
+		if omitted > 0 {
+			out = append(out, fmt.Sprintf("\t... (%d frames omitted) ...", omitted))
+			omitted = 0
+		}
+	}
+	for i, f := range t.Frames {
+		// Lines between two removed frames (Go goroutine headers, Java
+		// "... 3 more") go with them, except exception chain headers.
+		between := i > 0 && !keep[i-1] && !keep[i]
+		for _, line := range t.lines[pos:f.start] {
+			if between && !chainRe.MatchString(line) {
+				continue
+			}
+			flush()
+			out = append(out, line)
+		}
+		if keep[i] {
+			flush()
+			out = append(out, t.lines[f.start:f.end]...)
+		} else {
+			omitted++
+		}
+		pos = f.end
+	}
+	flush()
+	out = append(out, t.lines[pos:]...)
+	return strings.Join(out, "\n")
+}
+
+// Stack returns the trace as an event stack, with the frames Truncate
+// would keep.
+func (t *Trace) Stack(maxFrames int) *model.Stack {
+	s := &model.Stack{Language: t.Language, Exception: t.Exception, Message: t.Message}
+	for i, k := range t.keep(maxFrames) {
+		if k {
+			s.Frames = append(s.Frames, t.Frames[i].Frame)
+		} else {
+			s.Omitted++
+		}
+	}
+	return s
+}
@@ -0,0 +1,242 @@
+package stacktrace
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+func TestParseLanguages(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		lang      string
+		exception string
+		message   string
+		frames    int
+		first     model.Frame
+	}{
+		{
+			name: "java",
+			text: `Exception in thread "main" java.lang.IllegalStateException: pool exhausted
+	at com.acme.db.Pool.acquire(Pool.java:42)
+	at java.base/java.lang.Thread.run(Thread.java:829)
+Caused by: java.net.SocketTimeoutException: connect timed out
+	at java.net.Socket.connect(Native Method)
+	... 3 more`,
+			lang: Java, exception: "java.lang.IllegalStateException", message: "pool exhausted", frames: 3,
+			first: model.Frame{Function: "com.acme.db.Pool.acquire", File: "Pool.java", Line: 42, App: true},
+		},
+		{
+			name: "python",
+			text: `Traceback (most recent call last):
+  File "/app/worker.py", line 12, in <module>
+    main()
+  File "/usr/lib/python3.12/json/__init__.py", line 346, in loads
+    return _default_decoder.decode(s)
+json.decoder.JSONDecodeError: Expecting value: line 1 column 1 (char 0)`,
+			lang: Python, exception: "json.decoder.JSONDecodeError", message: "Expecting value: line 1 column 1 (char 0)", frames: 2,
+			first: model.Frame{Function: "<module>", File: "/app/worker.py", Line: 12, App: true},
+		},
+		{
+			name: "node",
+			text: `TypeError: Cannot read properties of undefined (reading 'id')
+    at getUser (/app/src/users.js:14:22)
+    at async Promise.all (index 0)
+    at Layer.handle (/app/node_modules/express/lib/router/layer.js:95:5)
+    at /app/src/server.js:40:3`,
+			lang: Node, exception: "TypeError", message: "Cannot read properties of undefined (reading 'id')", frames: 3,
+			first: model.Frame{Function: "getUser", File: "/app/src/users.js", Line: 14, App: true},
+		},
+		{
+			name: "go",
+			text: `panic: runtime error: invalid memory address or nil pointer dereference
+
+goroutine 1 [running]:
+main.(*Server).handle(0x0, {0x0, 0x0})
+	/app/server.go:27 +0x1d
+runtime.goexit()
+	/usr/local/go/src/runtime/asm_amd64.s:1695 +0x1
+main.main()
+	/app/main.go:9 +0x25`,
+			lang: Go, exception: "panic", message: "runtime error: invalid memory address or nil pointer dereference", frames: 3,
+			first: model.Frame{Function: "main.(*Server).handle", File: "/app/server.go", Line: 27, App: true},
+		},
+		{
+			name: "rust",
+			text: `thread 'main' panicked at src/main.rs:4:5:
+index out of bounds: the len is 3 but the index is 7
+stack backtrace:
+   0: rust_begin_unwind
+             at /rustc/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/panicking.rs:645:5
+   1: app::handler::run::h0123456789abcdef
+             at ./src/handler.rs:18:9
+   2: app::main
+             at ./src/main.rs:4:5`,
+			lang: Rust, exception: "panic", message: "index out of bounds: the len is 3 but the index is 7", frames: 3,
+			first: model.Frame{Function: "rust_begin_unwind", File: "/rustc/90b35a6239c3d8bdabc530a6a0816f7ff89a0aaf/library/std/src/panicking.rs", Line: 645},
+		},
+		{
+			name: "rust without backtrace",
+			text: `thread 'tokio-runtime-worker' panicked at 'called Option::unwrap() on a None value', src/db.rs:88:14`,
+			lang: Rust, exception: "panic", message: "called Option::unwrap() on a None value", frames: 1,
+			first: model.Frame{File: "src/db.rs", Line: 88, App: true},
+		},
+		{
+			name: "dotnet",
+			text: `System.InvalidOperationException: Sequence contains no elements
+   at System.Linq.ThrowHelper.ThrowNoElementsException()
+   at Acme.Orders.OrderService.GetLatest(Int32 customerId) in /src/Orders/OrderService.cs:line 57
+   at Acme.Api.Program.Main(String[] args)`,
+			lang: DotNet, exception: "System.InvalidOperationException", message: "Sequence contains no elements", frames: 3,
+			first: model.Frame{Function: "System.Linq.ThrowHelper.ThrowNoElementsException"},
+		},
+		{
+			name: "ruby",
+			text: "app/models/order.rb:23:in `total': undefined method `price' for nil (NoMethodError)\n" +
+				"\tfrom /usr/local/bundle/gems/activerecord-7.1.0/lib/active_record/relation.rb:10:in `each'\n" +
+				"\tfrom app/controllers/orders_controller.rb:5:in `show'",
+			lang: Ruby, exception: "NoMethodError", message: "undefined method `price' for nil", frames: 3,
+			first: model.Frame{Function: "total", File: "app/models/order.rb", Line: 23, App: true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr, ok := Parse(tt.text)
+			if !ok {
+				t.Fatal("no trace detected")
+			}
+			if tr.Language != tt.lang || tr.Exception != tt.exception || tr.Message != tt.message {
+				t.Errorf("got %s %q %q, want %s %q %q", tr.Language, tr.Exception, tr.Message, tt.lang, tt.exception, tt.message)
+			}
+			if len(tr.Frames) != tt.frames {
+				t.Fatalf("got %d frames, want %d: %+v", len(tr.Frames), tt.frames, tr.Frames)
+			}
+			if tr.Frames[0].Frame != tt.first {
+				t.Errorf("first frame = %+v, want %+v", tr.Frames[0].Frame, tt.first)
+			}
+		})
+	}
+}
+
+func TestParseAppFrames(t *testing.T) {
+	tr, _ := Parse(`Error: boom
+    at handler (/app/src/api.js:3:9)
+    at Layer.handle (/app/node_modules/express/lib/router/layer.js:95:5)
+    at process.processTicksAndRejections (node:internal/process/task_queues:95:5)`)
+	var app []bool
+	for _, f := range tr.Frames {
+		app = append(app, f.App)
+	}
+	if fmt.Sprint(app) != "[true false false]" {
+		t.Errorf("App flags = %v", app)
+	}
+}
+
+func TestParseNone(t *testing.T) {
+	for _, text := range []string{
+		"ERROR: connection refused to host=db-primary port=5432",
+		"GET /api/users 200 12ms",
+		"user logged in at 12:00:01",
+	} {
+		if tr, ok := Parse(text); ok {
+			t.Errorf("Parse(%q) detected a %s trace", text, tr.Language)
+		}
+	}
+}
+
+// javaTrace builds a trace whose frames are library frames except those
+// listed in app.
+func javaTrace(n int, app ...int) string {
+	isApp := make(map[int]bool)
+	for _, i := range app {
+		isApp[i] = true
+	}
+	lines := []string{"java.lang.RuntimeException: boom"}
+	for i := 0; i < n; i++ {
+		pkg := "org.springframework.web"
+		if isApp[i] {
+			pkg = "com.acme"
+		}
+		lines = append(lines, fmt.Sprintf("\tat %s.C%d.m(C%d.java:%d)", pkg, i, i, i+1))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestTruncatePrefersAppFrames(t *testing.T) {
+	tr, _ := Parse(javaTrace(20, 7, 12))
+	got := tr.Truncate(3)
+	want := strings.Join([]string{
+		"java.lang.RuntimeException: boom",
+		"\tat org.springframework.web.C0.m(C0.java:1)",
+		"\t... (6 frames omitted) ...",
+		"\tat com.acme.C7.m(C7.java:8)",
+		"\t... (4 frames omitted) ...",
+		"\tat com.acme.C12.m(C12.java:13)",
+		"\t... (5 frames omitted) ...",
+		"\tat org.springframework.web.C18.m(C18.java:19)",
+		"\tat org.springframework.web.C19.m(C19.java:20)",
+	}, "\n")
+	if got != want {
+		t.Errorf("Truncate:\n%s\nwant:\n%s", got, want)
+	}
+
+	s := tr.Stack(3)
+	if len(s.Frames) != 5 || s.Omitted != 15 || s.Frames[1].Function != "com.acme.C7.m" {
+		t.Errorf("Stack(3) = %d frames, %d omitted: %+v", len(s.Frames), s.Omitted, s.Frames)
+	}
+	if s := tr.Stack(0); len(s.Frames) != 20 || s.Omitted != 0 {
+		t.Errorf("Stack(0) should keep every frame, got %d/%d", len(s.Frames), s.Omitted)
+	}
+}
+
+func TestTruncateFillsWithLibraryFrames(t *testing.T) {
+	tr, _ := Parse(javaTrace(12, 5))
+	got := tr.Truncate(3)
+	if !strings.Contains(got, "C0.m") || !strings.Contains(got, "C1.m") || !strings.Contains(got, "com.acme.C5.m") || strings.Contains(got, "C2.m") {
+		t.Errorf("expected first frame, app frame and one library frame:\n%s", got)
+	}
+}
+
+func TestTruncateKeepsMultiLineFramesWhole(t *testing.T) {
+	lines := []string{"Traceback (most recent call last):"}
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`  File "/app/m%d.py", line %d, in f%d`, i, i+1, i), fmt.Sprintf("    f%d()", i+1))
+	}
+	lines = append(lines, "RecursionError: too deep")
+	tr, _ := Parse(strings.Join(lines, "\n"))
+	got := strings.Split(tr.Truncate(2), "\n")
+	// header + 2 frames x 2 lines + omission + 2 frames x 2 lines + exception
+	if len(got) != 11 || got[5] != "\t... (6 frames omitted) ..." || got[10] != "RecursionError: too deep" {
+		t.Errorf("unexpected truncation:\n%s", strings.Join(got, "\n"))
+	}
+}
+
+func TestTruncateShortTraceUnchanged(t *testing.T) {
+	text := javaTrace(4)
+	tr, _ := Parse(text)
+	if got := tr.Truncate(3); got != text {
+		t.Errorf("short trace changed:\n%s", got)
+	}
+}
+
+func TestTruncateDropsLinesBetweenRemovedFrames(t *testing.T) {
+	lines := []string{"java.lang.RuntimeException: outer"}
+	for i := 0; i < 6; i++ {
+		lines = append(lines, fmt.Sprintf("\tat com.acme.A%d.m(A%d.java:1)", i, i))
+	}
+	lines = append(lines, "\t... 2 more", "Caused by: java.io.IOException: inner")
+	for i := 0; i < 6; i++ {
+		lines = append(lines, fmt.Sprintf("\tat com.acme.B%d.m(B%d.java:1)", i, i))
+	}
+	tr, _ := Parse(strings.Join(lines, "\n"))
+	got := tr.Truncate(2)
+	if strings.Contains(got, "2 more") || !strings.Contains(got, "Caused by: java.io.IOException: inner") {
+		t.Errorf("expected the chain header kept and the rest of the gap dropped:\n%s", got)
+	}
+	if !strings.Contains(got, "(4 frames omitted)") || !strings.Contains(got, "(4 frames omitted) ...\nCaused by") {
+		t.Errorf("expected runs split at the chain header:\n%s", got)
+	}
+}
@@ -0,0 +1,271 @@
+package taxonomy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// validSeverities are the leaf severities understood by the engine and outputs.
+var validSeverities = map[string]bool{
+	"error":   true,
+	"warning": true,
+	"info":    true,
+	"debug":   true,
+}
+
+// fileFormat is the on-disk taxonomy format. When Extend is true the roots are
+// merged into DefaultRoots(); otherwise they replace the built-in tree.
+type fileFormat struct {
+	Extend bool       `json:"extend" yaml:"extend"`
+	Roots  []fileRoot `json:"roots" yaml:"roots"`
+}
+
+type fileRoot struct {
+	Name        string     `json:"name" yaml:"name"`
+	Description string     `json:"description" yaml:"description"`
+	Leaves      []fileLeaf `json:"leaves" yaml:"leaves"`
+}
+
+type fileLeaf struct {
+	Name             string   `json:"name" yaml:"name"`
+	Description      string   `json:"description" yaml:"description"`
+	Severity         string   `json:"severity" yaml:"severity"`
+	Threshold        float64  `json:"threshold,omitempty" yaml:"threshold"`
+	Examples         []string `json:"examples,omitempty" yaml:"examples"`
+	NegativeExamples []string `json:"negative_examples,omitempty" yaml:"negative_examples"`
+}
+
+// LoadFile reads a taxonomy file and returns the resulting root nodes,
+// merged with DefaultRoots() when the file sets extend: true.
+// The format is chosen by extension: .json, or .yaml/.yml. Any other
+// extension is rejected. The returned tree is validated before it is returned.
+func LoadFile(path string) ([]*model.TaxonomyNode, error) {
+	var parse func([]byte) ([]*model.TaxonomyNode, error)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		parse = Parse
+	case ".yaml", ".yml":
+		parse = ParseYAML
+	default:
+		return nil, fmt.Errorf("taxonomy: %s: unsupported file extension %q (must be .json, .yaml or .yml)", path, ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("taxonomy: %w", err)
+	}
+	roots, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("taxonomy: %s: %w", path, err)
+	}
+	return roots, nil
+}
+
+// Parse decodes taxonomy JSON. See LoadFile for merge semantics.
+func Parse(data []byte) ([]*model.TaxonomyNode, error) {
+	var f fileFormat
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	return fromFile(f)
+}
+
+// ParseYAML decodes a taxonomy in YAML, using the same keys as the JSON format.
+func ParseYAML(data []byte) ([]*model.TaxonomyNode, error) {
+	var f fileFormat
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	return fromFile(f)
+}
+
+// fromFile converts a decoded file into a validated taxonomy tree.
+func fromFile(f fileFormat) ([]*model.TaxonomyNode, error) {
+	roots := make([]*model.TaxonomyNode, len(f.Roots))
+	for i, r := range f.Roots {
+		root := &model.TaxonomyNode{Name: r.Name, Desc: r.Description}
+		for _, l := range r.Leaves {
+			root.Children = append(root.Children, &model.TaxonomyNode{
+				Name:             l.Name,
+				Desc:             l.Description,
+				Severity:         l.Severity,
+				Threshold:        l.Threshold,
+				Examples:         l.Examples,
+				NegativeExamples: l.NegativeExamples,
+			})
+		}
+		roots[i] = root
+	}
+
+	// Duplicates are checked on the file contents alone; in extend mode a leaf
+	// that shadows a built-in path is an intentional override, not a duplicate.
+	if err := CheckDuplicates(roots); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy: %w", err)
+	}
+
+	if f.Extend {
+		roots = Merge(DefaultRoots(), roots)
+	}
+	if err := Validate(roots); err != nil {
+		return nil, err
+	}
+	return roots, nil
+}
+
+// Merge returns base extended with ext. Roots with a matching name gain ext's
+// leaves; a leaf whose path already exists replaces the base leaf in place.
+// An empty root description in ext keeps the base description.
+// Neither input is modified.
+func Merge(base, ext []*model.TaxonomyNode) []*model.TaxonomyNode {
+	merged := cloneRoots(base)
+	byName := make(map[string]*model.TaxonomyNode, len(merged))
+	for _, root := range merged {
+		byName[root.Name] = root
+	}
+
+	for _, r := range ext {
+		root, ok := byName[r.Name]
+		if !ok {
+			root = &model.TaxonomyNode{Name: r.Name, Desc: r.Desc}
+			merged = append(merged, root)
+			byName[r.Name] = root
+		} else if r.Desc != "" {
+			root.Desc = r.Desc
+		}
+		for _, leaf := range r.Children {
+			l := *leaf
+			replaced := false
+			for i, existing := range root.Children {
+				if existing.Name == leaf.Name {
+					root.Children[i] = &l
+					replaced = true
+					break
+				}
+			}
+			if !replaced {
+				root.Children = append(root.Children, &l)
+			}
+		}
+	}
+	return merged
+}
+
+// Validate checks a taxonomy tree for problems that would make classification
+// ambiguous or produce malformed events: empty or dotted names, duplicate
+// paths, roots without leaves, empty descriptions or examples, unknown
+// severities, and thresholds outside 0-1.
+// All problems are reported.
+func Validate(roots []*model.TaxonomyNode) error {
+	var errs []string
+	if err := CheckDuplicates(roots); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	for _, root := range roots {
+		switch {
+		case root.Name == "":
+			errs = append(errs, "root with empty name")
+		case strings.Contains(root.Name, "."):
+			errs = append(errs, fmt.Sprintf("root %q: name must not contain '.'", root.Name))
+		}
+		if root.Desc == "" {
+			errs = append(errs, fmt.Sprintf("root %q: empty description", root.Name))
+		}
+		if len(root.Children) == 0 {
+			errs = append(errs, fmt.Sprintf("root %q: no leaves", root.Name))
+		}
+		for _, leaf := range root.Children {
+			path := root.Name + "." + leaf.Name
+			switch {
+			case leaf.Name == "":
+				errs = append(errs, fmt.Sprintf("root %q: leaf with empty name", root.Name))
+			case strings.Contains(leaf.Name, "."):
+				errs = append(errs, fmt.Sprintf("%s: name must not contain '.'", path))
+			}
+			if leaf.Desc == "" {
+				errs = append(errs, fmt.Sprintf("%s: empty description", path))
+			}
+			if !validSeverities[leaf.Severity] {
+				errs = append(errs, fmt.Sprintf("%s: unknown severity %q (must be error|warning|info|debug)", path, leaf.Severity))
+			}
+			if leaf.Threshold < 0 || leaf.Threshold > 1 {
+				errs = append(errs, fmt.Sprintf("%s: threshold must be 0-1, got %g", path, leaf.Threshold))
+			}
+			if hasEmpty(leaf.Examples) || hasEmpty(leaf.NegativeExamples) {
+				errs = append(errs, fmt.Sprintf("%s: empty example", path))
+			}
+		}
+	}
+	if len(roots) == 0 {
+		errs = append(errs, "taxonomy has no leaves")
+	}
+
+	if len(errs) > 0 {
+		return errors.New("invalid taxonomy:\n  - " + strings.Join(errs, "\n  - "))
+	}
+	return nil
+}
+
+// CheckDuplicates reports repeated root names or leaf paths. Run it on
+// user-supplied roots before Merge, which would otherwise let a later
+// duplicate silently replace an earlier one.
+func CheckDuplicates(roots []*model.TaxonomyNode) error {
+	var dups []string
+	seenRoots := make(map[string]bool)
+	seenPaths := make(map[string]bool)
+	for _, root := range roots {
+		if seenRoots[root.Name] {
+			dups = append(dups, fmt.Sprintf("duplicate root %q", root.Name))
+		}
+		seenRoots[root.Name] = true
+		for _, leaf := range root.Children {
+			path := root.Name + "." + leaf.Name
+			if seenPaths[path] {
+				dups = append(dups, fmt.Sprintf("duplicate path %q", path))
+			}
+			seenPaths[path] = true
+		}
+	}
+	if len(dups) > 0 {
+		return errors.New(strings.Join(dups, "; "))
+	}
+	return nil
+}
+
+// hasEmpty reports whether any string in ss is empty or whitespace.
+func hasEmpty(ss []string) bool {
+	for _, s := range ss {
+		if strings.TrimSpace(s) == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneRoots deep-copies a taxonomy tree so callers can mutate the result.
+func cloneRoots(roots []*model.TaxonomyNode) []*model.TaxonomyNode {
+	out := make([]*model.TaxonomyNode, len(roots))
+	for i, r := range roots {
+		root := *r
+		root.Children = make([]*model.TaxonomyNode, len(r.Children))
+		for j, leaf := range r.Children {
+			l := *leaf
+			root.Children[j] = &l
+		}
+		out[i] = &root
+	}
+	return out
+}
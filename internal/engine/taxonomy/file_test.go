@@ -0,0 +1,281 @@
+package taxonomy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseReplace(t *testing.T) {
+	data := []byte(`{
+		"roots": [
+			{
+				"name": "PAYMENTS",
+				"description": "Payment processing events",
+				"leaves": [
+					{"name": "chargeback_failed", "description": "Chargeback dispute failed, card network rejected the chargeback", "severity": "error"},
+					{"name": "payout_sent", "description": "Merchant payout sent to bank account", "severity": "info"}
+				]
+			}
+		]
+	}`)
+
+	roots, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(roots) != 1 {
+		t.Fatalf("expected 1 root (replace mode), got %d", len(roots))
+	}
+	if roots[0].Name != "PAYMENTS" || len(roots[0].Children) != 2 {
+		t.Fatalf("unexpected root: %+v", roots[0])
+	}
+	if roots[0].Children[0].Severity != "error" {
+		t.Errorf("severity = %q, want error", roots[0].Children[0].Severity)
+	}
+}
+
+func TestParseExtend(t *testing.T) {
+	data := []byte(`{
+		"extend": true,
+		"roots": [
+			{
+				"name": "PAYMENTS",
+				"description": "Payment processing events",
+				"leaves": [{"name": "chargeback_failed", "description": "Chargeback dispute failed", "severity": "error"}]
+			},
+			{
+				"name": "ERROR",
+				"leaves": [
+					{"name": "timeout", "description": "Custom timeout description", "severity": "warning"},
+					{"name": "quota_exhausted", "description": "Tenant quota exhausted", "severity": "warning"}
+				]
+			}
+		]
+	}`)
+
+	roots, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if len(roots) != 9 {
+		t.Fatalf("expected 9 roots (8 default + PAYMENTS), got %d", len(roots))
+	}
+
+	var errRoot = roots[0]
+	if errRoot.Name != "ERROR" {
+		t.Fatalf("expected ERROR first, got %q", errRoot.Name)
+	}
+	if errRoot.Desc == "" {
+		t.Error("ERROR description should be inherited from the default tree")
+	}
+	if len(errRoot.Children) != 10 {
+		t.Errorf("expected 10 ERROR leaves (9 default + 1 new), got %d", len(errRoot.Children))
+	}
+	for _, leaf := range errRoot.Children {
+		if leaf.Name == "timeout" && leaf.Desc != "Custom timeout description" {
+			t.Errorf("timeout leaf not overridden: %q", leaf.Desc)
+		}
+	}
+
+	// DefaultRoots must not be mutated by the merge.
+	for _, leaf := range DefaultRoots()[0].Children {
+		if leaf.Name == "timeout" && leaf.Desc == "Custom timeout description" {
+			t.Fatal("Merge mutated DefaultRoots()")
+		}
+	}
+}
+
+func TestParseValidationErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{
+			"duplicate path",
+			`{"roots":[{"name":"A","description":"a","leaves":[
+				{"name":"x","description":"x","severity":"info"},
+				{"name":"x","description":"x again","severity":"info"}]}]}`,
+			`duplicate path "A.x"`,
+		},
+		{
+			"duplicate root",
+			`{"roots":[
+				{"name":"A","description":"a","leaves":[{"name":"x","description":"x","severity":"info"}]},
+				{"name":"A","description":"a","leaves":[{"name":"y","description":"y","severity":"info"}]}]}`,
+			`duplicate root "A"`,
+		},
+		{
+			"empty leaf description",
+			`{"roots":[{"name":"A","description":"a","leaves":[{"name":"x","severity":"info"}]}]}`,
+			"A.x: empty description",
+		},
+		{
+			"empty root description",
+			`{"roots":[{"name":"A","leaves":[{"name":"x","description":"x","severity":"info"}]}]}`,
+			`root "A": empty description`,
+		},
+		{
+			"unknown severity",
+			`{"roots":[{"name":"A","description":"a","leaves":[{"name":"x","description":"x","severity":"fatal"}]}]}`,
+			`unknown severity "fatal"`,
+		},
+		{
+			"dotted name",
+			`{"roots":[{"name":"A","description":"a","leaves":[{"name":"x.y","description":"x","severity":"info"}]}]}`,
+			"must not contain '.'",
+		},
+		{
+			"no leaves",
+			`{"roots":[]}`,
+			"no leaves",
+		},
+		{
+			"empty root in extend mode",
+			`{"extend":true,"roots":[{"name":"PAYMENTS","description":"Payments","leaves":[]}]}`,
+			`root "PAYMENTS": no leaves`,
+		},
+		{
+			"unknown field",
+			`{"roots":[], "mode":"extend"}`,
+			"unknown field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.json))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected error to mention %q, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+	data := `{"roots":[{"name":"A","description":"a","leaves":[{"name":"x","description":"x","severity":"debug"}]}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	roots, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error: %v", err)
+	}
+	if len(roots) != 1 || roots[0].Children[0].Severity != "debug" {
+		t.Fatalf("unexpected roots: %+v", roots)
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestValidateDefaultRoots(t *testing.T) {
+	if err := Validate(DefaultRoots()); err != nil {
+		t.Fatalf("DefaultRoots() should validate, got: %v", err)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	data := []byte(`
+extend: true
+roots:
+  - name: PAYMENTS
+    description: Payment processing events
+    leaves:
+      - name: chargeback_failed
+        description: Chargeback dispute failed
+        severity: error
+`)
+	roots, err := ParseYAML(data)
+	if err != nil {
+		t.Fatalf("ParseYAML() error: %v", err)
+	}
+	if len(roots) != 9 || roots[8].Name != "PAYMENTS" {
+		t.Fatalf("expected 9 roots ending in PAYMENTS, got %d", len(roots))
+	}
+
+	if _, err := ParseYAML([]byte("roots: []\nmode: extend\n")); err == nil {
+		t.Fatal("expected error for unknown YAML field")
+	}
+}
+
+func TestLoadFileExtensions(t *testing.T) {
+	dir := t.TempDir()
+	yml := "roots:\n  - name: A\n    description: a\n    leaves:\n      - {name: x, description: x, severity: info}\n"
+	for _, name := range []string{"taxonomy.yaml", "taxonomy.yml"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadFile(path); err != nil {
+			t.Errorf("LoadFile(%s) error: %v", name, err)
+		}
+	}
+
+	path := filepath.Join(dir, "taxonomy.toml")
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadFile(path)
+	if err == nil || !strings.Contains(err.Error(), "unsupported file extension") {
+		t.Fatalf("expected unsupported extension error, got: %v", err)
+	}
+}
+
+func TestCheckDuplicates(t *testing.T) {
+	roots, err := Parse([]byte(`{"roots":[{"name":"A","description":"a","leaves":[{"name":"x","description":"x","severity":"info"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckDuplicates(roots); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	roots = append(roots, roots[0])
+	if err := CheckDuplicates(roots); err == nil {
+		t.Fatal("expected duplicate root error")
+	}
+}
+
+func TestParseExamples(t *testing.T) {
+	roots, err := Parse([]byte(`{"roots":[{"name":"A","description":"a","leaves":[
+		{"name":"x","description":"x","severity":"info",
+		 "examples":["x happened"],"negative_examples":["y happened"]}]}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	leaf := roots[0].Children[0]
+	if len(leaf.Examples) != 1 || len(leaf.NegativeExamples) != 1 {
+		t.Fatalf("examples not parsed: %+v", leaf)
+	}
+
+	_, err = Parse([]byte(`{"roots":[{"name":"A","description":"a","leaves":[
+		{"name":"x","description":"x","severity":"info","examples":["  "]}]}]}`))
+	if err == nil || !strings.Contains(err.Error(), "empty example") {
+		t.Fatalf("expected empty example error, got: %v", err)
+	}
+}
+
+func TestParseThreshold(t *testing.T) {
+	roots, err := Parse([]byte(`{"roots":[{"name":"A","description":"a","leaves":[
+		{"name":"x","description":"x","severity":"info","threshold":0.7}]}]}`))
+	if err != nil {
+		t.Fatalf("Parse() error: %v", err)
+	}
+	if roots[0].Children[0].Threshold != 0.7 {
+		t.Errorf("threshold = %f, want 0.7", roots[0].Children[0].Threshold)
+	}
+
+	_, err = Parse([]byte(`{"roots":[{"name":"A","description":"a","leaves":[
+		{"name":"x","description":"x","severity":"info","threshold":1.2}]}]}`))
+	if err == nil || !strings.Contains(err.Error(), "threshold must be 0-1") {
+		t.Fatalf("expected threshold range error, got: %v", err)
+	}
+}
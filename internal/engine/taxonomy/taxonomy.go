@@ -2,6 +2,7 @@ package taxonomy
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/kaminocorp/lumber/internal/engine/embedder"
 	"github.com/kaminocorp/lumber/internal/model"
@@ -15,17 +16,24 @@ type Taxonomy struct {
 
 // New creates a Taxonomy from a set of root nodes and pre-embeds all leaf labels.
 // Each leaf is embedded using the text "{Parent}: {Leaf.Desc}" to capture both
-// the category context and the semantic description.
+// the category context and the semantic description. Leaf examples and
+// negative examples are embedded verbatim, as raw logs are, in the same batch.
 func New(roots []*model.TaxonomyNode, emb embedder.Embedder) (*Taxonomy, error) {
-	// Collect leaf paths, severities, and embedding texts.
+	// Collect leaf paths, severities, and embedding texts. Example texts are
+	// appended after all descriptions; spans records each leaf's range.
+	type span struct{ pos, posN, neg, negN int }
 	var paths []string
 	var severities []string
+	var thresholds []float64
 	var texts []string
+	var leaves []*model.TaxonomyNode
 	for _, root := range roots {
 		for _, child := range root.Children {
 			paths = append(paths, root.Name+"."+child.Name)
 			severities = append(severities, child.Severity)
+			thresholds = append(thresholds, child.Threshold)
 			texts = append(texts, root.Name+": "+child.Desc)
+			leaves = append(leaves, child)
 		}
 	}
 
@@ -33,6 +41,14 @@ func New(roots []*model.TaxonomyNode, emb embedder.Embedder) (*Taxonomy, error)
 		return &Taxonomy{root: roots}, nil
 	}
 
+	spans := make([]span, len(leaves))
+	for i, leaf := range leaves {
+		spans[i].pos, spans[i].posN = len(texts), len(leaf.Examples)
+		texts = append(texts, leaf.Examples...)
+		spans[i].neg, spans[i].negN = len(texts), len(leaf.NegativeExamples)
+		texts = append(texts, leaf.NegativeExamples...)
+	}
+
 	vecs, err := emb.EmbedBatch(texts)
 	if err != nil {
 		return nil, fmt.Errorf("taxonomy: pre-embed %d labels: %w", len(texts), err)
@@ -40,7 +56,15 @@ func New(roots []*model.TaxonomyNode, emb embedder.Embedder) (*Taxonomy, error)
 
 	labels := make([]model.EmbeddedLabel, len(paths))
 	for i := range paths {
-		labels[i] = model.EmbeddedLabel{Path: paths[i], Vector: vecs[i], Severity: severities[i]}
+		s := spans[i]
+		labels[i] = model.EmbeddedLabel{Path: paths[i], Vector: vecs[i], Severity: severities[i], Threshold: thresholds[i]}
+		if s.posN > 0 {
+			labels[i].Exemplars = vecs[s.pos : s.pos+s.posN]
+			labels[i].Centroid = centroid(append([][]float32{vecs[i]}, labels[i].Exemplars...))
+		}
+		if s.negN > 0 {
+			labels[i].Negatives = vecs[s.neg : s.neg+s.negN]
+		}
 	}
 
 	return &Taxonomy{root: roots, labels: labels}, nil
@@ -55,3 +79,49 @@ func (t *Taxonomy) Labels() []model.EmbeddedLabel {
 func (t *Taxonomy) Roots() []*model.TaxonomyNode {
 	return t.root
 }
+
+// SeedExamples returns a copy of roots with examples appended to the leaves
+// named by path (e.g. "ERROR.timeout"). Paths not in the tree are ignored.
+func SeedExamples(roots []*model.TaxonomyNode, examples map[string][]string) []*model.TaxonomyNode {
+	seeded := cloneRoots(roots)
+	for _, root := range seeded {
+		for _, leaf := range root.Children {
+			if ex := examples[root.Name+"."+leaf.Name]; len(ex) > 0 {
+				leaf.Examples = append(append([]string(nil), leaf.Examples...), ex...)
+			}
+		}
+	}
+	return seeded
+}
+
+// centroid returns the unit-normalized mean of the unit-normalized vectors,
+// so each prototype contributes equally regardless of its magnitude.
+func centroid(vecs [][]float32) []float32 {
+	sum := make([]float64, len(vecs[0]))
+	for _, v := range vecs {
+		var norm float64
+		for _, x := range v {
+			norm += float64(x) * float64(x)
+		}
+		if norm == 0 || len(v) != len(sum) {
+			continue
+		}
+		norm = math.Sqrt(norm)
+		for j, x := range v {
+			sum[j] += float64(x) / norm
+		}
+	}
+	var norm float64
+	for _, x := range sum {
+		norm += x * x
+	}
+	out := make([]float32, len(sum))
+	if norm == 0 {
+		return out
+	}
+	norm = math.Sqrt(norm)
+	for j, x := range sum {
+		out[j] = float32(x / norm)
+	}
+	return out
+}
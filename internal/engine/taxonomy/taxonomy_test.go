@@ -212,3 +212,99 @@ func TestDefaultRootsDescriptions(t *testing.T) {
 		}
 	}
 }
+
+// recordingEmbedder returns a distinct unit vector per text and records the
+// texts it was asked to embed.
+type recordingEmbedder struct {
+	texts []string
+}
+
+func (r *recordingEmbedder) Embed(text string) ([]float32, error) {
+	vecs, err := r.EmbedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vecs[0], nil
+}
+
+func (r *recordingEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vec := make([]float32, 8)
+		vec[len(r.texts)%8] = 1
+		r.texts = append(r.texts, text)
+		vecs[i] = vec
+	}
+	return vecs, nil
+}
+
+func (r *recordingEmbedder) Close() error { return nil }
+
+func TestNewEmbedsExamples(t *testing.T) {
+	roots := []*model.TaxonomyNode{
+		{
+			Name: "ERROR",
+			Desc: "Errors",
+			Children: []*model.TaxonomyNode{
+				{
+					Name:             "timeout",
+					Desc:             "Request timeout",
+					Severity:         "error",
+					Examples:         []string{"context deadline exceeded", "upstream timed out"},
+					NegativeExamples: []string{"timeout set to 30s"},
+				},
+				{Name: "connection_failure", Desc: "Connection failed", Severity: "error"},
+			},
+		},
+	}
+
+	emb := &recordingEmbedder{}
+	tax, err := New(roots, emb)
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	// Descriptions first, then each leaf's examples and negatives, in one batch.
+	want := []string{"ERROR: Request timeout", "ERROR: Connection failed",
+		"context deadline exceeded", "upstream timed out", "timeout set to 30s"}
+	if fmt.Sprint(emb.texts) != fmt.Sprint(want) {
+		t.Fatalf("embedded texts = %q, want %q", emb.texts, want)
+	}
+
+	timeout := tax.Labels()[0]
+	if len(timeout.Exemplars) != 2 || len(timeout.Negatives) != 1 {
+		t.Fatalf("expected 2 exemplars and 1 negative, got %d and %d", len(timeout.Exemplars), len(timeout.Negatives))
+	}
+	if timeout.Exemplars[0][2] != 1 || timeout.Negatives[0][4] != 1 {
+		t.Error("exemplar vectors mapped to the wrong texts")
+	}
+	// Centroid of three orthogonal unit vectors has equal components.
+	c := timeout.Centroid
+	if c == nil || c[0] != c[2] || c[2] != c[3] || c[1] != 0 {
+		t.Errorf("unexpected centroid %v", c)
+	}
+
+	conn := tax.Labels()[1]
+	if conn.Exemplars != nil || conn.Centroid != nil {
+		t.Error("label without examples should have no exemplars or centroid")
+	}
+}
+
+func TestSeedExamples(t *testing.T) {
+	roots := DefaultRoots()
+	seeded := SeedExamples(roots, map[string][]string{
+		"ERROR.timeout": {"context deadline exceeded"},
+		"NOPE.missing":  {"ignored"},
+	})
+
+	for _, leaf := range seeded[0].Children {
+		if leaf.Name == "timeout" && len(leaf.Examples) != 1 {
+			t.Errorf("expected 1 seeded example, got %v", leaf.Examples)
+		}
+	}
+	for _, leaf := range roots[0].Children {
+		if len(leaf.Examples) != 0 {
+			t.Fatal("SeedExamples mutated its input")
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package eval
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/engine/classifier"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Embedder embeds a batch of texts. Satisfied by embedder.Embedder.
+type Embedder interface {
+	EmbedBatch(texts []string) ([][]float32, error)
+}
+
+// CalibrateOptions controls threshold fitting.
+type CalibrateOptions struct {
+	// TargetPrecision is the precision each fitted label threshold must reach
+	// on the corpus.
+	TargetPrecision float64
+	// MinSupport is the number of corpus predictions a label needs before
+	// a threshold is fitted for it.
+	MinSupport int
+	// Softmax fits a temperature and expresses thresholds as calibrated
+	// probabilities; otherwise thresholds apply to raw similarity.
+	Softmax bool
+}
+
+// Prediction is a classifier's top-1 confidence and whether it was correct.
+type Prediction struct {
+	Confidence float64
+	Correct    bool
+}
+
+// CalibrationResult is a fitted calibration plus the labels that could not
+// be fitted, with the reason.
+type CalibrationResult struct {
+	Calibration classifier.Calibration
+	Skipped     map[string]string
+}
+
+// Calibrate embeds the corpus, fits a softmax temperature (when
+// opts.Softmax is set) and fits a per-label threshold reaching
+// opts.TargetPrecision for every label with enough predictions, plus a
+// pooled threshold over all predictions for the remaining labels.
+// Thresholds are fitted on unthresholded top-1 predictions.
+func Calibrate(emb Embedder, cls *classifier.Classifier, labels []model.EmbeddedLabel, entries []corpus.CorpusEntry, opts CalibrateOptions) (CalibrationResult, error) {
+	if len(labels) == 0 {
+		return CalibrationResult{}, fmt.Errorf("eval: taxonomy has no labels")
+	}
+	index := make(map[string]int, len(labels))
+	for i, lbl := range labels {
+		index[lbl.Path] = i
+	}
+
+	scores := make([][]float64, 0, len(entries))
+	truth := make([]int, 0, len(entries)) // -1 when the expected label is not in the taxonomy
+	for start := 0; start < len(entries); start += batchSize {
+		end := min(start+batchSize, len(entries))
+		texts := make([]string, end-start)
+		for i, e := range entries[start:end] {
+			texts[i] = e.Raw
+		}
+		vecs, err := emb.EmbedBatch(texts)
+		if err != nil {
+			return CalibrationResult{}, fmt.Errorf("eval: embed entries %d-%d: %w", start+1, end, err)
+		}
+		for i, vec := range vecs {
+			e := entries[start+i]
+			scores = append(scores, cls.Scores(vec, labels))
+			if idx, ok := index[labelPath(e.ExpectedType, e.ExpectedCategory)]; ok {
+				truth = append(truth, idx)
+			} else {
+				truth = append(truth, -1)
+			}
+		}
+	}
+
+	var cal classifier.Calibration
+	if opts.Softmax {
+		cal.Temperature = FitTemperature(scores, truth)
+	}
+
+	var all []Prediction
+	byLabel := make(map[string][]Prediction)
+	for i, s := range scores {
+		conf := s
+		if cal.Temperature > 0 {
+			conf = classifier.Softmax(s, cal.Temperature)
+		}
+		best := 0
+		for j := range conf {
+			if conf[j] > conf[best] {
+				best = j
+			}
+		}
+		path := labels[best].Path
+		pred := Prediction{Confidence: conf[best], Correct: best == truth[i]}
+		byLabel[path] = append(byLabel[path], pred)
+		all = append(all, pred)
+	}
+
+	result := CalibrationResult{Skipped: make(map[string]string)}
+	// The pooled threshold covers labels that cannot be fitted individually,
+	// on the same (possibly calibrated) scale.
+	if t, ok := FitThreshold(all, opts.TargetPrecision); ok {
+		cal.Threshold = t
+	}
+	cal.Thresholds = make(map[string]float64)
+	for _, lbl := range labels {
+		preds := byLabel[lbl.Path]
+		if len(preds) < opts.MinSupport || len(preds) == 0 {
+			result.Skipped[lbl.Path] = fmt.Sprintf("%d predictions, need %d", len(preds), max(opts.MinSupport, 1))
+			continue
+		}
+		t, ok := FitThreshold(preds, opts.TargetPrecision)
+		if !ok {
+			result.Skipped[lbl.Path] = fmt.Sprintf("precision %.2f not reachable", opts.TargetPrecision)
+			continue
+		}
+		cal.Thresholds[lbl.Path] = t
+	}
+	result.Calibration = cal
+	return result, nil
+}
+
+// temperatureGrid is searched by FitTemperature, log-spaced from 0.005 to 2.
+var temperatureGrid = func() []float64 {
+	const steps = 60
+	grid := make([]float64, steps)
+	lo, hi := math.Log(0.005), math.Log(2)
+	for i := range grid {
+		grid[i] = math.Exp(lo + (hi-lo)*float64(i)/(steps-1))
+	}
+	return grid
+}()
+
+// FitTemperature returns the softmax temperature minimizing the negative
+// log-likelihood of the true labels. Entries with truth < 0 are ignored.
+func FitTemperature(scores [][]float64, truth []int) float64 {
+	best, bestNLL := temperatureGrid[0], math.Inf(1)
+	for _, t := range temperatureGrid {
+		var nll float64
+		var n int
+		for i, s := range scores {
+			if truth[i] < 0 {
+				continue
+			}
+			p := classifier.Softmax(s, t)[truth[i]]
+			nll -= math.Log(math.Max(p, 1e-12))
+			n++
+		}
+		if n == 0 {
+			return 0
+		}
+		if nll < bestNLL {
+			best, bestNLL = t, nll
+		}
+	}
+	return best
+}
+
+// FitThreshold returns the lowest confidence threshold at which the
+// predictions at or above it reach the target precision. It reports false
+// when no threshold reaches the target.
+func FitThreshold(preds []Prediction, target float64) (float64, bool) {
+	sorted := append([]Prediction(nil), preds...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Confidence > sorted[j].Confidence })
+
+	threshold, found := 0.0, false
+	correct := 0
+	for i, p := range sorted {
+		if p.Correct {
+			correct++
+		}
+		// A threshold admits every prediction with equal confidence, so only
+		// evaluate at the end of a run of ties.
+		if i+1 < len(sorted) && sorted[i+1].Confidence == p.Confidence {
+			continue
+		}
+		if float64(correct)/float64(i+1) >= target {
+			threshold, found = p.Confidence, true
+		}
+	}
+	return threshold, found
+}
@@ -0,0 +1,108 @@
+package eval
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/engine/classifier"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// vecEmbedder maps texts to fixed vectors.
+type vecEmbedder map[string][]float32
+
+func (v vecEmbedder) EmbedBatch(texts []string) ([][]float32, error) {
+	vecs := make([][]float32, len(texts))
+	for i, text := range texts {
+		vecs[i] = v[text]
+	}
+	return vecs, nil
+}
+
+func TestFitThreshold(t *testing.T) {
+	preds := []Prediction{
+		{0.9, true}, {0.8, true}, {0.7, false}, {0.6, true}, {0.5, false}, {0.4, false},
+	}
+	// Precision by cutoff: 0.9→1, 0.8→1, 0.7→.67, 0.6→.75, 0.5→.6, 0.4→.5
+	if th, ok := FitThreshold(preds, 0.75); !ok || th != 0.6 {
+		t.Errorf("FitThreshold(0.75) = %v, %v; want 0.6", th, ok)
+	}
+	if th, ok := FitThreshold(preds, 1); !ok || th != 0.8 {
+		t.Errorf("FitThreshold(1) = %v, %v; want 0.8", th, ok)
+	}
+	if _, ok := FitThreshold([]Prediction{{0.9, false}}, 0.5); ok {
+		t.Error("expected no threshold when every prediction is wrong")
+	}
+
+	// Ties are admitted together: at 0.8 precision is 1/2.
+	tied := []Prediction{{0.8, true}, {0.8, false}}
+	if _, ok := FitThreshold(tied, 0.9); ok {
+		t.Error("tied predictions must not be split by the threshold")
+	}
+}
+
+func TestFitTemperature(t *testing.T) {
+	// Correct label wins by a clear margin: a sharp distribution fits best.
+	sharp := [][]float64{{0.9, 0.1}, {0.2, 0.8}}
+	// Correct label loses half the time: a flat distribution fits best.
+	flat := [][]float64{{0.9, 0.1}, {0.9, 0.1}}
+	truth := []int{0, 1}
+
+	if ts, tf := FitTemperature(sharp, truth), FitTemperature(flat, truth); ts >= tf {
+		t.Errorf("expected sharper temperature for separable data: sharp=%f flat=%f", ts, tf)
+	}
+	if got := FitTemperature(sharp, []int{-1, -1}); got != 0 {
+		t.Errorf("no labeled entries should give temperature 0, got %f", got)
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	labels := []model.EmbeddedLabel{
+		{Path: "ERROR.timeout", Vector: []float32{1, 0}},
+		{Path: "REQUEST.success", Vector: []float32{0, 1}},
+	}
+	emb := vecEmbedder{
+		"t1": {1, 0.1}, "t2": {1, 0.2}, "t3": {1, 0.9},
+		"s1": {0.1, 1}, "s2": {0.9, 1},
+	}
+	entries := []corpus.CorpusEntry{
+		entry("t1", "ERROR", "timeout"),
+		entry("t2", "ERROR", "timeout"),
+		entry("t3", "REQUEST", "success"), // closer to timeout: a false positive
+		entry("s1", "REQUEST", "success"),
+		entry("s2", "REQUEST", "success"),
+	}
+
+	res, err := Calibrate(emb, classifier.New(0), labels, entries, CalibrateOptions{TargetPrecision: 1, MinSupport: 1})
+	if err != nil {
+		t.Fatalf("Calibrate() error: %v", err)
+	}
+	cal := res.Calibration
+	if cal.Temperature != 0 {
+		t.Errorf("temperature = %f, want 0 without softmax", cal.Temperature)
+	}
+	// The timeout threshold must sit above t3's similarity to exclude it.
+	t3 := 1 / math.Sqrt(1+0.81)
+	if th := cal.Thresholds["ERROR.timeout"]; th <= t3 {
+		t.Errorf("timeout threshold %f should exceed false-positive score %f", th, t3)
+	}
+	if _, ok := cal.Thresholds["REQUEST.success"]; !ok {
+		t.Error("expected a fitted threshold for REQUEST.success")
+	}
+
+	res, err = Calibrate(emb, classifier.New(0), labels, entries, CalibrateOptions{TargetPrecision: 1, MinSupport: 3, Softmax: true})
+	if err != nil {
+		t.Fatalf("Calibrate() error: %v", err)
+	}
+	if res.Calibration.Temperature <= 0 {
+		t.Error("expected a fitted temperature with Softmax")
+	}
+	if !strings.Contains(res.Skipped["REQUEST.success"], "need 3") {
+		t.Errorf("expected REQUEST.success skipped for support, got %q", res.Skipped["REQUEST.success"])
+	}
+	if err := res.Calibration.Validate(); err != nil {
+		t.Errorf("fitted calibration should validate: %v", err)
+	}
+}
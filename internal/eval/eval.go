@@ -0,0 +1,270 @@
+// Package eval measures classification quality against a labeled corpus.
+package eval
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// Unclassified is the label recorded when the engine returns no match.
+const Unclassified = "UNCLASSIFIED"
+
+// batchSize is the number of entries sent to the processor per call.
+const batchSize = 64
+
+// Processor classifies a batch of raw logs. Satisfied by *engine.Engine.
+type Processor interface {
+	ProcessBatch(raws []model.RawLog) ([]model.CanonicalEvent, error)
+}
+
+// Metrics holds precision, recall and F1 for a single class.
+type Metrics struct {
+	Label     string  `json:"label"`
+	Support   int     `json:"support"` // entries expected to have this label
+	TP        int     `json:"tp"`
+	FP        int     `json:"fp"`
+	FN        int     `json:"fn"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// Miss is a single misclassified entry.
+type Miss struct {
+	Description string  `json:"description,omitempty"`
+	Raw         string  `json:"raw"`
+	Expected    string  `json:"expected"`
+	Got         string  `json:"got"`
+	Confidence  float64 `json:"confidence"`
+}
+
+// Report summarizes an evaluation run.
+type Report struct {
+	Total        int     `json:"total"`
+	Correct      int     `json:"correct"`
+	Unclassified int     `json:"unclassified"`
+	Accuracy     float64 `json:"accuracy"`
+
+	PerType  []Metrics `json:"per_type"`
+	PerLabel []Metrics `json:"per_label"`
+
+	// Confusion counts entries by expected label, then predicted label.
+	// TypeConfusion is the same matrix collapsed to root types.
+	Confusion     map[string]map[string]int `json:"confusion"`
+	TypeConfusion map[string]map[string]int `json:"type_confusion"`
+
+	// Misses lists misclassified entries, most confident first: a confident
+	// wrong answer is worse than a hesitant one.
+	Misses []Miss `json:"misses"`
+}
+
+// LoadFile reads a labeled corpus from a JSON array or NDJSON file using the
+// same fields as the embedded corpus (raw, expected_type, expected_category).
+func LoadFile(path string) ([]corpus.CorpusEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("eval: %w", err)
+	}
+	entries, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("eval: %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Parse decodes a JSON array or NDJSON corpus. Blank NDJSON lines are skipped.
+func Parse(data []byte) ([]corpus.CorpusEntry, error) {
+	var entries []corpus.CorpusEntry
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &entries); err != nil {
+			return nil, fmt.Errorf("parse corpus: %w", err)
+		}
+	} else {
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for n := 1; sc.Scan(); n++ {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var e corpus.CorpusEntry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return nil, fmt.Errorf("parse corpus line %d: %w", n, err)
+			}
+			entries = append(entries, e)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("read corpus: %w", err)
+		}
+	}
+
+	for i, e := range entries {
+		if e.Raw == "" || e.ExpectedType == "" {
+			return nil, fmt.Errorf("entry %d: raw and expected_type are required", i+1)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("corpus is empty")
+	}
+	return entries, nil
+}
+
+// Run classifies every entry and scores the predictions.
+func Run(proc Processor, entries []corpus.CorpusEntry) (Report, error) {
+	got := make([]model.CanonicalEvent, 0, len(entries))
+	for start := 0; start < len(entries); start += batchSize {
+		end := min(start+batchSize, len(entries))
+		raws := make([]model.RawLog, end-start)
+		for i, e := range entries[start:end] {
+			raws[i] = model.RawLog{Raw: e.Raw}
+		}
+		events, err := proc.ProcessBatch(raws)
+		if err != nil {
+			return Report{}, fmt.Errorf("eval: classify entries %d-%d: %w", start+1, end, err)
+		}
+		got = append(got, events...)
+	}
+	return Score(entries, got), nil
+}
+
+// Score compares predicted events against the corpus labels. events[i] is
+// the prediction for entries[i].
+func Score(entries []corpus.CorpusEntry, events []model.CanonicalEvent) Report {
+	r := Report{
+		Total:         len(entries),
+		Confusion:     make(map[string]map[string]int),
+		TypeConfusion: make(map[string]map[string]int),
+	}
+	typeCounts := newCounter()
+	labelCounts := newCounter()
+
+	for i, e := range entries {
+		ev := events[i]
+		expected := labelPath(e.ExpectedType, e.ExpectedCategory)
+		predicted := labelPath(ev.Type, ev.Category)
+		if ev.Type == Unclassified {
+			predicted = Unclassified
+			r.Unclassified++
+		}
+
+		increment(r.Confusion, expected, predicted)
+		increment(r.TypeConfusion, e.ExpectedType, ev.Type)
+
+		typeCounts.add(e.ExpectedType, ev.Type)
+		labelCounts.add(expected, predicted)
+
+		if predicted == expected {
+			r.Correct++
+			continue
+		}
+		r.Misses = append(r.Misses, Miss{
+			Description: e.Description,
+			Raw:         e.Raw,
+			Expected:    expected,
+			Got:         predicted,
+			Confidence:  ev.Confidence,
+		})
+	}
+
+	if r.Total > 0 {
+		r.Accuracy = float64(r.Correct) / float64(r.Total)
+	}
+	r.PerType = typeCounts.metrics()
+	r.PerLabel = labelCounts.metrics()
+	sort.SliceStable(r.Misses, func(i, j int) bool {
+		return r.Misses[i].Confidence > r.Misses[j].Confidence
+	})
+	return r
+}
+
+func increment(m map[string]map[string]int, row, col string) {
+	if m[row] == nil {
+		m[row] = make(map[string]int)
+	}
+	m[row][col]++
+}
+
+// labelPath joins a type and category as "TYPE.category", or just the type
+// when the category is empty.
+func labelPath(typ, category string) string {
+	if category == "" {
+		return typ
+	}
+	return typ + "." + category
+}
+
+// counter accumulates true/false positives and false negatives per class.
+type counter map[string]*Metrics
+
+func newCounter() counter { return make(counter) }
+
+func (c counter) get(label string) *Metrics {
+	m, ok := c[label]
+	if !ok {
+		m = &Metrics{Label: label}
+		c[label] = m
+	}
+	return m
+}
+
+func (c counter) add(expected, predicted string) {
+	c.get(expected).Support++
+	if expected == predicted {
+		c.get(expected).TP++
+		return
+	}
+	c.get(expected).FN++
+	if predicted != Unclassified {
+		c.get(predicted).FP++
+	}
+}
+
+// metrics returns per-class metrics sorted by label, skipping classes that
+// were neither expected nor predicted.
+func (c counter) metrics() []Metrics {
+	out := make([]Metrics, 0, len(c))
+	for _, m := range c {
+		if m.TP+m.FP > 0 {
+			m.Precision = float64(m.TP) / float64(m.TP+m.FP)
+		}
+		if m.TP+m.FN > 0 {
+			m.Recall = float64(m.TP) / float64(m.TP+m.FN)
+		}
+		if m.Precision+m.Recall > 0 {
+			m.F1 = 2 * m.Precision * m.Recall / (m.Precision + m.Recall)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Label < out[j].Label })
+	return out
+}
+
+// sortedKeys returns every row and column key of a confusion matrix,
+// sorted, with UNCLASSIFIED last.
+func sortedKeys(m map[string]map[string]int) []string {
+	seen := make(map[string]bool)
+	for row, cols := range m {
+		seen[row] = true
+		for col := range cols {
+			seen[col] = true
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		if k != Unclassified {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	if seen[Unclassified] {
+		keys = append(keys, Unclassified)
+	}
+	return keys
+}
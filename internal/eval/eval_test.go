@@ -0,0 +1,205 @@
+package eval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// mapProcessor classifies each raw log by looking it up in a fixed map.
+type mapProcessor struct {
+	labels map[string]string // raw -> "TYPE.category" or "UNCLASSIFIED"
+	calls  int
+	err    error
+}
+
+func (m *mapProcessor) ProcessBatch(raws []model.RawLog) ([]model.CanonicalEvent, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	events := make([]model.CanonicalEvent, len(raws))
+	for i, raw := range raws {
+		typ, category, _ := strings.Cut(m.labels[raw.Raw], ".")
+		events[i] = model.CanonicalEvent{Type: typ, Category: category, Confidence: 0.5 + float64(i)/100}
+	}
+	return events, nil
+}
+
+func entry(raw, typ, category string) corpus.CorpusEntry {
+	return corpus.CorpusEntry{Raw: raw, ExpectedType: typ, ExpectedCategory: category}
+}
+
+func TestScore(t *testing.T) {
+	entries := []corpus.CorpusEntry{
+		entry("a1", "ERROR", "timeout"),
+		entry("a2", "ERROR", "timeout"),
+		entry("b1", "ERROR", "connection_failure"),
+		entry("c1", "REQUEST", "success"),
+		entry("c2", "REQUEST", "success"),
+	}
+	proc := &mapProcessor{labels: map[string]string{
+		"a1": "ERROR.timeout",
+		"a2": "ERROR.connection_failure", // wrong leaf, right type
+		"b1": "ERROR.connection_failure",
+		"c1": "REQUEST.success",
+		"c2": "UNCLASSIFIED",
+	}}
+
+	r, err := Run(proc, entries)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if r.Total != 5 || r.Correct != 3 || r.Unclassified != 1 {
+		t.Fatalf("total/correct/unclassified = %d/%d/%d, want 5/3/1", r.Total, r.Correct, r.Unclassified)
+	}
+	if math.Abs(r.Accuracy-0.6) > 1e-9 {
+		t.Errorf("accuracy = %f, want 0.6", r.Accuracy)
+	}
+
+	labels := map[string]Metrics{}
+	for _, m := range r.PerLabel {
+		labels[m.Label] = m
+	}
+	conn := labels["ERROR.connection_failure"]
+	if conn.TP != 1 || conn.FP != 1 || conn.FN != 0 || conn.Precision != 0.5 || conn.Recall != 1 {
+		t.Errorf("connection_failure metrics = %+v", conn)
+	}
+	timeout := labels["ERROR.timeout"]
+	if timeout.Support != 2 || timeout.Recall != 0.5 || timeout.Precision != 1 {
+		t.Errorf("timeout metrics = %+v", timeout)
+	}
+	if _, ok := labels[Unclassified]; ok {
+		t.Error("UNCLASSIFIED should not get its own metrics row")
+	}
+
+	types := map[string]Metrics{}
+	for _, m := range r.PerType {
+		types[m.Label] = m
+	}
+	if types["ERROR"].TP != 3 || types["ERROR"].Precision != 1 {
+		t.Errorf("ERROR type metrics = %+v", types["ERROR"])
+	}
+	if types["REQUEST"].Recall != 0.5 {
+		t.Errorf("REQUEST type recall = %f, want 0.5", types["REQUEST"].Recall)
+	}
+
+	if r.Confusion["ERROR.timeout"]["ERROR.connection_failure"] != 1 {
+		t.Error("confusion matrix missing timeout -> connection_failure")
+	}
+	if r.TypeConfusion["REQUEST"][Unclassified] != 1 {
+		t.Error("type confusion missing REQUEST -> UNCLASSIFIED")
+	}
+
+	if len(r.Misses) != 2 || r.Misses[0].Confidence < r.Misses[1].Confidence {
+		t.Errorf("misses should be sorted by confidence descending: %+v", r.Misses)
+	}
+}
+
+func TestRunBatches(t *testing.T) {
+	entries := make([]corpus.CorpusEntry, batchSize*2+1)
+	for i := range entries {
+		entries[i] = entry("x", "ERROR", "timeout")
+	}
+	proc := &mapProcessor{labels: map[string]string{"x": "ERROR.timeout"}}
+	r, err := Run(proc, entries)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if proc.calls != 3 {
+		t.Errorf("expected 3 batches, got %d", proc.calls)
+	}
+	if r.Correct != len(entries) {
+		t.Errorf("correct = %d, want %d", r.Correct, len(entries))
+	}
+
+	_, err = Run(&mapProcessor{err: errors.New("boom")}, entries)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("expected processor error, got: %v", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	array := `[{"raw":"a","expected_type":"ERROR","expected_category":"timeout"}]`
+	ndjson := "{\"raw\":\"a\",\"expected_type\":\"ERROR\",\"expected_category\":\"timeout\"}\n\n" +
+		"{\"raw\":\"b\",\"expected_type\":\"REQUEST\",\"expected_category\":\"success\"}\n"
+
+	entries, err := Parse([]byte(array))
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Parse(array) = %d entries, err %v", len(entries), err)
+	}
+	entries, err = Parse([]byte(ndjson))
+	if err != nil || len(entries) != 2 || entries[1].ExpectedCategory != "success" {
+		t.Fatalf("Parse(ndjson) = %+v, err %v", entries, err)
+	}
+
+	for name, data := range map[string]string{
+		"empty":        "",
+		"missing type": `[{"raw":"a"}]`,
+		"bad line":     "{\"raw\":\"a\",\"expected_type\":\"ERROR\"}\nnot json\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "corpus.ndjson")
+	if err := os.WriteFile(path, []byte(`{"raw":"a","expected_type":"ERROR","expected_category":"timeout"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := LoadFile(path)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("LoadFile() = %d entries, err %v", len(entries), err)
+	}
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("expected error for missing file")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	entries := []corpus.CorpusEntry{
+		entry("a1", "ERROR", "timeout"),
+		entry("b1\twith\ttabs", "ERROR", "connection_failure"),
+	}
+	proc := &mapProcessor{labels: map[string]string{
+		"a1":             "ERROR.timeout",
+		"b1\twith\ttabs": "ERROR.timeout",
+	}}
+	r, err := Run(proc, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text bytes.Buffer
+	if err := r.WriteText(&text, 10); err != nil {
+		t.Fatalf("WriteText() error: %v", err)
+	}
+	for _, want := range []string{"Accuracy: 50.0%", "PRECISION", "EXPECTED \\ GOT",
+		"ERROR.connection_failure -> ERROR.timeout", "WORST MISCLASSIFICATIONS (1 of 1)", "b1 with tabs"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report JSON does not round-trip: %v", err)
+	}
+	if decoded.Correct != 1 || len(decoded.PerLabel) != 2 {
+		t.Errorf("decoded report = %+v", decoded)
+	}
+}
@@ -0,0 +1,111 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes a human-readable report: overall accuracy, per-type and
+// per-label metrics, the type-level confusion matrix, label-level confusions,
+// and up to maxMisses of the most confident misclassifications.
+func (r Report) WriteText(w io.Writer, maxMisses int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Accuracy: %.1f%% (%d/%d correct, %d unclassified)\n\n",
+		r.Accuracy*100, r.Correct, r.Total, r.Unclassified)
+
+	writeMetrics(tw, "TYPE", r.PerType)
+	fmt.Fprintln(tw)
+	writeMetrics(tw, "LABEL", r.PerLabel)
+	fmt.Fprintln(tw)
+
+	// Type-level confusion matrix: rows are expected, columns predicted.
+	types := sortedKeys(r.TypeConfusion)
+	fmt.Fprint(tw, "EXPECTED \\ GOT")
+	for _, t := range types {
+		fmt.Fprintf(tw, "\t%s", t)
+	}
+	fmt.Fprintln(tw)
+	for _, row := range types {
+		if r.TypeConfusion[row] == nil {
+			continue
+		}
+		fmt.Fprint(tw, row)
+		for _, col := range types {
+			fmt.Fprintf(tw, "\t%d", r.TypeConfusion[row][col])
+		}
+		fmt.Fprintln(tw)
+	}
+
+	// Label-level confusions, most frequent first. The full matrix is in the
+	// JSON output; with 40+ labels a grid is unreadable in a terminal.
+	type pair struct {
+		expected, got string
+		n             int
+	}
+	var pairs []pair
+	for expected, row := range r.Confusion {
+		for got, n := range row {
+			if got != expected {
+				pairs = append(pairs, pair{expected, got, n})
+			}
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].n != pairs[j].n {
+			return pairs[i].n > pairs[j].n
+		}
+		if pairs[i].expected != pairs[j].expected {
+			return pairs[i].expected < pairs[j].expected
+		}
+		return pairs[i].got < pairs[j].got
+	})
+	if len(pairs) > 0 {
+		fmt.Fprintln(tw, "\nCONFUSED AS\tCOUNT")
+		for _, p := range pairs {
+			fmt.Fprintf(tw, "%s -> %s\t%d\n", p.expected, p.got, p.n)
+		}
+	}
+
+	if n := min(maxMisses, len(r.Misses)); n > 0 {
+		fmt.Fprintf(tw, "\nWORST MISCLASSIFICATIONS (%d of %d)\n", n, len(r.Misses))
+		fmt.Fprintln(tw, "CONF\tEXPECTED\tGOT\tLOG")
+		for _, m := range r.Misses[:n] {
+			text := m.Description
+			if text == "" {
+				text = m.Raw
+			}
+			text = strings.Join(strings.Fields(text), " ")
+			fmt.Fprintf(tw, "%.3f\t%s\t%s\t%s\n", m.Confidence, m.Expected, m.Got, truncate(text, 80))
+		}
+	}
+
+	return tw.Flush()
+}
+
+func writeMetrics(w io.Writer, title string, metrics []Metrics) {
+	fmt.Fprintf(w, "%s\tPRECISION\tRECALL\tF1\tSUPPORT\n", title)
+	for _, m := range metrics {
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%.3f\t%d\n", m.Label, m.Precision, m.Recall, m.F1, m.Support)
+	}
+}
+
+// truncate shortens s to at most n runes, marking the cut with "...".
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-3]) + "..."
+}
@@ -4,12 +4,81 @@ import "time"
 
 // CanonicalEvent is Lumber's output type — a classified, normalized log event.
 type CanonicalEvent struct {
-	Type       string    `json:"type"`
-	Category   string    `json:"category"`
-	Severity   string    `json:"severity"`
-	Timestamp  time.Time `json:"timestamp"`
-	Summary    string    `json:"summary"`
-	Confidence float64   `json:"confidence,omitempty"`
-	Raw        string    `json:"raw,omitempty"`
-	Count      int       `json:"count,omitempty"` // >0 when deduplicated
+	Type           string         `json:"type"`
+	Category       string         `json:"category"`
+	Severity       string         `json:"severity"`
+	SourceSeverity string         `json:"source_severity,omitempty"` // level declared by the log itself, normalized
+	Timestamp      time.Time      `json:"timestamp"`
+	Source         string         `json:"source,omitempty"`      // connector that produced the log, e.g. "vercel"
+	TemplateID     string         `json:"template_id,omitempty"` // stable id of the mined message template
+	Template       string         `json:"template,omitempty"`    // message template, e.g. "connection to <*> refused"
+	Summary        string         `json:"summary"`
+	Confidence     float64        `json:"confidence,omitempty"`
+	Method         string         `json:"method,omitempty"`       // "rule" when a rule matched; empty = embedding
+	Alternatives   []Alternative  `json:"alternatives,omitempty"` // runner-up labels when top-k is enabled
+	Margin         float64        `json:"margin,omitempty"`       // best minus runner-up score
+	Ambiguous      bool           `json:"ambiguous,omitempty"`    // margin below the ambiguity threshold
+	Attributes     map[string]any `json:"attributes,omitempty"`   // curated connector metadata (region, status, ...)
+	Fields         map[string]any `json:"fields,omitempty"`       // values extracted from the log line (status, duration_ms, ...)
+	Raw            string         `json:"raw,omitempty"`
+	Count          int            `json:"count,omitempty"`    // >0 when deduplicated
+	Variants       []string       `json:"variants,omitempty"` // distinct templates/summaries merged by dedup
+	Samples        []string       `json:"samples,omitempty"`  // distinct raw texts merged by dedup
+	Rollup         *Rollup        `json:"rollup,omitempty"`   // group state in stateful stream dedup
+	Stack          *Stack         `json:"stack,omitempty"`    // parsed stack trace of ERROR events
+
+	// TimeSource is how Timestamp was obtained for logs read from text:
+	// TimeParsed or TimeInferred. Empty when the provider reported it.
+	TimeSource string `json:"time_source,omitempty"`
+
+	// Vector is the log's embedding when the model classified it (nil for
+	// rule hits and empty input). Not serialized; used by similarity dedup.
+	Vector []float32 `json:"-"`
+	// Verbosity is the verbosity a compaction policy chose for this event
+	// (minimal, standard, full or summary); empty when no policy is set.
+	// Not serialized; output formatting honors it over its own verbosity.
+	Verbosity string `json:"-"`
+}
+
+// Alternative is a runner-up taxonomy label and its similarity score.
+type Alternative struct {
+	Path       string  `json:"path"` // e.g. "ERROR.timeout"
+	Confidence float64 `json:"confidence"`
+}
+
+// Rollup states.
+const (
+	RollupFirst    = "first"    // first occurrence of a new group
+	RollupOngoing  = "ongoing"  // periodic update while the group keeps occurring
+	RollupResolved = "resolved" // final update once the group has gone quiet
+)
+
+// Rollup describes a dedup group that stays open across flushes. The
+// first, ongoing and resolved events of a group share its GroupID.
+type Rollup struct {
+	GroupID    string    `json:"group_id"`
+	State      string    `json:"state"`        // first, ongoing or resolved
+	Count      int       `json:"count"`        // occurrences so far
+	New        int       `json:"new"`          // occurrences since the previous update
+	RatePerMin float64   `json:"rate_per_min"` // occurrences per minute between first and last seen
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// Stack is a stack trace parsed from an ERROR event.
+type Stack struct {
+	Language  string  `json:"language"`            // java, go, python, node, rust, dotnet or ruby
+	Exception string  `json:"exception,omitempty"` // exception or panic type
+	Message   string  `json:"message,omitempty"`
+	Frames    []Frame `json:"frames"`
+	Omitted   int     `json:"omitted,omitempty"` // frames dropped by compaction
+}
+
+// Frame is one stack frame. App is false for standard library, runtime and
+// third-party dependency frames.
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	App      bool   `json:"app"`
 }
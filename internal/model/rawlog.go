@@ -4,8 +4,15 @@ import "time"
 
 // RawLog is the intermediate type produced by connectors and consumed by the engine.
 type RawLog struct {
-	Timestamp time.Time
-	Source    string         // provider name (e.g. "vercel", "aws")
-	Raw       string         // original log text
-	Metadata  map[string]any // provider-specific metadata
+	Timestamp  time.Time
+	TimeSource string         // TimeParsed or TimeInferred; empty = reported by the provider
+	Source     string         // provider name (e.g. "vercel", "aws")
+	Raw        string         // original log text
+	Metadata   map[string]any // provider-specific metadata
 }
+
+// Timestamp sources of logs read from text (stdin, files).
+const (
+	TimeParsed   = "parsed"   // found in the log text
+	TimeInferred = "inferred" // none found; the time the log was read
+)
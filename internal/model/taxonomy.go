@@ -6,11 +6,24 @@ type TaxonomyNode struct {
 	Children []*TaxonomyNode
 	Desc     string // description used for embedding
 	Severity string // leaf-level severity (error, warning, info, debug)
+
+	// Examples are log lines that belong to this leaf. Their embeddings join
+	// the description as the label's prototypes.
+	Examples []string
+	// NegativeExamples are log lines that look similar but belong elsewhere.
+	// Scores close to a negative are penalized.
+	NegativeExamples []string
+	// Threshold is the leaf's minimum confidence; 0 uses the global threshold.
+	Threshold float64
 }
 
 // EmbeddedLabel is a taxonomy leaf with its pre-computed embedding vector.
 type EmbeddedLabel struct {
-	Path     string    // e.g. "ERROR.connection_failure"
-	Vector   []float32
-	Severity string // leaf-level severity carried from TaxonomyNode
+	Path      string      // e.g. "ERROR.connection_failure"
+	Vector    []float32   // embedding of "{Parent}: {Desc}"
+	Severity  string      // leaf-level severity carried from TaxonomyNode
+	Threshold float64     // per-label threshold carried from TaxonomyNode; 0 = global
+	Exemplars [][]float32 // embeddings of Examples
+	Negatives [][]float32 // embeddings of NegativeExamples
+	Centroid  []float32   // mean of Vector and Exemplars (unit-normalized)
 }
@@ -6,12 +6,27 @@ import (
 )
 
 // FormatEvent returns a copy of the event with fields stripped according to verbosity.
-// At Minimal: Raw and Confidence are zeroed (omitted from JSON via omitempty).
+// At Minimal: Raw, Samples, Confidence, Alternatives, Margin, Attributes and
+// Template are zeroed (omitted from JSON via omitempty); Source, TemplateID,
+// Fields, Stack, Variants and the Ambiguous flag are kept, since extracted
+// fields and frames stand in for the dropped raw text.
 // At Standard/Full: all fields preserved.
+// An event whose verbosity was chosen by a compaction policy (e.Verbosity)
+// is formatted at that verbosity instead; "summary" formats as Minimal.
 func FormatEvent(e model.CanonicalEvent, verbosity compactor.Verbosity) model.CanonicalEvent {
+	if v, ok := compactor.ParseVerbosity(e.Verbosity); ok {
+		verbosity = v
+	} else if e.Verbosity == compactor.Summary {
+		verbosity = compactor.Minimal
+	}
 	if verbosity == compactor.Minimal {
 		e.Raw = ""
+		e.Samples = nil
 		e.Confidence = 0
+		e.Alternatives = nil
+		e.Margin = 0
+		e.Attributes = nil
+		e.Template = ""
 	}
 	return e
 }
@@ -117,3 +117,76 @@ func TestJSONTagNames(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatEventAlternatives(t *testing.T) {
+	e := baseEvent()
+	e.Alternatives = []model.Alternative{{Path: "ERROR.timeout", Confidence: 0.89}}
+	e.Margin = 0.02
+	e.Ambiguous = true
+
+	std := FormatEvent(e, compactor.Standard)
+	if len(std.Alternatives) != 1 || std.Margin != 0.02 {
+		t.Fatal("Alternatives and Margin should be preserved at Standard")
+	}
+
+	minimal := FormatEvent(e, compactor.Minimal)
+	if minimal.Alternatives != nil || minimal.Margin != 0 {
+		t.Fatal("Alternatives and Margin should be stripped at Minimal")
+	}
+	if !minimal.Ambiguous {
+		t.Fatal("Ambiguous should be preserved at Minimal")
+	}
+
+	// Without top-k the fields are omitted from JSON entirely.
+	data, _ := json.Marshal(baseEvent())
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	for _, key := range []string{"alternatives", "margin", "ambiguous"} {
+		if _, ok := m[key]; ok {
+			t.Fatalf("%q should be omitted when unset", key)
+		}
+	}
+}
+
+func TestFormatEventAttributes(t *testing.T) {
+	e := baseEvent()
+	e.Source = "vercel"
+	e.Attributes = map[string]any{"status": 502, "path": "/api/users"}
+	e.Fields = map[string]any{"status": 502}
+	e.TemplateID, e.Template = "1c130cdd", "GET <*> 502"
+	e.Samples = []string{"GET /a 502", "GET /b 502"}
+
+	std := FormatEvent(e, compactor.Standard)
+	if std.Source != "vercel" || len(std.Attributes) != 2 {
+		t.Fatal("Source and Attributes should be preserved at Standard")
+	}
+
+	minimal := FormatEvent(e, compactor.Minimal)
+	if minimal.Attributes != nil {
+		t.Fatal("Attributes should be stripped at Minimal")
+	}
+	if minimal.Source != "vercel" || minimal.Fields["status"] != 502 {
+		t.Fatal("Source and Fields should be preserved at Minimal")
+	}
+	if minimal.TemplateID != "1c130cdd" || minimal.Template != "" {
+		t.Fatal("Minimal should keep TemplateID and drop Template")
+	}
+	if minimal.Samples != nil || len(std.Samples) != 2 {
+		t.Fatal("Samples should be stripped at Minimal only")
+	}
+}
+
+func TestFormatEventPolicyVerbosity(t *testing.T) {
+	e := baseEvent()
+	e.Verbosity = "full"
+	if got := FormatEvent(e, compactor.Minimal); got.Raw == "" || got.Confidence == 0 {
+		t.Error("event with policy verbosity full should keep raw and confidence at Minimal output")
+	}
+
+	e.Verbosity = compactor.Summary
+	if got := FormatEvent(e, compactor.Full); got.Raw != "" || got.Confidence != 0 || got.Summary == "" {
+		t.Errorf("summary event should format as Minimal, got raw %q confidence %v", got.Raw, got.Confidence)
+	}
+}
@@ -0,0 +1,191 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// batchJob is a micro-batch of raw logs on its way through the workers.
+// done receives the processed events; it is buffered so workers never block.
+type batchJob struct {
+	raws []model.RawLog
+	done chan []model.CanonicalEvent
+}
+
+// streamBatched accumulates logs into micro-batches, processes them on
+// p.workers goroutines with ProcessBatch, and writes the results in arrival
+// order, so events from any one source keep their relative order.
+//
+// A batch is dispatched when it reaches p.batchSize logs or when its oldest
+// log has waited p.maxLatency. On cancellation or end of input the partial
+// batch and all in-flight batches are still processed and written.
+func (p *Pipeline) streamBatched(ctx context.Context, ch <-chan model.RawLog) error {
+	// abort stops the collector and workers early when an output write fails.
+	abort, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	jobs := make(chan *batchJob)
+	ordered := make(chan *batchJob, 2*p.workers) // dispatch order; bounds in-flight batches
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				job.done <- p.processBatch(job.raws)
+			}
+		}()
+	}
+	defer wg.Wait()
+
+	go func() {
+		defer close(ordered)
+		defer close(jobs)
+		p.collect(ctx, abort, ch, jobs, ordered)
+	}()
+
+	var buf eventBuffer
+	if p.dedup != nil || p.tracker != nil {
+		buf = p.newBuffer()
+	}
+
+	// Once ctx is cancelled, keep draining with a background context so
+	// in-flight writes complete. The shutdown timer in main.go provides the
+	// hard bound.
+	writeCtx := func() context.Context {
+		if ctx.Err() != nil {
+			return context.Background()
+		}
+		return ctx
+	}
+
+	for {
+		var flushCh <-chan time.Time
+		if buf != nil {
+			flushCh = buf.flushCh()
+		}
+
+		select {
+		case job, ok := <-ordered:
+			if !ok {
+				if buf != nil {
+					if err := buf.drain(context.Background()); err != nil {
+						return fmt.Errorf("pipeline flush: %w", err)
+					}
+				}
+				if skipped := p.skippedLogs.Load(); skipped > 0 {
+					msg := "stream ended"
+					if ctx.Err() != nil {
+						msg = "stream stopped"
+					}
+					slog.Info(msg, "skipped_logs", skipped)
+				}
+				return ctx.Err()
+			}
+
+			events := <-job.done
+			p.queued.Add(-int64(len(job.raws)))
+			for _, event := range events {
+				if buf == nil {
+					if err := p.output.Write(writeCtx(), event); err != nil {
+						stop()
+						return fmt.Errorf("pipeline output: %w", err)
+					}
+					p.writtenEvents.Add(1)
+					continue
+				}
+				if buf.add(event) {
+					if err := buf.flushDue(writeCtx()); err != nil {
+						stop()
+						return fmt.Errorf("pipeline flush (buffer full): %w", err)
+					}
+				}
+			}
+		case <-flushCh:
+			if err := buf.flushDue(writeCtx()); err != nil {
+				stop()
+				return fmt.Errorf("pipeline flush: %w", err)
+			}
+		}
+	}
+}
+
+// collect reads logs from ch into batches and hands each batch to the
+// workers (via jobs) and to the writer (via ordered, in dispatch order).
+// It returns when ch closes, ctx is cancelled, or abort is done.
+func (p *Pipeline) collect(ctx, abort context.Context, ch <-chan model.RawLog, jobs, ordered chan<- *batchJob) {
+	var batch []model.RawLog
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	dispatch := func() bool {
+		if timer != nil {
+			timer.Stop()
+			timer, timerC = nil, nil
+		}
+		if len(batch) == 0 {
+			return true
+		}
+		job := &batchJob{raws: batch, done: make(chan []model.CanonicalEvent, 1)}
+		batch = nil
+		select {
+		case ordered <- job:
+		case <-abort.Done():
+			return false
+		}
+		select {
+		case jobs <- job:
+		case <-abort.Done():
+			return false
+		}
+		p.batches.Add(1)
+		p.batchedLogs.Add(int64(len(job.raws)))
+		return true
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			dispatch()
+			return
+		case <-abort.Done():
+			return
+		case raw, ok := <-ch:
+			if !ok {
+				dispatch()
+				return
+			}
+			p.receivedLogs.Add(1)
+			p.queued.Add(1)
+			batch = append(batch, raw)
+			if len(batch) == 1 {
+				timer = time.NewTimer(p.maxLatency)
+				timerC = timer.C
+			}
+			if len(batch) >= p.batchSize && !dispatch() {
+				return
+			}
+		case <-timerC:
+			if !dispatch() {
+				return
+			}
+		}
+	}
+}
+
+// processBatch classifies a batch, falling back to one log at a time when
+// the batch call fails so a single bad log does not drop its neighbours.
+func (p *Pipeline) processBatch(raws []model.RawLog) []model.CanonicalEvent {
+	events, err := p.engine.ProcessBatch(raws)
+	if err != nil {
+		slog.Warn("batch processing failed, falling back to individual", "error", err, "count", len(raws))
+		return p.processIndividual(raws)
+	}
+	return events
+}
@@ -0,0 +1,233 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kaminocorp/lumber/internal/connector"
+	"github.com/kaminocorp/lumber/internal/engine/dedup"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
+// batchRecorder is a categoryProcessor that records the size of every
+// ProcessBatch call and can sleep a random amount per batch to shuffle
+// completion order across workers.
+type batchRecorder struct {
+	categoryProcessor
+	jitter time.Duration
+
+	mu    sync.Mutex
+	sizes []int
+}
+
+func (b *batchRecorder) ProcessBatch(raws []model.RawLog) ([]model.CanonicalEvent, error) {
+	b.mu.Lock()
+	b.sizes = append(b.sizes, len(raws))
+	b.mu.Unlock()
+	if b.jitter > 0 {
+		time.Sleep(time.Duration(rand.Int63n(int64(b.jitter))))
+	}
+	return b.categoryProcessor.ProcessBatch(raws)
+}
+
+func (b *batchRecorder) Sizes() []int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]int(nil), b.sizes...)
+}
+
+// chanConnector streams whatever the test sends on ch.
+type chanConnector struct {
+	ch chan model.RawLog
+}
+
+func (c *chanConnector) Stream(context.Context, connector.ConnectorConfig) (<-chan model.RawLog, error) {
+	return c.ch, nil
+}
+
+func (c *chanConnector) Query(context.Context, connector.ConnectorConfig, connector.QueryParams) ([]model.RawLog, error) {
+	return nil, nil
+}
+
+// failingOutput rejects every write.
+type failingOutput struct{}
+
+func (failingOutput) Write(context.Context, model.CanonicalEvent) error {
+	return errors.New("output down")
+}
+
+func (failingOutput) Close() error { return nil }
+
+func numberedLogs(n int) []model.RawLog {
+	t0 := time.Now()
+	logs := make([]model.RawLog, n)
+	for i := range logs {
+		logs[i] = model.RawLog{Timestamp: t0, Source: "test", Raw: fmt.Sprintf("log-%03d", i)}
+	}
+	return logs
+}
+
+func TestStreamBatched_BatchesBySize(t *testing.T) {
+	conn := &mockConnector{logs: numberedLogs(10)}
+	out := &mockOutput{}
+	proc := &batchRecorder{}
+
+	p := New(conn, proc, out, WithBatching(4, time.Second))
+	if err := p.Stream(context.Background(), connector.ConnectorConfig{}); err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	if got := proc.Sizes(); fmt.Sprint(got) != "[4 4 2]" {
+		t.Errorf("batch sizes = %v, want [4 4 2]", got)
+	}
+	if n := len(out.Events()); n != 10 {
+		t.Errorf("expected 10 events, got %d", n)
+	}
+
+	s := p.Stats()
+	if s.Received != 10 || s.Written != 10 || s.Batches != 3 || s.QueueDepth != 0 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+	if s.AvgBatchSize < 3.3 || s.AvgBatchSize > 3.4 {
+		t.Errorf("avg batch size = %f, want 10/3", s.AvgBatchSize)
+	}
+}
+
+func TestStreamBatched_MaxLatency(t *testing.T) {
+	conn := &chanConnector{ch: make(chan model.RawLog)}
+	out := &mockOutput{}
+	proc := &batchRecorder{}
+	p := New(conn, proc, out, WithBatching(100, 20*time.Millisecond))
+
+	done := make(chan error, 1)
+	go func() { done <- p.Stream(context.Background(), connector.ConnectorConfig{}) }()
+
+	for _, raw := range numberedLogs(2) {
+		conn.ch <- raw
+	}
+
+	// The batch never fills; the latency bound must dispatch it.
+	deadline := time.Now().Add(time.Second)
+	for len(out.Events()) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("partial batch was not dispatched after max latency")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	close(conn.ch)
+	if err := <-done; err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+	if got := proc.Sizes(); fmt.Sprint(got) != "[2]" {
+		t.Errorf("batch sizes = %v, want [2]", got)
+	}
+}
+
+func TestStreamBatched_PreservesOrderAcrossWorkers(t *testing.T) {
+	logs := numberedLogs(200)
+	conn := &mockConnector{logs: logs}
+	out := &mockOutput{}
+	proc := &batchRecorder{jitter: 3 * time.Millisecond}
+
+	p := New(conn, proc, out, WithBatching(7, time.Second), WithWorkers(4))
+	if err := p.Stream(context.Background(), connector.ConnectorConfig{}); err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	events := out.Events()
+	if len(events) != len(logs) {
+		t.Fatalf("expected %d events, got %d", len(logs), len(events))
+	}
+	for i, e := range events {
+		if e.Summary != logs[i].Raw {
+			t.Fatalf("events[%d] = %q, want %q (output out of order)", i, e.Summary, logs[i].Raw)
+		}
+	}
+}
+
+func TestStreamBatched_FallsBackOnBatchError(t *testing.T) {
+	t0 := time.Now()
+	conn := &mockConnector{logs: []model.RawLog{
+		{Timestamp: t0, Source: "test", Raw: "good log 1"},
+		{Timestamp: t0, Source: "test", Raw: "BAD"},
+		{Timestamp: t0, Source: "test", Raw: "good log 2"},
+	}}
+	out := &mockOutput{}
+	proc := &mockProcessor{failOn: "BAD"}
+
+	p := New(conn, proc, out, WithBatching(8, time.Second))
+	if err := p.Stream(context.Background(), connector.ConnectorConfig{}); err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	events := out.Events()
+	if len(events) != 2 || events[0].Summary != "good log 1" || events[1].Summary != "good log 2" {
+		t.Fatalf("expected the 2 good logs in order, got %+v", events)
+	}
+	if s := p.Stats(); s.Skipped != 1 {
+		t.Errorf("expected 1 skipped log, got %d", s.Skipped)
+	}
+}
+
+func TestStreamBatched_WithDedup(t *testing.T) {
+	t0 := time.Now()
+	var logs []model.RawLog
+	for i := 0; i < 6; i++ {
+		logs = append(logs, model.RawLog{Timestamp: t0, Raw: "same"})
+	}
+	logs = append(logs, model.RawLog{Timestamp: t0, Raw: "other"})
+	conn := &mockConnector{logs: logs}
+	out := &mockOutput{}
+	d := dedup.New(dedup.Config{Window: time.Second})
+
+	p := New(conn, &categoryProcessor{}, out, WithDedup(d, 50*time.Millisecond), WithBatching(3, time.Second), WithWorkers(2))
+	if err := p.Stream(context.Background(), connector.ConnectorConfig{}); err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	events := out.Events()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 deduplicated events, got %d", len(events))
+	}
+	if events[0].Category != "same" || events[0].Count != 6 {
+		t.Errorf("events[0] = %+v, want 'same' x6", events[0])
+	}
+}
+
+func TestStreamBatched_CancelDrainsInFlight(t *testing.T) {
+	conn := &chanConnector{ch: make(chan model.RawLog)}
+	out := &mockOutput{}
+	p := New(conn, &categoryProcessor{}, out, WithBatching(100, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- p.Stream(ctx, connector.ConnectorConfig{}) }()
+
+	for _, raw := range numberedLogs(3) {
+		conn.ch <- raw
+	}
+	cancel()
+
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	if n := len(out.Events()); n != 3 {
+		t.Errorf("expected the 3 buffered logs to be written on shutdown, got %d", n)
+	}
+}
+
+func TestStreamBatched_OutputErrorStops(t *testing.T) {
+	conn := &mockConnector{logs: numberedLogs(50)}
+	p := New(conn, &categoryProcessor{}, failingOutput{}, WithBatching(5, time.Second), WithWorkers(3))
+
+	err := p.Stream(context.Background(), connector.ConnectorConfig{})
+	if err == nil || err.Error() != "pipeline output: output down" {
+		t.Fatalf("expected output error, got: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"time"
 
@@ -10,17 +11,57 @@ import (
 	"github.com/kaminocorp/lumber/internal/output"
 )
 
+// eventBuffer sits between processing and output when stream mode
+// deduplicates events.
+type eventBuffer interface {
+	// add takes an event and reports whether the buffer needs flushing now.
+	add(event model.CanonicalEvent) bool
+	// flushCh fires when the buffer should be flushed; nil when idle.
+	flushCh() <-chan time.Time
+	// flushDue writes the events that are due.
+	flushDue(ctx context.Context) error
+	// drain writes everything still held, at the end of the stream.
+	drain(ctx context.Context) error
+}
+
+// newBuffer returns the stream buffer for the pipeline's dedup mode.
+func (p *Pipeline) newBuffer() eventBuffer {
+	onWrite := func() { p.writtenEvents.Add(1) }
+	if p.tracker != nil {
+		return newTrackerBuffer(p.tracker, p.output, p.tick, onWrite)
+	}
+	b := newStreamBuffer(p.dedup, p.output, p.window, p.maxBufferSize, onWrite)
+	b.windows = p.windows
+	return b
+}
+
 // streamBuffer accumulates events and flushes deduplicated batches on a timer.
+// Events are held in lanes by window: the buffer window by default, or the
+// override for the event's type or severity, so errors can be written
+// immediately while info and debug noise waits longer.
 type streamBuffer struct {
 	dedup   *dedup.Deduplicator
 	out     output.Output
 	window  time.Duration
-	maxSize int // 0 means unlimited (backward compat)
-	onWrite func() // called after each successful Write
+	windows dedup.Windows // per-type/severity window overrides
+	maxSize int           // 0 means unlimited (backward compat)
+	onWrite func()        // called after each successful Write
 
-	mu      sync.Mutex
+	mu    sync.Mutex
+	lanes []*lane
+	size  int // pending events across lanes
+	timer *time.Timer
+}
+
+// lane holds the pending events that share a window.
+type lane struct {
+	window  time.Duration
 	pending []model.CanonicalEvent
-	timer   *time.Timer
+	opened  time.Time // when the oldest pending event arrived
+}
+
+func (l *lane) deadline() time.Time {
+	return l.opened.Add(l.window)
 }
 
 func newStreamBuffer(d *dedup.Deduplicator, out output.Output, window time.Duration, maxSize int, onWrite func()) *streamBuffer {
@@ -33,18 +74,51 @@ func newStreamBuffer(d *dedup.Deduplicator, out output.Output, window time.Durat
 	}
 }
 
-// add appends an event to the buffer. If this is the first event, starts the flush timer.
-// Returns true if the buffer is full and needs flushing.
+// add appends an event to its lane. If the lane was empty, its window
+// starts now. Returns true if the event is due immediately (zero window)
+// or the buffer is full and needs flushing.
 func (b *streamBuffer) add(event model.CanonicalEvent) bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.pending = append(b.pending, event)
-	if len(b.pending) == 1 {
-		// First event — start timer.
-		b.timer = time.NewTimer(b.window)
+	w := b.windows.For(event, b.window)
+	l := b.lane(w)
+	l.pending = append(l.pending, event)
+	b.size++
+	if len(l.pending) == 1 {
+		l.opened = time.Now()
+		b.arm()
+	}
+	return w <= 0 || b.maxSize > 0 && b.size >= b.maxSize
+}
+
+// lane returns the lane for window w, creating it. Caller holds b.mu.
+func (b *streamBuffer) lane(w time.Duration) *lane {
+	for _, l := range b.lanes {
+		if l.window == w {
+			return l
+		}
+	}
+	l := &lane{window: w}
+	b.lanes = append(b.lanes, l)
+	return l
+}
+
+// arm sets the timer to the earliest lane deadline. Caller holds b.mu.
+func (b *streamBuffer) arm() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	var next time.Time
+	for _, l := range b.lanes {
+		if len(l.pending) > 0 && (next.IsZero() || l.deadline().Before(next)) {
+			next = l.deadline()
+		}
+	}
+	if !next.IsZero() {
+		b.timer = time.NewTimer(time.Until(next))
 	}
-	return b.maxSize > 0 && len(b.pending) >= b.maxSize
 }
 
 // flushCh returns the timer's channel, or nil if no timer is active.
@@ -59,21 +133,117 @@ func (b *streamBuffer) flushCh() <-chan time.Time {
 
 // flush deduplicates and writes all pending events.
 func (b *streamBuffer) flush(ctx context.Context) error {
+	return b.write(ctx, true)
+}
+
+// flushDue deduplicates and writes the lanes whose window has elapsed, or
+// every lane when the buffer is full.
+func (b *streamBuffer) flushDue(ctx context.Context) error {
+	return b.write(ctx, false)
+}
+
+func (b *streamBuffer) write(ctx context.Context, all bool) error {
 	b.mu.Lock()
-	events := b.pending
-	b.pending = nil
-	if b.timer != nil {
-		b.timer.Stop()
-		b.timer = nil
+	if b.maxSize > 0 && b.size >= b.maxSize {
+		all = true
+	}
+	now := time.Now()
+	var due []lane
+	for _, l := range b.lanes {
+		if len(l.pending) > 0 && (all || !now.Before(l.deadline())) {
+			due = append(due, *l)
+			b.size -= len(l.pending)
+			l.pending = nil
+		}
 	}
+	b.arm()
 	b.mu.Unlock()
 
-	if len(events) == 0 {
-		return nil
+	// Oldest lane first, so events keep roughly their arrival order.
+	sort.SliceStable(due, func(i, j int) bool { return due[i].opened.Before(due[j].opened) })
+	for _, l := range due {
+		// The default lane merges within the dedup window as before;
+		// override lanes merge within their own window.
+		var deduped []model.CanonicalEvent
+		if l.window == b.window {
+			deduped = b.dedup.DeduplicateBatch(l.pending)
+		} else {
+			deduped = b.dedup.DeduplicateWindow(l.pending, l.window)
+		}
+		for _, e := range deduped {
+			if err := b.out.Write(ctx, e); err != nil {
+				return err
+			}
+			if b.onWrite != nil {
+				b.onWrite()
+			}
+		}
+	}
+	return nil
+}
+
+// drain flushes the remaining events.
+func (b *streamBuffer) drain(ctx context.Context) error {
+	return b.flush(ctx)
+}
+
+// trackerBuffer deduplicates with a stateful dedup.Tracker: first
+// occurrences are written as soon as they arrive, rollup and resolved
+// events on each tick.
+type trackerBuffer struct {
+	tracker *dedup.Tracker
+	out     output.Output
+	onWrite func()
+	ticker  *time.Ticker
+
+	mu      sync.Mutex
+	pending []model.CanonicalEvent // first occurrences not yet written
+}
+
+func newTrackerBuffer(t *dedup.Tracker, out output.Output, tick time.Duration, onWrite func()) *trackerBuffer {
+	return &trackerBuffer{
+		tracker: t,
+		out:     out,
+		onWrite: onWrite,
+		ticker:  time.NewTicker(tick),
 	}
+}
+
+// add records the event and returns true when it opened a new group, so
+// its first occurrence is written without waiting for a tick.
+func (b *trackerBuffer) add(event model.CanonicalEvent) bool {
+	first, ok := b.tracker.Add(event, time.Now())
+	if !ok {
+		return false
+	}
+	b.mu.Lock()
+	b.pending = append(b.pending, first)
+	b.mu.Unlock()
+	return true
+}
+
+func (b *trackerBuffer) flushCh() <-chan time.Time {
+	return b.ticker.C
+}
+
+// flushDue writes pending first occurrences and the rollups that are due.
+func (b *trackerBuffer) flushDue(ctx context.Context) error {
+	return b.write(ctx, b.tracker.Tick(time.Now()))
+}
+
+// drain writes pending first occurrences and resolves all open groups.
+func (b *trackerBuffer) drain(ctx context.Context) error {
+	b.ticker.Stop()
+	return b.write(ctx, b.tracker.Close())
+}
+
+func (b *trackerBuffer) write(ctx context.Context, due []model.CanonicalEvent) error {
+	b.mu.Lock()
+	events := append(b.pending, due...)
+	b.pending = nil
+	b.mu.Unlock()
 
-	deduped := b.dedup.DeduplicateBatch(events)
-	for _, e := range deduped {
+	for _, e := range events {
 		if err := b.out.Write(ctx, e); err != nil {
 			return err
 		}
@@ -26,9 +26,34 @@ type Pipeline struct {
 	output        output.Output
 	dedup         *dedup.Deduplicator
 	window        time.Duration
+	windows       dedup.Windows
+	tracker       *dedup.Tracker
+	tick          time.Duration
 	maxBufferSize int
+	batchSize     int
+	maxLatency    time.Duration
+	workers       int
+	statsInterval time.Duration
+
+	started       time.Time
+	receivedLogs  atomic.Int64
 	skippedLogs   atomic.Int64
 	writtenEvents atomic.Int64
+	batches       atomic.Int64
+	batchedLogs   atomic.Int64
+	queued        atomic.Int64 // logs received by the batcher but not yet written
+}
+
+// Stats is a snapshot of pipeline activity. Received, Batches, AvgBatchSize
+// and QueueDepth are only tracked in batched stream mode (see WithBatching).
+type Stats struct {
+	Received     int64         // logs read from the connector
+	Written      int64         // events written to the output
+	Skipped      int64         // logs that failed processing
+	Batches      int64         // micro-batches dispatched to workers
+	AvgBatchSize float64       // mean logs per micro-batch
+	QueueDepth   int64         // logs received but not yet written
+	Uptime       time.Duration // time since the pipeline was created
 }
 
 // Option configures a Pipeline.
@@ -42,6 +67,29 @@ func WithDedup(d *dedup.Deduplicator, window time.Duration) Option {
 	}
 }
 
+// WithDedupWindows overrides the dedup window for some event types or
+// severities in stream mode, e.g. {"error": 0, "debug": 30 * time.Second}.
+// Events with a zero window are written as soon as they are processed;
+// the rest wait for their own window. Requires WithDedup; not used with
+// WithStatefulDedup.
+func WithDedupWindows(w dedup.Windows) Option {
+	return func(p *Pipeline) {
+		p.windows = w
+	}
+}
+
+// WithStatefulDedup makes stream mode deduplicate with t instead of
+// per-window batches: the first occurrence of a group is written
+// immediately, then rollup and resolved events as t reports them. t is
+// polled every tick. Takes precedence over WithDedup in stream mode; query
+// mode still uses WithDedup.
+func WithStatefulDedup(t *dedup.Tracker, tick time.Duration) Option {
+	return func(p *Pipeline) {
+		p.tracker = t
+		p.tick = tick
+	}
+}
+
 // WithMaxBufferSize sets the maximum number of events buffered before a force flush.
 // 0 means unlimited (default).
 func WithMaxBufferSize(n int) Option {
@@ -50,19 +98,79 @@ func WithMaxBufferSize(n int) Option {
 	}
 }
 
+// WithBatching makes stream mode classify logs in micro-batches of up to
+// size logs with ProcessBatch. A partial batch is dispatched once its oldest
+// log has waited maxLatency. A size <= 1 disables batching (default).
+func WithBatching(size int, maxLatency time.Duration) Option {
+	return func(p *Pipeline) {
+		p.batchSize = size
+		p.maxLatency = maxLatency
+	}
+}
+
+// WithWorkers sets how many micro-batches are classified concurrently in
+// stream mode. Events are still written in arrival order. Values > 1 only
+// help when the engine can run inferences in parallel (embedder sessions).
+// Default: 1.
+func WithWorkers(n int) Option {
+	return func(p *Pipeline) {
+		p.workers = n
+	}
+}
+
+// WithStatsInterval logs a "pipeline stats" line with throughput, batch size
+// and queue depth every interval while streaming. 0 disables (default).
+func WithStatsInterval(interval time.Duration) Option {
+	return func(p *Pipeline) {
+		p.statsInterval = interval
+	}
+}
+
 // New creates a Pipeline from the given components.
 func New(conn connector.Connector, eng Processor, out output.Output, opts ...Option) *Pipeline {
 	p := &Pipeline{
 		connector: conn,
 		engine:    eng,
 		output:    out,
+		workers:   1,
+		started:   time.Now(),
 	}
 	for _, opt := range opts {
 		opt(p)
 	}
+	if p.workers < 1 {
+		p.workers = 1
+	}
+	if p.maxLatency <= 0 {
+		p.maxLatency = defaultMaxLatency
+	}
+	if p.tick <= 0 {
+		p.tick = time.Second
+	}
 	return p
 }
 
+// defaultMaxLatency bounds how long a log waits for its micro-batch to fill
+// when WithBatching is given no latency.
+const defaultMaxLatency = 50 * time.Millisecond
+
+// Stats returns a snapshot of pipeline activity. Safe to call concurrently
+// with Stream and Query.
+func (p *Pipeline) Stats() Stats {
+	s := Stats{
+		Received:   p.receivedLogs.Load(),
+		Written:    p.writtenEvents.Load(),
+		Skipped:    p.skippedLogs.Load(),
+		Batches:    p.batches.Load(),
+		QueueDepth: p.queued.Load(),
+		Uptime:     time.Since(p.started),
+	}
+	if s.Batches > 0 {
+		s.AvgBatchSize = float64(p.batchedLogs.Load()) / float64(s.Batches)
+	}
+	return s
+}
+
 // Stream starts the pipeline in streaming mode, processing logs as they arrive.
 // Blocks until the context is cancelled or an error occurs.
 func (p *Pipeline) Stream(ctx context.Context, cfg connector.ConnectorConfig) error {
@@ -71,12 +179,43 @@ func (p *Pipeline) Stream(ctx context.Context, cfg connector.ConnectorConfig) er
 		return fmt.Errorf("pipeline stream: %w", err)
 	}
 
-	if p.dedup != nil {
+	if p.statsInterval > 0 {
+		done := make(chan struct{})
+		defer close(done)
+		go p.logStats(done)
+	}
+
+	if p.batchSize > 1 || p.workers > 1 {
+		return p.streamBatched(ctx, ch)
+	}
+	if p.dedup != nil || p.tracker != nil {
 		return p.streamWithDedup(ctx, ch)
 	}
 	return p.streamDirect(ctx, ch)
 }
 
+// logStats logs pipeline activity every statsInterval until done is closed.
+func (p *Pipeline) logStats(done <-chan struct{}) {
+	ticker := time.NewTicker(p.statsInterval)
+	defer ticker.Stop()
+	last := p.Stats()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s := p.Stats()
+			rate := float64(s.Written-last.Written) / (s.Uptime - last.Uptime).Seconds()
+			slog.Info("pipeline stats",
+				"events_per_sec", fmt.Sprintf("%.1f", rate),
+				"written", s.Written, "skipped", s.Skipped,
+				"avg_batch_size", fmt.Sprintf("%.1f", s.AvgBatchSize),
+				"queue_depth", s.QueueDepth)
+			last = s
+		}
+	}
+}
+
 // streamDirect writes events directly without dedup.
 func (p *Pipeline) streamDirect(ctx context.Context, ch <-chan model.RawLog) error {
 	for {
@@ -107,11 +246,9 @@ func (p *Pipeline) streamDirect(ctx context.Context, ch <-chan model.RawLog) err
 	}
 }
 
-// streamWithDedup buffers events and flushes deduplicated batches on a timer.
+// streamWithDedup buffers events and flushes deduplicated events on a timer.
 func (p *Pipeline) streamWithDedup(ctx context.Context, ch <-chan model.RawLog) error {
-	buf := newStreamBuffer(p.dedup, p.output, p.window, p.maxBufferSize, func() {
-		p.writtenEvents.Add(1)
-	})
+	buf := p.newBuffer()
 
 	for {
 		select {
@@ -121,7 +258,7 @@ func (p *Pipeline) streamWithDedup(ctx context.Context, ch <-chan model.RawLog)
 			}
 			// Use background context so writes can complete during drain.
 			// The shutdown timer in main.go provides the hard bound.
-			if err := buf.flush(context.Background()); err != nil {
+			if err := buf.drain(context.Background()); err != nil {
 				return fmt.Errorf("pipeline flush on shutdown: %w", err)
 			}
 			return ctx.Err()
@@ -132,7 +269,7 @@ func (p *Pipeline) streamWithDedup(ctx context.Context, ch <-chan model.RawLog)
 				}
 				// Channel closed — flush remaining. Use background context
 				// since the parent ctx may already be cancelled.
-				return buf.flush(context.Background())
+				return buf.drain(context.Background())
 			}
 			event, err := p.engine.Process(raw)
 			if err != nil {
@@ -142,12 +279,12 @@ func (p *Pipeline) streamWithDedup(ctx context.Context, ch <-chan model.RawLog)
 			}
 			if buf.add(event) {
 				// Buffer full — force early flush.
-				if err := buf.flush(ctx); err != nil {
+				if err := buf.flushDue(ctx); err != nil {
 					return fmt.Errorf("pipeline flush (buffer full): %w", err)
 				}
 			}
 		case <-buf.flushCh():
-			if err := buf.flush(ctx); err != nil {
+			if err := buf.flushDue(ctx); err != nil {
 				return fmt.Errorf("pipeline flush: %w", err)
 			}
 		}
@@ -161,11 +298,7 @@ func (p *Pipeline) Query(ctx context.Context, cfg connector.ConnectorConfig, par
 		return fmt.Errorf("pipeline query: %w", err)
 	}
 
-	events, err := p.engine.ProcessBatch(raws)
-	if err != nil {
-		slog.Warn("batch processing failed, falling back to individual", "error", err, "count", len(raws))
-		events = p.processIndividual(raws)
-	}
+	events := p.processBatch(raws)
 
 	if p.dedup != nil {
 		events = p.dedup.DeduplicateBatch(events)
@@ -187,7 +320,7 @@ func (p *Pipeline) processIndividual(raws []model.RawLog) []model.CanonicalEvent
 		event, err := p.engine.Process(raw)
 		if err != nil {
 			p.skippedLogs.Add(1)
-			slog.Warn("skipping log in query", "error", err, "source", raw.Source)
+			slog.Warn("skipping log", "error", err, "source", raw.Source)
 			continue
 		}
 		events = append(events, event)
@@ -197,10 +330,12 @@ func (p *Pipeline) processIndividual(raws []model.RawLog) []model.CanonicalEvent
 
 // Close shuts down the output.
 func (p *Pipeline) Close() error {
-	written := p.writtenEvents.Load()
-	skipped := p.skippedLogs.Load()
-	if written > 0 || skipped > 0 {
-		slog.Info("pipeline closing", "total_events_written", written, "total_skipped_logs", skipped)
+	s := p.Stats()
+	if s.Batches > 0 {
+		slog.Info("pipeline closing", "total_events_written", s.Written, "total_skipped_logs", s.Skipped,
+			"batches", s.Batches, "avg_batch_size", fmt.Sprintf("%.1f", s.AvgBatchSize))
+	} else if s.Written > 0 || s.Skipped > 0 {
+		slog.Info("pipeline closing", "total_events_written", s.Written, "total_skipped_logs", s.Skipped)
 	}
 	return p.output.Close()
 }
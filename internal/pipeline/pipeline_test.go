@@ -442,3 +442,101 @@ func TestStreamBuffer_UnlimitedBackcompat(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamWithStatefulDedup(t *testing.T) {
+	conn := &chanConnector{ch: make(chan model.RawLog)}
+	out := &mockOutput{}
+	tr := dedup.NewTracker(dedup.New(dedup.Config{Rollup: 30 * time.Millisecond, ResolveAfter: time.Minute}))
+	p := New(conn, &categoryProcessor{}, out, WithStatefulDedup(tr, 10*time.Millisecond))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- p.Stream(context.Background(), connector.ConnectorConfig{}) }()
+
+	waitFor := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for len(out.Events()) < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %d events, got %+v", n, out.Events())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// The first occurrence is written without waiting for a window.
+	conn.ch <- model.RawLog{Timestamp: time.Now(), Raw: "storm"}
+	waitFor(1)
+
+	// Repeats across many ticks produce rollups, not new events.
+	for i := 0; i < 4; i++ {
+		conn.ch <- model.RawLog{Timestamp: time.Now(), Raw: "storm"}
+	}
+	waitFor(2)
+	close(conn.ch)
+	if err := <-errCh; err != nil {
+		t.Fatalf("Stream() error: %v", err)
+	}
+
+	events := out.Events()
+	first, last := events[0].Rollup, events[len(events)-1].Rollup
+	if first == nil || first.State != model.RollupFirst {
+		t.Fatalf("events[0].Rollup = %+v, want first", first)
+	}
+	if last == nil || last.State != model.RollupResolved || last.Count != 5 || last.GroupID != first.GroupID {
+		t.Errorf("last Rollup = %+v, want resolved x5 in the same group", last)
+	}
+	total := 0
+	for _, e := range events[:len(events)-1] {
+		total += e.Rollup.New
+	}
+	if total+last.New != 5 {
+		t.Errorf("rollups reported %d occurrences, want 5", total+last.New)
+	}
+	if p.writtenEvents.Load() != int64(len(events)) {
+		t.Errorf("writtenEvents = %d, want %d", p.writtenEvents.Load(), len(events))
+	}
+}
+
+func TestStreamBuffer_PriorityLane(t *testing.T) {
+	out := &mockOutput{}
+	d := dedup.New(dedup.Config{Window: 10 * time.Second})
+	buf := newStreamBuffer(d, out, 10*time.Second, 0, nil)
+	buf.windows = dedup.Windows{"error": 0, "debug": 50 * time.Millisecond}
+
+	t0 := time.Now()
+	if buf.add(model.CanonicalEvent{Type: "REQUEST", Category: "success", Severity: "info", Timestamp: t0, Summary: "ok"}) {
+		t.Fatal("info event should wait for its window")
+	}
+	if buf.add(model.CanonicalEvent{Type: "DEBUG", Category: "trace", Severity: "debug", Timestamp: t0, Summary: "trace"}) {
+		t.Fatal("debug event should wait for its window")
+	}
+	if !buf.add(model.CanonicalEvent{Type: "ERROR", Category: "runtime_error", Severity: "error", Timestamp: t0, Summary: "panic"}) {
+		t.Fatal("error event should be due immediately")
+	}
+	if err := buf.flushDue(context.Background()); err != nil {
+		t.Fatalf("flushDue error: %v", err)
+	}
+	if events := out.Events(); len(events) != 1 || events[0].Summary != "panic" {
+		t.Fatalf("expected only the error to be written, got %+v", events)
+	}
+
+	// The debug lane's shorter window fires before the default one.
+	select {
+	case <-buf.flushCh():
+	case <-time.After(time.Second):
+		t.Fatal("debug lane timer didn't fire")
+	}
+	if err := buf.flushDue(context.Background()); err != nil {
+		t.Fatalf("flushDue error: %v", err)
+	}
+	if events := out.Events(); len(events) != 2 || events[1].Summary != "trace" {
+		t.Fatalf("expected the debug event next, got %+v", events)
+	}
+
+	if err := buf.drain(context.Background()); err != nil {
+		t.Fatalf("drain error: %v", err)
+	}
+	if events := out.Events(); len(events) != 3 || events[2].Summary != "ok" {
+		t.Fatalf("expected the info event on drain, got %+v", events)
+	}
+}
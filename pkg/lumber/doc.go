@@ -1,5 +1,7 @@
 // Package lumber provides a log classification engine that embeds log text
-// into vectors and classifies against a 42-label taxonomy.
+// into vectors and classifies against a taxonomy: the built-in 42 labels,
+// or one loaded from a file (WithTaxonomyFile), replaced (WithTaxonomy) or
+// extended (WithTaxonomyExtension).
 //
 // Quick start (auto-download, recommended for getting started):
 //
@@ -20,5 +22,6 @@
 //	l, err := lumber.New(lumber.WithModelDir("/opt/lumber/models"))
 //
 // The Lumber instance is safe for concurrent use. Create once, reuse across
-// requests. See the README for full documentation.
+// requests; WithSessions lets concurrent callers run inference in parallel.
+// See the README for full documentation.
 package lumber
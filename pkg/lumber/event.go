@@ -6,12 +6,70 @@ import "time"
 // This is the stable public type — internal representations may evolve
 // independently without breaking consumers.
 type Event struct {
-	Type       string    `json:"type"`               // Root category: ERROR, REQUEST, DEPLOY, etc.
-	Category   string    `json:"category"`            // Leaf label: connection_failure, success, etc.
-	Severity   string    `json:"severity"`            // error, warning, info, debug
-	Timestamp  time.Time `json:"timestamp"`           // When the log was produced
-	Summary    string    `json:"summary"`             // First line, <=120 runes
-	Confidence float64   `json:"confidence,omitempty"` // Cosine similarity score
-	Raw        string    `json:"raw,omitempty"`        // Compacted original text
-	Count      int       `json:"count,omitempty"`      // >0 when deduplicated
+	Type           string         `json:"type"`                      // Root category: ERROR, REQUEST, DEPLOY, etc.
+	Category       string         `json:"category"`                  // Leaf label: connection_failure, success, etc.
+	Severity       string         `json:"severity"`                  // error, warning, info, debug
+	SourceSeverity string         `json:"source_severity,omitempty"` // Level declared by the log itself, normalized
+	Timestamp      time.Time      `json:"timestamp"`                 // When the log was produced
+	Source         string         `json:"source,omitempty"`          // Provider/origin name from Log.Source
+	TemplateID     string         `json:"template_id,omitempty"`     // Stable id of the message template (WithTemplates)
+	Template       string         `json:"template,omitempty"`        // Message template, variable tokens as <*>
+	Summary        string         `json:"summary"`                   // First line, <=120 runes
+	Confidence     float64        `json:"confidence,omitempty"`      // Cosine similarity score
+	Method         string         `json:"method,omitempty"`          // "rule" for rule hits; empty = embedding
+	Alternatives   []Alternative  `json:"alternatives,omitempty"`    // Runner-up labels (WithTopK)
+	Margin         float64        `json:"margin,omitempty"`          // Best minus runner-up score (WithTopK)
+	Ambiguous      bool           `json:"ambiguous,omitempty"`       // Margin below WithAmbiguityMargin
+	Attributes     map[string]any `json:"attributes,omitempty"`      // Log.Metadata, filtered by WithAttributes
+	Fields         map[string]any `json:"fields,omitempty"`          // Extracted from the text: status, duration_ms, route, ...
+	Raw            string         `json:"raw,omitempty"`             // Compacted original text
+	Count          int            `json:"count,omitempty"`           // >0 when deduplicated
+	Stack          *Stack         `json:"stack,omitempty"`           // Parsed stack trace of ERROR events (WithStackTraces)
+}
+
+// Stack is a stack trace parsed from an ERROR event (see WithStackTraces).
+type Stack struct {
+	Language  string  `json:"language"`            // java, go, python, node, rust, dotnet or ruby
+	Exception string  `json:"exception,omitempty"` // Exception or panic type
+	Message   string  `json:"message,omitempty"`   // Exception message
+	Frames    []Frame `json:"frames"`              // Kept frames, innermost first (outermost first for Python)
+	Omitted   int     `json:"omitted,omitempty"`   // Frames dropped by compaction
+}
+
+// Frame is one stack frame.
+type Frame struct {
+	Function string `json:"function,omitempty"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	App      bool   `json:"app"` // False for standard library, runtime and dependency frames
+}
+
+// Alternative is a runner-up label considered during classification.
+type Alternative struct {
+	Path       string  `json:"path"`       // Full label path, e.g. "ERROR.timeout"
+	Confidence float64 `json:"confidence"` // Cosine similarity score
+}
+
+// CacheStats reports template cache activity (see WithCacheSize).
+type CacheStats struct {
+	Hits     uint64 `json:"hits"`     // Lookups served from the cache
+	Misses   uint64 `json:"misses"`   // Lookups that ran the model
+	Entries  int    `json:"entries"`  // Templates currently cached
+	Capacity int    `json:"capacity"` // Maximum templates cached
+}
+
+// TokenStats reports token counts before and after compaction (see
+// WithTokenCounter).
+type TokenStats struct {
+	Raw       int64   `json:"raw"`       // Tokens of the logs as received
+	Compacted int64   `json:"compacted"` // Tokens of the raw text kept on events
+	Reduction float64 `json:"reduction"` // Share of tokens removed, 0-1
+}
+
+// Template is a mined message template (see WithTemplates).
+type Template struct {
+	ID       string `json:"id"`       // Stable id, as in Event.TemplateID
+	Template string `json:"template"` // Pattern with variable tokens as <*>
+	Count    int    `json:"count"`    // Logs matched so far
+	Example  string `json:"example"`  // First log that started the template
 }
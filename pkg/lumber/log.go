@@ -9,5 +9,5 @@ type Log struct {
 	Text      string         // The log text to classify
 	Timestamp time.Time      // When the log was produced (zero = time.Now())
 	Source    string         // Provider/origin name (optional)
-	Metadata  map[string]any // Additional context (optional, not used in classification)
+	Metadata  map[string]any // Additional context, returned as Event.Attributes (optional, not used in classification)
 }
@@ -6,16 +6,21 @@ import (
 	"time"
 
 	"github.com/kaminocorp/lumber/internal/engine"
+	"github.com/kaminocorp/lumber/internal/engine/attributes"
 	"github.com/kaminocorp/lumber/internal/engine/classifier"
 	"github.com/kaminocorp/lumber/internal/engine/compactor"
+	"github.com/kaminocorp/lumber/internal/engine/drain"
 	"github.com/kaminocorp/lumber/internal/engine/embedder"
+	"github.com/kaminocorp/lumber/internal/engine/redact"
+	"github.com/kaminocorp/lumber/internal/engine/severity"
 	"github.com/kaminocorp/lumber/internal/engine/taxonomy"
 	"github.com/kaminocorp/lumber/internal/model"
 )
 
 // Lumber is a log classification engine.
-// It embeds log text into vectors and classifies against a 42-label taxonomy.
-// Safe for concurrent use.
+// It embeds log text into vectors and classifies against its taxonomy, the
+// built-in one unless an option replaces or extends it.
+// Safe for concurrent use; see WithSessions for parallel inference.
 type Lumber struct {
 	engine   *engine.Engine
 	embedder embedder.Embedder
@@ -31,6 +36,34 @@ func New(opts ...Option) (*Lumber, error) {
 		opt(&o)
 	}
 
+	if o.sessions < 1 {
+		return nil, fmt.Errorf("lumber: sessions must be at least 1, got %d", o.sessions)
+	}
+	if o.intraOpThreads < 0 || o.interOpThreads < 0 {
+		return nil, fmt.Errorf("lumber: thread counts must be non-negative")
+	}
+
+	switch o.scoring {
+	case "knn", "centroid":
+	default:
+		return nil, fmt.Errorf("lumber: invalid scoring %q (must be knn or centroid)", o.scoring)
+	}
+
+	switch o.severityPolicy {
+	case "taxonomy", "source", "max":
+	default:
+		return nil, fmt.Errorf("lumber: invalid severity policy %q (must be taxonomy, source or max)", o.severityPolicy)
+	}
+
+	switch o.tokenCounter {
+	case "heuristic", "wordpiece", compactor.CL100K, compactor.O200K:
+	default:
+		return nil, fmt.Errorf("lumber: invalid token counter %q (must be heuristic, wordpiece, cl100k or o200k)", o.tokenCounter)
+	}
+	if o.tokenBudget < 0 || o.batchTokenBudget < 0 {
+		return nil, fmt.Errorf("lumber: token budgets must be non-negative")
+	}
+
 	// Auto-download models + ORT if requested and no explicit paths provided.
 	if o.autoDownload && o.modelDir == "" && o.modelPath == "" {
 		cacheDir := o.cacheDir
@@ -53,22 +86,103 @@ func New(opts ...Option) (*Lumber, error) {
 		o.modelDir = cacheDir
 	}
 
+	// Resolve the taxonomy, rules, calibration, redaction and token counter
+	// before loading the model so invalid files fail fast without paying for
+	// ONNX initialization.
+	roots, err := resolveTaxonomy(o)
+	if err != nil {
+		return nil, fmt.Errorf("lumber: %w", err)
+	}
+
+	ruleSet, err := resolveRules(o)
+	if err != nil {
+		return nil, fmt.Errorf("lumber: %w", err)
+	}
+
+	var cal *classifier.Calibration
+	if o.calibrationFile != "" {
+		c, err := classifier.LoadCalibration(o.calibrationFile)
+		if err != nil {
+			return nil, fmt.Errorf("lumber: %w", err)
+		}
+		cal = &c
+	}
+
+	var policy *compactor.Policy
+	if o.compactionPolicy != "" {
+		if policy, err = compactor.LoadPolicy(o.compactionPolicy); err != nil {
+			return nil, fmt.Errorf("lumber: %w", err)
+		}
+	}
+
+	var red *redact.Redactor
+	if o.redaction != nil {
+		if red, err = redact.New(*o.redaction); err != nil {
+			return nil, fmt.Errorf("lumber: %w", err)
+		}
+	}
+
 	modelPath, vocabPath, projPath := resolvePaths(o)
 
-	emb, err := embedder.New(modelPath, vocabPath, projPath)
+	var counter compactor.TokenCounter = compactor.Heuristic{}
+	switch o.tokenCounter {
+	case "wordpiece":
+		if counter, err = embedder.NewWordPiece(vocabPath); err != nil {
+			return nil, fmt.Errorf("lumber: token counter: %w", err)
+		}
+	case compactor.CL100K, compactor.O200K:
+		if counter, err = compactor.LoadBPE(o.tokenVocab, o.tokenCounter); err != nil {
+			return nil, fmt.Errorf("lumber: token counter: %w", err)
+		}
+	}
+
+	emb, err := embedder.New(modelPath, vocabPath, projPath,
+		embedder.WithSessions(o.sessions),
+		embedder.WithThreads(o.intraOpThreads, o.interOpThreads))
 	if err != nil {
 		return nil, fmt.Errorf("lumber: %w", err)
 	}
 
-	tax, err := taxonomy.New(taxonomy.DefaultRoots(), emb)
+	tax, err := taxonomy.New(roots, emb)
 	if err != nil {
 		emb.Close()
 		return nil, fmt.Errorf("lumber: %w", err)
 	}
 
 	cls := classifier.New(o.confidenceThreshold)
-	cmp := compactor.New(parseVerbosity(o.verbosity))
-	eng := engine.New(emb, tax, cls, cmp)
+	cls.Scoring = classifier.Scoring(o.scoring)
+	cls.TopK = o.topK
+	cls.AmbiguityMargin = o.ambiguityMargin
+	if cal != nil {
+		cls.Calibrate(*cal)
+	}
+	cmp := compactor.New(parseVerbosity(o.verbosity), compactor.WithPolicy(policy),
+		compactor.WithTokenCounter(counter), compactor.WithTokenBudget(o.tokenBudget, o.batchTokenBudget))
+	engOpts := []engine.Option{engine.WithSeverityPolicy(severity.Policy(o.severityPolicy))}
+	if ruleSet != nil {
+		if err := ruleSet.CheckPaths(tax.Labels()); err != nil {
+			emb.Close()
+			return nil, fmt.Errorf("lumber: %w", err)
+		}
+		engOpts = append(engOpts, engine.WithRules(ruleSet))
+	}
+	if len(o.attributes) > 0 || len(o.dropAttributes) > 0 {
+		engOpts = append(engOpts, engine.WithAttributes(attributes.New(
+			o.attributes, o.dropAttributes, cmp.Verbosity == compactor.Full)))
+	}
+	if o.templates {
+		engOpts = append(engOpts, engine.WithTemplates(drain.New(drain.Config{})))
+	}
+	if o.cacheSize > 0 {
+		engOpts = append(engOpts, engine.WithCache(o.cacheSize))
+	}
+	if o.stackTraces {
+		engOpts = append(engOpts, engine.WithStackTraces())
+	}
+	if red != nil {
+		engOpts = append(engOpts, engine.WithRedaction(red))
+	}
+	eng := engine.New(emb, tax, cls, cmp, engOpts...)
 
 	return &Lumber{engine: eng, embedder: emb, taxonomy: tax}, nil
 }
@@ -152,6 +266,40 @@ func (l *Lumber) ClassifyLogs(logs []Log) ([]Event, error) {
 	return events, nil
 }
 
+// CacheStats reports template cache activity (see WithCacheSize).
+// All zero when the cache is disabled.
+func (l *Lumber) CacheStats() CacheStats {
+	s := l.engine.CacheStats()
+	return CacheStats{Hits: s.Hits, Misses: s.Misses, Entries: s.Entries, Capacity: s.Capacity}
+}
+
+// TokenStats reports the tokens of the logs classified so far and of the
+// raw text kept on their events, as counted by WithTokenCounter.
+func (l *Lumber) TokenStats() TokenStats {
+	s := l.engine.TokenStats()
+	return TokenStats{Raw: s.Raw, Compacted: s.Compacted, Reduction: s.Reduction()}
+}
+
+// RedactionCounts reports the values redacted so far per detector (see
+// WithRedaction). Nil when redaction is disabled.
+func (l *Lumber) RedactionCounts() map[string]int64 {
+	return l.engine.RedactionCounts()
+}
+
+// Templates returns the message templates mined so far, most frequent
+// first. Nil unless WithTemplates is set.
+func (l *Lumber) Templates() []Template {
+	clusters := l.engine.Templates()
+	if clusters == nil {
+		return nil
+	}
+	out := make([]Template, len(clusters))
+	for i, c := range clusters {
+		out[i] = Template{ID: c.ID, Template: c.Template, Count: c.Count, Example: c.Example}
+	}
+	return out
+}
+
 // Close releases model resources (ONNX runtime, memory).
 // Must be called when the Lumber instance is no longer needed.
 func (l *Lumber) Close() error {
@@ -160,16 +308,44 @@ func (l *Lumber) Close() error {
 
 // eventFromCanonical converts the internal CanonicalEvent to the public Event type.
 func eventFromCanonical(ce model.CanonicalEvent) Event {
-	return Event{
-		Type:       ce.Type,
-		Category:   ce.Category,
-		Severity:   ce.Severity,
-		Timestamp:  ce.Timestamp,
-		Summary:    ce.Summary,
-		Confidence: ce.Confidence,
-		Raw:        ce.Raw,
-		Count:      ce.Count,
+	ev := Event{
+		Type:           ce.Type,
+		Category:       ce.Category,
+		Severity:       ce.Severity,
+		SourceSeverity: ce.SourceSeverity,
+		Timestamp:      ce.Timestamp,
+		Source:         ce.Source,
+		TemplateID:     ce.TemplateID,
+		Template:       ce.Template,
+		Summary:        ce.Summary,
+		Confidence:     ce.Confidence,
+		Method:         ce.Method,
+		Margin:         ce.Margin,
+		Ambiguous:      ce.Ambiguous,
+		Attributes:     ce.Attributes,
+		Fields:         ce.Fields,
+		Raw:            ce.Raw,
+		Count:          ce.Count,
+	}
+	if ce.Stack != nil {
+		ev.Stack = &Stack{
+			Language:  ce.Stack.Language,
+			Exception: ce.Stack.Exception,
+			Message:   ce.Stack.Message,
+			Omitted:   ce.Stack.Omitted,
+			Frames:    make([]Frame, len(ce.Stack.Frames)),
+		}
+		for i, f := range ce.Stack.Frames {
+			ev.Stack.Frames[i] = Frame{Function: f.Function, File: f.File, Line: f.Line, App: f.App}
+		}
+	}
+	if len(ce.Alternatives) > 0 {
+		ev.Alternatives = make([]Alternative, len(ce.Alternatives))
+		for i, a := range ce.Alternatives {
+			ev.Alternatives[i] = Alternative{Path: a.Path, Confidence: a.Confidence}
+		}
 	}
+	return ev
 }
 
 // parseVerbosity maps a string to the internal Verbosity enum.
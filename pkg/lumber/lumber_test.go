@@ -2,9 +2,12 @@ package lumber
 
 import (
 	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/kaminocorp/lumber/internal/model"
 )
 
 const testModelDir = "../../models"
@@ -33,6 +36,50 @@ func TestNewBadPathReturnsError(t *testing.T) {
 	}
 }
 
+func TestNewBadOptionsFailBeforeModelLoad(t *testing.T) {
+	// Both errors must surface even though the model directory is missing.
+	_, err := New(WithModelDir("/nonexistent/path"), WithCalibrationFile("/nonexistent/calibration.json"))
+	if err == nil || !strings.Contains(err.Error(), "calibration") {
+		t.Fatalf("expected calibration error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithSessions(0))
+	if err == nil || !strings.Contains(err.Error(), "sessions") {
+		t.Fatalf("expected sessions error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithScoring("mean"))
+	if err == nil || !strings.Contains(err.Error(), "invalid scoring") {
+		t.Fatalf("expected scoring error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithCompactionPolicyFile("/nonexistent/policy.json"))
+	if err == nil || !strings.Contains(err.Error(), "compaction policy") {
+		t.Fatalf("expected compaction policy error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithRedaction("email", "ssn"))
+	if err == nil || !strings.Contains(err.Error(), "redaction detector") {
+		t.Fatalf("expected redaction error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithRedactionPattern("bad", "("))
+	if err == nil || !strings.Contains(err.Error(), "redaction pattern") {
+		t.Fatalf("expected redaction pattern error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithSeverityPolicy("highest"))
+	if err == nil || !strings.Contains(err.Error(), "severity policy") {
+		t.Fatalf("expected severity policy error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithTokenCounter("p50k", ""))
+	if err == nil || !strings.Contains(err.Error(), "invalid token counter") {
+		t.Fatalf("expected token counter error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithTokenCounter("cl100k", "/nonexistent/cl100k_base.tiktoken"))
+	if err == nil || !strings.Contains(err.Error(), "token counter") {
+		t.Fatalf("expected token vocabulary error, got: %v", err)
+	}
+	_, err = New(WithModelDir("/nonexistent/path"), WithTokenBudget(-1, 0))
+	if err == nil || !strings.Contains(err.Error(), "token budgets") {
+		t.Fatalf("expected token budget error, got: %v", err)
+	}
+}
+
 func TestClassifyKnownLogLine(t *testing.T) {
 	skipWithoutModel(t)
 
@@ -178,6 +225,15 @@ func TestOptionsDefaults(t *testing.T) {
 	if o.verbosity != "standard" {
 		t.Errorf("default verbosity = %q, want standard", o.verbosity)
 	}
+	if o.topK != 0 {
+		t.Errorf("default top-k = %d, want 0 (disabled)", o.topK)
+	}
+	if o.sessions != 1 {
+		t.Errorf("default sessions = %d, want 1", o.sessions)
+	}
+	if o.cacheSize != 0 {
+		t.Errorf("default cache size = %d, want 0 (disabled)", o.cacheSize)
+	}
 }
 
 func TestResolvePathsExplicit(t *testing.T) {
@@ -291,3 +347,130 @@ func TestClassifyLogs(t *testing.T) {
 		t.Errorf("events[0].Type = %q, want ERROR", events[0].Type)
 	}
 }
+
+func TestCacheSizeReusesTemplates(t *testing.T) {
+	skipWithoutModel(t)
+
+	l, err := New(WithModelDir(testModelDir), WithCacheSize(100))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer l.Close()
+
+	a, err := l.Classify("connection to 10.0.0.1:5432 refused after 3012ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := l.Classify("connection to 10.0.0.9:5432 refused after 12ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Type != b.Type || a.Category != b.Category {
+		t.Errorf("same template classified differently: %s.%s vs %s.%s", a.Type, a.Category, b.Type, b.Category)
+	}
+	if s := l.CacheStats(); s.Hits != 1 || s.Misses != 1 || s.Capacity != 100 {
+		t.Errorf("unexpected cache stats: %+v", s)
+	}
+}
+
+func TestClassifyLogAttributes(t *testing.T) {
+	skipWithoutModel(t)
+
+	l, err := New(WithModelDir(testModelDir), WithoutAttributes("user_id"))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer l.Close()
+
+	event, err := l.ClassifyLog(Log{
+		Text:     "ERROR: connection refused to db-primary:5432",
+		Source:   "checkout",
+		Metadata: map[string]any{"region": "iad", "user_id": "u_123", "empty": ""},
+	})
+	if err != nil {
+		t.Fatalf("ClassifyLog() error: %v", err)
+	}
+
+	if event.Source != "checkout" {
+		t.Errorf("Source = %q, want checkout", event.Source)
+	}
+	if len(event.Attributes) != 1 || event.Attributes["region"] != "iad" {
+		t.Errorf("Attributes = %v, want only region", event.Attributes)
+	}
+}
+
+func TestEventFromCanonicalAttributes(t *testing.T) {
+	ev := eventFromCanonical(model.CanonicalEvent{
+		Source:         "vercel",
+		SourceSeverity: "error",
+		Attributes:     map[string]any{"status": 502},
+		Fields:         map[string]any{"route": "/api"},
+	})
+	if ev.Source != "vercel" || ev.SourceSeverity != "error" || ev.Attributes["status"] != 502 || ev.Fields["route"] != "/api" {
+		t.Errorf("source/attributes/fields not carried over: %+v", ev)
+	}
+}
+
+func TestEventFromCanonicalStack(t *testing.T) {
+	ev := eventFromCanonical(model.CanonicalEvent{Stack: &model.Stack{
+		Language:  "python",
+		Exception: "KeyError",
+		Frames:    []model.Frame{{Function: "handle", File: "/app/api.py", Line: 3, App: true}},
+		Omitted:   4,
+	}})
+	if ev.Stack == nil || ev.Stack.Exception != "KeyError" || ev.Stack.Omitted != 4 || len(ev.Stack.Frames) != 1 || !ev.Stack.Frames[0].App {
+		t.Errorf("stack not carried over: %+v", ev.Stack)
+	}
+	if eventFromCanonical(model.CanonicalEvent{}).Stack != nil {
+		t.Error("expected nil stack")
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	skipWithoutModel(t)
+
+	l, err := New(WithModelDir(testModelDir), WithTemplates())
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer l.Close()
+
+	events, err := l.ClassifyBatch([]string{
+		"connection to 10.0.0.1:5432 refused after 3012ms",
+		"connection to 10.0.0.2:5432 refused after 12ms",
+	})
+	if err != nil {
+		t.Fatalf("ClassifyBatch() error: %v", err)
+	}
+	if events[0].TemplateID == "" || events[0].TemplateID != events[1].TemplateID {
+		t.Errorf("template ids = %q, %q, want the same non-empty id", events[0].TemplateID, events[1].TemplateID)
+	}
+	top := l.Templates()
+	if len(top) != 1 || top[0].Count != 2 || top[0].Template != "connection to <*> refused after <*>ms" {
+		t.Errorf("Templates() = %+v", top)
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	skipWithoutModel(t)
+
+	l, err := New(WithModelDir(testModelDir), WithRedaction(), WithRedactionPattern("customer_id", `cus_[A-Za-z0-9]{8}`))
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	defer l.Close()
+
+	event, err := l.Classify("payment failed for jane@example.com (cus_AbCd1234) card 4111 1111 1111 1111")
+	if err != nil {
+		t.Fatalf("Classify() error: %v", err)
+	}
+	for _, secret := range []string{"jane@example.com", "cus_AbCd1234", "4111 1111 1111 1111"} {
+		if strings.Contains(event.Raw, secret) || strings.Contains(event.Summary, secret) {
+			t.Errorf("%q leaked into event: %+v", secret, event)
+		}
+	}
+	counts := l.RedactionCounts()
+	if counts["email"] != 1 || counts["customer_id"] != 1 || counts["credit_card"] != 1 {
+		t.Errorf("RedactionCounts() = %v", counts)
+	}
+}
@@ -1,6 +1,10 @@
 package lumber
 
-import "path/filepath"
+import (
+	"path/filepath"
+
+	"github.com/kaminocorp/lumber/internal/engine/redact"
+)
 
 type options struct {
 	modelDir            string
@@ -8,9 +12,34 @@ type options struct {
 	vocabPath           string
 	projectionPath      string
 	confidenceThreshold float64
+	calibrationFile     string
+	compactionPolicy    string
+	rulesFile           string
+	rules               []Rule
+	scoring             string
+	severityPolicy      string
+	corpusExamples      bool
+	topK                int
+	ambiguityMargin     float64
+	cacheSize           int
+	templates           bool
+	stackTraces         bool
+	sessions            int
+	intraOpThreads      int
+	interOpThreads      int
 	verbosity           string
+	attributes          []string
+	dropAttributes      []string
 	autoDownload        bool
 	cacheDir            string
+	taxonomyFile        string
+	taxonomy            []Category
+	extendTaxonomy      bool
+	redaction           *redact.Config
+	tokenCounter        string
+	tokenVocab          string
+	tokenBudget         int
+	batchTokenBudget    int
 }
 
 // Option configures a Lumber instance.
@@ -42,6 +71,204 @@ func WithConfidenceThreshold(t float64) Option {
 	}
 }
 
+// WithRulesFile loads pre-classification rules from a JSON or YAML file.
+// Rules are evaluated in order before embedding; the first match classifies
+// the log without running the model.
+func WithRulesFile(path string) Option {
+	return func(o *options) {
+		o.rulesFile = path
+	}
+}
+
+// WithRules adds pre-classification rules, evaluated after any rules from
+// WithRulesFile. Every rule's Path must be a label in the taxonomy.
+func WithRules(rules []Rule) Option {
+	return func(o *options) {
+		o.rules = rules
+	}
+}
+
+// WithCalibrationFile applies a calibration file written by
+// "lumber calibrate": a softmax temperature, which makes Confidence a
+// probability across labels, and per-label thresholds fitted to a target
+// precision.
+func WithCalibrationFile(path string) Option {
+	return func(o *options) {
+		o.calibrationFile = path
+	}
+}
+
+// WithScoring selects how labels with examples are scored: "knn" (the most
+// similar of the description and examples) or "centroid" (their mean).
+// Default: "knn".
+func WithScoring(mode string) Option {
+	return func(o *options) {
+		o.scoring = mode
+	}
+}
+
+// WithCorpusExamples adds Lumber's built-in labeled corpus as examples on
+// the matching taxonomy labels. Labels not in the corpus are unaffected.
+func WithCorpusExamples() Option {
+	return func(o *options) {
+		o.corpusExamples = true
+	}
+}
+
+// WithTopK reports the k best-scoring labels per event: Event.Alternatives
+// holds the runners-up and Event.Margin the gap between the top two scores.
+// Default: 0 (disabled). Values <= 1 disable alternatives.
+func WithTopK(k int) Option {
+	return func(o *options) {
+		o.topK = k
+	}
+}
+
+// WithAmbiguityMargin sets the margin below which a classified event is
+// flagged Ambiguous. Only takes effect with WithTopK(k) where k > 1.
+// Default: 0.05.
+func WithAmbiguityMargin(m float64) Option {
+	return func(o *options) {
+		o.ambiguityMargin = m
+	}
+}
+
+// WithCacheSize caches classification results for up to n log templates.
+// Lines that differ only in numbers, UUIDs, hex ids, IPs or timestamps reuse
+// the result of the first such line instead of running the model again.
+// Default: 0 (disabled).
+func WithCacheSize(n int) Option {
+	return func(o *options) {
+		o.cacheSize = n
+	}
+}
+
+// WithTemplates mines message templates from classified logs and sets
+// Event.TemplateID and Event.Template, e.g. "connection to <*> refused
+// after <*>ms". Use Templates() for the most frequent patterns.
+// Default: disabled.
+func WithTemplates() Option {
+	return func(o *options) {
+		o.templates = true
+	}
+}
+
+// WithSessions sets the number of ONNX inference sessions. Each session
+// holds its own copy of the model (~25MB), and up to n Classify or
+// ClassifyBatch calls run inference in parallel; further callers wait for
+// a free session. Default: 1.
+func WithSessions(n int) Option {
+	return func(o *options) {
+		o.sessions = n
+	}
+}
+
+// WithThreads sets the ONNX Runtime threads per session: intraOp threads
+// parallelize a single operator, interOp threads run independent operators
+// concurrently. Zero keeps the default, which divides the CPUs across
+// sessions (at most 4 intra-op threads each) with 1 inter-op thread.
+func WithThreads(intraOp, interOp int) Option {
+	return func(o *options) {
+		o.intraOpThreads = intraOp
+		o.interOpThreads = interOp
+	}
+}
+
+// WithSeverityPolicy sets how Event.Severity is derived when the log
+// declares its own level (a JSON "level", an ERROR prefix, or
+// Log.Metadata["level"]): "taxonomy" keeps the label's severity, "source"
+// uses the declared level, "max" uses the more severe of the two. The
+// declared level is always reported in Event.SourceSeverity.
+// Default: "taxonomy".
+func WithSeverityPolicy(policy string) Option {
+	return func(o *options) {
+		o.severityPolicy = policy
+	}
+}
+
+// WithStackTraces parses stack traces in ERROR events into Event.Stack:
+// language, exception type and message, and frames (function, file, line)
+// flagged as app-owned or library. Java, Go, Python, Node.js, Rust, .NET
+// and Ruby traces are recognized.
+func WithStackTraces() Option {
+	return func(o *options) {
+		o.stackTraces = true
+	}
+}
+
+// WithCompactionPolicyFile applies a compaction policy file (.json or
+// .yaml): rules by type, category or severity that choose the verbosity,
+// raw text truncation and stack frame limit, e.g. full stack traces for
+// ERROR and summary only for REQUEST.success. Events no rule matches use
+// WithVerbosity.
+func WithCompactionPolicyFile(path string) Option {
+	return func(o *options) {
+		o.compactionPolicy = path
+	}
+}
+
+// WithRedaction redacts PII and secrets before logs are embedded, so they
+// never reach events: email, ip, jwt, bearer, api_key, credit_card
+// (Luhn-checked) and password. With no arguments every detector runs.
+func WithRedaction(detectors ...string) Option {
+	return func(o *options) {
+		if len(detectors) == 0 {
+			detectors = []string{"all"}
+		}
+		r := o.redactionConfig()
+		r.Detectors = append(r.Detectors, detectors...)
+	}
+}
+
+// WithRedactionMode sets how redacted values are replaced: "mask"
+// ([REDACTED:email]), "hash" ([email:<hash>], equal values keep equal
+// tokens) or "drop". Default: "mask".
+func WithRedactionMode(mode string) Option {
+	return func(o *options) {
+		o.redactionConfig().Mode = redact.Mode(mode)
+	}
+}
+
+// WithRedactionPattern adds a custom redaction detector: matches of regex
+// are redacted and counted under name. When regex has a capture group only
+// the group is replaced, e.g. `ssn=(\d{3}-\d{2}-\d{4})`.
+func WithRedactionPattern(name, regex string) Option {
+	return func(o *options) {
+		r := o.redactionConfig()
+		r.Patterns = append(r.Patterns, redact.Pattern{Name: name, Regex: regex})
+	}
+}
+
+func (o *options) redactionConfig() *redact.Config {
+	if o.redaction == nil {
+		o.redaction = &redact.Config{}
+	}
+	return o.redaction
+}
+
+// WithTokenCounter sets how tokens are counted for token budgets and
+// TokenStats: "heuristic" (default, a whitespace estimate), "wordpiece"
+// (the embedding model's vocabulary), or "cl100k"/"o200k" (OpenAI BPE,
+// loaded from the tiktoken file at vocabPath). vocabPath is ignored for the
+// other counters.
+func WithTokenCounter(kind, vocabPath string) Option {
+	return func(o *options) {
+		o.tokenCounter = kind
+		o.tokenVocab = vocabPath
+	}
+}
+
+// WithTokenBudget compacts raw text to token budgets instead of fixed rune
+// limits: at most perEvent tokens per event (below "full" verbosity) and
+// perBatch tokens across the events of one ClassifyBatch/ClassifyLogs call.
+// Zero disables either budget.
+func WithTokenBudget(perEvent, perBatch int) Option {
+	return func(o *options) {
+		o.tokenBudget = perEvent
+		o.batchTokenBudget = perBatch
+	}
+}
+
 // WithVerbosity sets the compaction verbosity: "minimal", "standard", "full".
 // Default: "standard".
 func WithVerbosity(v string) Option {
@@ -50,6 +277,23 @@ func WithVerbosity(v string) Option {
 	}
 }
 
+// WithAttributes keeps only the named keys in Event.Attributes. Keys
+// not present on a log are ignored. Default: all of Log.Metadata (for the
+// built-in connectors, a curated subset unless verbosity is "full").
+func WithAttributes(keys ...string) Option {
+	return func(o *options) {
+		o.attributes = keys
+	}
+}
+
+// WithoutAttributes removes the named keys from Event.Attributes, e.g. to
+// keep user ids or request bodies out of downstream prompts.
+func WithoutAttributes(keys ...string) Option {
+	return func(o *options) {
+		o.dropAttributes = keys
+	}
+}
+
 // WithAutoDownload enables automatic model and ONNX Runtime download.
 // On first call, downloads ~35-60MB of files to the OS cache directory
 // (or the directory specified by WithCacheDir). Subsequent calls reuse
@@ -72,10 +316,46 @@ func WithCacheDir(dir string) Option {
 	}
 }
 
+// WithTaxonomyFile loads the taxonomy from a JSON or YAML file instead of the
+// built-in tree. If the file sets extend: true, its roots and leaves are
+// merged into the built-in taxonomy rather than replacing it.
+// WithTaxonomyExtension is applied on top of the file's tree; combining it
+// with WithTaxonomy makes New return an error.
+func WithTaxonomyFile(path string) Option {
+	return func(o *options) {
+		o.taxonomyFile = path
+	}
+}
+
+// WithTaxonomy replaces the built-in taxonomy with the given categories.
+// Every label needs a Description (the text that gets embedded) and a
+// Severity of error, warning, info, or debug.
+func WithTaxonomy(categories []Category) Option {
+	return func(o *options) {
+		o.taxonomy = categories
+		o.extendTaxonomy = false
+	}
+}
+
+// WithTaxonomyExtension adds the given categories to the built-in taxonomy.
+// Categories matching a built-in root gain the extra labels; a label whose
+// path already exists replaces the built-in label.
+func WithTaxonomyExtension(categories []Category) Option {
+	return func(o *options) {
+		o.taxonomy = categories
+		o.extendTaxonomy = true
+	}
+}
+
 func defaultOptions() options {
 	return options{
 		confidenceThreshold: 0.5,
+		scoring:             "knn",
+		ambiguityMargin:     0.05,
+		sessions:            1,
 		verbosity:           "standard",
+		severityPolicy:      "taxonomy",
+		tokenCounter:        "heuristic",
 	}
 }
 
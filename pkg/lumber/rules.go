@@ -0,0 +1,46 @@
+package lumber
+
+import (
+	"github.com/kaminocorp/lumber/internal/engine/rules"
+)
+
+// Rule classifies logs matching all of its conditions directly, without
+// running the embedding model. Events from a rule have Method "rule".
+type Rule struct {
+	Name     string // Identifies the rule in errors
+	Path     string // Taxonomy label path, e.g. "ERROR.runtime_exception"
+	Severity string // Optional; overrides the label's severity
+
+	Pattern string   // Regular expression matched against the log text
+	Field   string   // Dot-separated JSON field that must exist, e.g. "level"
+	Equals  []string // Field value must equal one of these (case-insensitive)
+	Match   string   // Field value must match this regular expression
+}
+
+// resolveRules combines rules from WithRulesFile and WithRules, file rules
+// first. Returns nil when no rules are configured.
+func resolveRules(o options) (*rules.Set, error) {
+	var all []rules.Rule
+	if o.rulesFile != "" {
+		set, err := rules.LoadFile(o.rulesFile)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, set.Rules()...)
+	}
+	for _, r := range o.rules {
+		all = append(all, rules.Rule{
+			Name:     r.Name,
+			Path:     r.Path,
+			Severity: r.Severity,
+			Pattern:  r.Pattern,
+			Field:    r.Field,
+			Equals:   r.Equals,
+			Match:    r.Match,
+		})
+	}
+	if len(all) == 0 {
+		return nil, nil
+	}
+	return rules.New(all)
+}
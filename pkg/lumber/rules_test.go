@@ -0,0 +1,46 @@
+package lumber
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveRulesNone(t *testing.T) {
+	set, err := resolveRules(defaultOptions())
+	if err != nil || set != nil {
+		t.Fatalf("expected no rule set, got %v, %v", set, err)
+	}
+}
+
+func TestResolveRulesFileThenInline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	data := `{"rules":[{"name":"from-file","pattern":"boom","path":"ERROR.runtime_exception"}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	o := defaultOptions()
+	WithRulesFile(path)(&o)
+	WithRules([]Rule{{Name: "inline", Pattern: "boom|bang", Path: "ERROR.timeout"}})(&o)
+
+	set, err := resolveRules(o)
+	if err != nil {
+		t.Fatalf("resolveRules() error: %v", err)
+	}
+	if r, ok := set.Match("boom"); !ok || r.Name != "from-file" {
+		t.Errorf("file rules should be evaluated first, got %+v", r)
+	}
+	if r, ok := set.Match("bang"); !ok || r.Name != "inline" {
+		t.Errorf("expected inline rule match, got %+v", r)
+	}
+}
+
+func TestResolveRulesInvalid(t *testing.T) {
+	o := defaultOptions()
+	WithRules([]Rule{{Name: "bad", Pattern: "(", Path: "ERROR.timeout"}})(&o)
+	if _, err := resolveRules(o); err == nil || !strings.Contains(err.Error(), "rule bad") {
+		t.Fatalf("expected invalid rule error, got: %v", err)
+	}
+}
@@ -1,19 +1,34 @@
 package lumber
 
+import (
+	"fmt"
+
+	"github.com/kaminocorp/lumber/internal/corpus"
+	"github.com/kaminocorp/lumber/internal/engine/taxonomy"
+	"github.com/kaminocorp/lumber/internal/model"
+)
+
 // Category represents a taxonomy root category with its leaf labels.
 type Category struct {
-	Name   string  // Root name: ERROR, REQUEST, DEPLOY, etc.
-	Labels []Label // Leaf labels under this root
+	Name        string  // Root name: ERROR, REQUEST, DEPLOY, etc.
+	Description string  // Human-readable description of the category
+	Labels      []Label // Leaf labels under this root
 }
 
 // Label represents a single taxonomy leaf.
 type Label struct {
-	Name     string // e.g. "connection_failure"
-	Path     string // e.g. "ERROR.connection_failure"
-	Severity string // error, warning, info, debug
+	Name        string  // e.g. "connection_failure"
+	Path        string  // e.g. "ERROR.connection_failure"
+	Description string  // Text embedded for classification
+	Severity    string  // error, warning, info, debug
+	Threshold   float64 // Min confidence for this label; 0 = global threshold
+
+	Examples         []string // Example log lines embedded as extra prototypes
+	NegativeExamples []string // Similar-looking lines that belong elsewhere
 }
 
-// Taxonomy returns the current taxonomy tree. This is read-only —
+// Taxonomy returns the current taxonomy tree, including any custom
+// categories loaded via WithTaxonomy or WithTaxonomyFile. This is read-only —
 // consumers can inspect available categories but not modify them.
 func (l *Lumber) Taxonomy() []Category {
 	roots := l.taxonomy.Roots()
@@ -22,15 +37,85 @@ func (l *Lumber) Taxonomy() []Category {
 		labels := make([]Label, len(root.Children))
 		for j, child := range root.Children {
 			labels[j] = Label{
-				Name:     child.Name,
-				Path:     root.Name + "." + child.Name,
-				Severity: child.Severity,
+				Name:        child.Name,
+				Path:        root.Name + "." + child.Name,
+				Description: child.Desc,
+				Severity:    child.Severity,
+				Threshold:   child.Threshold,
+
+				Examples:         child.Examples,
+				NegativeExamples: child.NegativeExamples,
 			}
 		}
 		categories[i] = Category{
-			Name:   root.Name,
-			Labels: labels,
+			Name:        root.Name,
+			Description: root.Desc,
+			Labels:      labels,
 		}
 	}
 	return categories
 }
+
+// resolveTaxonomy builds the taxonomy tree from the configured options.
+// A taxonomy file may be combined with WithTaxonomyExtension (applied on top
+// of the file's tree) but not with WithTaxonomy, which would discard one of
+// the two replacement trees. Label.Path is ignored on input; paths are
+// derived from the names.
+func resolveTaxonomy(o options) ([]*model.TaxonomyNode, error) {
+	if o.taxonomyFile != "" && o.taxonomy != nil && !o.extendTaxonomy {
+		return nil, fmt.Errorf("WithTaxonomyFile and WithTaxonomy are mutually exclusive (use WithTaxonomyExtension to add to a file taxonomy)")
+	}
+
+	base := taxonomy.DefaultRoots()
+	if o.taxonomyFile != "" {
+		var err error
+		base, err = taxonomy.LoadFile(o.taxonomyFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.taxonomy == nil {
+		return seedExamples(o, base)
+	}
+
+	roots := make([]*model.TaxonomyNode, len(o.taxonomy))
+	for i, cat := range o.taxonomy {
+		root := &model.TaxonomyNode{Name: cat.Name, Desc: cat.Description}
+		for _, lbl := range cat.Labels {
+			root.Children = append(root.Children, &model.TaxonomyNode{
+				Name:             lbl.Name,
+				Desc:             lbl.Description,
+				Severity:         lbl.Severity,
+				Threshold:        lbl.Threshold,
+				Examples:         lbl.Examples,
+				NegativeExamples: lbl.NegativeExamples,
+			})
+		}
+		roots[i] = root
+	}
+	if o.extendTaxonomy {
+		// Reject duplicates in the caller's categories before Merge, which
+		// would otherwise let the later entry silently replace the earlier one.
+		if err := taxonomy.CheckDuplicates(roots); err != nil {
+			return nil, fmt.Errorf("invalid taxonomy: %w", err)
+		}
+		roots = taxonomy.Merge(base, roots)
+	}
+	if err := taxonomy.Validate(roots); err != nil {
+		return nil, err
+	}
+	return seedExamples(o, roots)
+}
+
+// seedExamples adds the built-in labeled corpus to roots when
+// WithCorpusExamples is set.
+func seedExamples(o options, roots []*model.TaxonomyNode) ([]*model.TaxonomyNode, error) {
+	if !o.corpusExamples {
+		return roots, nil
+	}
+	examples, err := corpus.Examples()
+	if err != nil {
+		return nil, err
+	}
+	return taxonomy.SeedExamples(roots, examples), nil
+}
@@ -1,6 +1,9 @@
 package lumber
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/kaminocorp/lumber/internal/engine/taxonomy"
@@ -100,3 +103,129 @@ func TestTaxonomyPathFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestResolveTaxonomyDefault(t *testing.T) {
+	roots, err := resolveTaxonomy(defaultOptions())
+	if err != nil {
+		t.Fatalf("resolveTaxonomy() error: %v", err)
+	}
+	if len(roots) != 8 {
+		t.Fatalf("expected 8 default roots, got %d", len(roots))
+	}
+}
+
+func TestResolveTaxonomyReplaceAndExtend(t *testing.T) {
+	custom := []Category{{
+		Name:        "PAYMENTS",
+		Description: "Payment processing events",
+		Labels: []Label{
+			{Name: "chargeback_failed", Description: "Chargeback dispute failed", Severity: "error"},
+		},
+	}}
+
+	o := defaultOptions()
+	WithTaxonomy(custom)(&o)
+	roots, err := resolveTaxonomy(o)
+	if err != nil {
+		t.Fatalf("replace: resolveTaxonomy() error: %v", err)
+	}
+	if len(roots) != 1 || roots[0].Name != "PAYMENTS" {
+		t.Fatalf("replace: unexpected roots %+v", roots)
+	}
+
+	o = defaultOptions()
+	WithTaxonomyExtension(custom)(&o)
+	roots, err = resolveTaxonomy(o)
+	if err != nil {
+		t.Fatalf("extend: resolveTaxonomy() error: %v", err)
+	}
+	if len(roots) != 9 || roots[8].Name != "PAYMENTS" {
+		t.Fatalf("extend: expected 9 roots ending in PAYMENTS, got %d", len(roots))
+	}
+}
+
+func TestResolveTaxonomyInvalid(t *testing.T) {
+	o := defaultOptions()
+	WithTaxonomy([]Category{{
+		Name:        "PAYMENTS",
+		Description: "Payments",
+		Labels:      []Label{{Name: "chargeback_failed", Severity: "fatal"}},
+	}})(&o)
+	if _, err := resolveTaxonomy(o); err == nil {
+		t.Fatal("expected validation error for empty description and unknown severity")
+	}
+}
+
+func TestResolveTaxonomyExtensionDuplicates(t *testing.T) {
+	tests := []struct {
+		name string
+		cats []Category
+	}{
+		{"duplicate label", []Category{{
+			Name: "ERROR",
+			Labels: []Label{
+				{Name: "quota_exhausted", Description: "Quota exhausted", Severity: "warning"},
+				{Name: "quota_exhausted", Description: "Quota exhausted again", Severity: "error"},
+			},
+		}}},
+		{"duplicate category", []Category{
+			{Name: "PAYMENTS", Description: "Payments", Labels: []Label{{Name: "a", Description: "a", Severity: "info"}}},
+			{Name: "PAYMENTS", Description: "Payments", Labels: []Label{{Name: "b", Description: "b", Severity: "info"}}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := defaultOptions()
+			WithTaxonomyExtension(tt.cats)(&o)
+			_, err := resolveTaxonomy(o)
+			if err == nil || !strings.Contains(err.Error(), "duplicate") {
+				t.Fatalf("expected duplicate error, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveTaxonomyFileCombinations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "taxonomy.json")
+	data := `{"roots":[{"name":"A","description":"a","leaves":[{"name":"x","description":"x","severity":"info"}]}]}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	extra := []Category{{Name: "B", Description: "b", Labels: []Label{{Name: "y", Description: "y", Severity: "info"}}}}
+
+	o := defaultOptions()
+	WithTaxonomyFile(path)(&o)
+	WithTaxonomy(extra)(&o)
+	if _, err := resolveTaxonomy(o); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected mutually exclusive error, got: %v", err)
+	}
+
+	o = defaultOptions()
+	WithTaxonomyFile(path)(&o)
+	WithTaxonomyExtension(extra)(&o)
+	roots, err := resolveTaxonomy(o)
+	if err != nil {
+		t.Fatalf("resolveTaxonomy() error: %v", err)
+	}
+	if len(roots) != 2 || roots[0].Name != "A" || roots[1].Name != "B" {
+		t.Fatalf("expected extension applied on top of file tree, got %d roots", len(roots))
+	}
+}
+
+func TestResolveTaxonomyCorpusExamples(t *testing.T) {
+	o := defaultOptions()
+	o.corpusExamples = true
+	roots, err := resolveTaxonomy(o)
+	if err != nil {
+		t.Fatalf("resolveTaxonomy() error: %v", err)
+	}
+	seeded := 0
+	for _, root := range roots {
+		for _, leaf := range root.Children {
+			seeded += len(leaf.Examples)
+		}
+	}
+	if seeded == 0 {
+		t.Fatal("expected corpus examples on the default taxonomy")
+	}
+}